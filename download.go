@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/cdelmoral/apexlogs/internal/app"
+	"github.com/cdelmoral/apexlogs/internal/download"
+	sf "github.com/cdelmoral/apexlogs/internal/salesforce"
+)
+
+// downloadCmd runs the download subcommand and returns the exit code.
+func downloadCmd(args []string) int {
+	opts := app.DownloadOptions{Options: loadOptions()}
+	fs := flag.NewFlagSet("download", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: apexlogs download [flags]")
+		fmt.Fprintln(fs.Output(), "\nDownloads the apex logs of the default org into a directory.")
+		fmt.Fprintln(fs.Output(), "Logs already in the directory are skipped, so an interrupted download can be resumed.")
+		fmt.Fprintln(fs.Output())
+		fs.PrintDefaults()
+	}
+	fs.StringVar(&opts.DownloadDir, "dir", opts.DownloadDir, "directory the logs are downloaded to")
+	fs.StringVar(&opts.CacheDir, "cache-dir", opts.CacheDir, "directory of the local log cache, empty to disable it")
+	fs.IntVar(&opts.Workers, "workers", download.DefaultWorkers, "number of logs downloaded concurrently")
+	fs.StringVar(&opts.Filter.Operation, "operation", "", "only download logs of this operation, % matches any text")
+	fs.StringVar(&opts.Filter.Status, "status", "", "only download logs with this status, e.g. Success")
+	fs.IntVar(&opts.Filter.Limit, "limit", sf.DefaultApexLogsLimit, fmt.Sprintf("maximum number of logs to download (max %d)", sf.MaxApexLogsLimit))
+	since := fs.Duration("since", 0, "only download logs started within this duration, e.g. 24h")
+	fs.Parse(args)
+
+	if opts.Workers < 1 {
+		fmt.Fprintln(os.Stderr, "fatal: workers must be at least 1")
+		return 2
+	}
+	if *since > 0 {
+		opts.Filter.Since = time.Now().Add(-*since)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if err := app.Download(ctx, opts, os.Stderr); err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return 1
+	}
+	return 0
+}
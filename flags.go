@@ -0,0 +1,45 @@
+package main
+
+import (
+	"errors"
+	"flag"
+
+	"github.com/cdelmoral/apexlogs/internal/app"
+	"github.com/cdelmoral/apexlogs/internal/traceflag"
+)
+
+// orgFlags are the flags of the commands that open the application connected
+// to an org, which manage its trace flag.
+type orgFlags struct {
+	opts    *app.Options
+	cleanup string
+}
+
+// addOrgFlags registers the flags setting opts in fs. [orgFlags.validate]
+// must be called once fs is parsed.
+func addOrgFlags(fs *flag.FlagSet, opts *app.Options) *orgFlags {
+	f := &orgFlags{opts: opts}
+	fs.DurationVar(&opts.TraceFlagDuration, "trace-duration", opts.TraceFlagDuration, "how long the trace flag stays active after every renewal (max 24h)")
+	fs.StringVar(&f.cleanup, "trace-cleanup", string(opts.TraceFlagCleanup), "what to do with the trace flag on exit: none, expire or delete")
+	fs.DurationVar(&opts.Timeout, "timeout", opts.Timeout, "maximum duration of a request to the org")
+	fs.DurationVar(&opts.BodyTimeout, "body-timeout", opts.BodyTimeout, "maximum duration of a request downloading a log")
+	fs.StringVar(&opts.CacheDir, "cache-dir", opts.CacheDir, "directory of the local log cache, empty to disable it")
+	fs.StringVar(&opts.DownloadDir, "download-dir", opts.DownloadDir, "directory the listed logs are downloaded to")
+	return f
+}
+
+// validate checks the values of the flags, setting the ones that need parsing in the options.
+func (f *orgFlags) validate() error {
+	if err := traceflag.ValidateDuration(f.opts.TraceFlagDuration); err != nil {
+		return err
+	}
+	if f.opts.Timeout <= 0 || f.opts.BodyTimeout <= 0 {
+		return errors.New("timeouts must be positive")
+	}
+	cleanup, err := traceflag.ParseCleanup(f.cleanup)
+	if err != nil {
+		return err
+	}
+	f.opts.TraceFlagCleanup = cleanup
+	return nil
+}
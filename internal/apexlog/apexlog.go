@@ -0,0 +1,107 @@
+// Package apexlog parses the content of Salesforce debug logs.
+//
+// Every event of a debug log is a line like
+//
+//	15:50:17.5 (5559211)|CODE_UNIT_STARTED|[EXTERNAL]|DuplicateDetector
+//
+// made of the time of the event, the nanoseconds elapsed since the start of
+// the request in parentheses, the event type and its pipe separated fields.
+// Events with multi-line values, like USER_DEBUG, continue on the next lines.
+package apexlog
+
+import (
+	"strings"
+)
+
+// A Span is the byte range [Start, End) of a part of a line.
+type Span struct {
+	Start int
+	End   int
+}
+
+// Text returns the part of line covered by the span.
+func (s Span) Text(line string) string {
+	return line[s.Start:s.End]
+}
+
+// A Line is the location of the parts of an event line.
+type Line struct {
+	Time  Span
+	Nanos Span
+	Event Span
+	// Fields are the fields of the event, not including the separators.
+	Fields []Span
+}
+
+// EventType returns the event type of the line, e.g. USER_DEBUG.
+func (l Line) EventType(line string) string {
+	return l.Event.Text(line)
+}
+
+// ParseLine parses an event line.
+// It returns false for the header of the log and the continuation lines of
+// multi-line values, which are not events.
+func ParseLine(s string) (Line, bool) {
+	sep := strings.IndexByte(s, '|')
+	if sep < 0 {
+		return Line{}, false
+	}
+
+	// The prefix is the time and the nanoseconds, like "15:50:17.5 (5559211)".
+	space := strings.IndexByte(s[:sep], ' ')
+	if space < 0 || !isTime(s[:space]) || !isNanos(s[space+1:sep]) {
+		return Line{}, false
+	}
+
+	l := Line{
+		Time:  Span{0, space},
+		Nanos: Span{space + 1, sep},
+	}
+
+	start := sep + 1
+	for i := start; i <= len(s); i++ {
+		if i < len(s) && s[i] != '|' {
+			continue
+		}
+		if start == sep+1 {
+			l.Event = Span{start, i}
+		} else {
+			l.Fields = append(l.Fields, Span{start, i})
+		}
+		start = i + 1
+	}
+	if l.Event.Start == l.Event.End {
+		return Line{}, false
+	}
+	return l, true
+}
+
+// isTime reports whether s is a time like 15:50:17.5.
+func isTime(s string) bool {
+	if len(s) < 10 || s[2] != ':' || s[5] != ':' || s[8] != '.' {
+		return false
+	}
+	for i := range len(s) {
+		if i != 2 && i != 5 && i != 8 && !isDigit(s[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// isNanos reports whether s is a number in parentheses like (5559211).
+func isNanos(s string) bool {
+	if len(s) < 3 || s[0] != '(' || s[len(s)-1] != ')' {
+		return false
+	}
+	for i := 1; i < len(s)-1; i++ {
+		if !isDigit(s[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func isDigit(b byte) bool {
+	return b >= '0' && b <= '9'
+}
@@ -0,0 +1,167 @@
+package apexlog
+
+import (
+	"testing"
+)
+
+func TestParseLine(t *testing.T) {
+	line := "15:50:17.5 (5559211)|CODE_UNIT_STARTED|[EXTERNAL]|DuplicateDetector"
+	l, ok := ParseLine(line)
+	if !ok {
+		t.Fatalf("expected %q to be an event line", line)
+	}
+	if got := l.Time.Text(line); got != "15:50:17.5" {
+		t.Errorf("unexpected time %q", got)
+	}
+	if got := l.Nanos.Text(line); got != "(5559211)" {
+		t.Errorf("unexpected nanos %q", got)
+	}
+	if got := l.EventType(line); got != "CODE_UNIT_STARTED" {
+		t.Errorf("unexpected event type %q", got)
+	}
+	if len(l.Fields) != 2 || l.Fields[0].Text(line) != "[EXTERNAL]" || l.Fields[1].Text(line) != "DuplicateDetector" {
+		t.Errorf("unexpected fields %v", l.Fields)
+	}
+}
+
+func TestParseLineWithoutFields(t *testing.T) {
+	line := "15:50:17.5 (5534320)|EXECUTION_STARTED"
+	l, ok := ParseLine(line)
+	if !ok || l.EventType(line) != "EXECUTION_STARTED" || len(l.Fields) != 0 {
+		t.Errorf("unexpected parsed line %+v", l)
+	}
+
+	line = "15:50:17.5 (5534320)|DmlType:|"
+	l, ok = ParseLine(line)
+	if !ok || len(l.Fields) != 1 || l.Fields[0].Text(line) != "" {
+		t.Errorf("expected a trailing empty field, got %+v", l)
+	}
+}
+
+func TestParseLineNotAnEvent(t *testing.T) {
+	for _, line := range []string{
+		"",
+		"61.0 APEX_CODE,FINEST;APEX_PROFILING,INFO",
+		"a continuation | of a debug message",
+		"15:50:17.5 5534320|EXECUTION_STARTED",
+		"15:50:17.5 (5534320)|",
+	} {
+		if _, ok := ParseLine(line); ok {
+			t.Errorf("expected %q not to be an event line", line)
+		}
+	}
+}
+
+func TestCategoryOf(t *testing.T) {
+	tests := map[string]Category{
+		"SOQL_EXECUTE_BEGIN":          CategorySOQL,
+		"DML_END":                     CategoryDML,
+		"USER_DEBUG":                  CategoryDebug,
+		"EXCEPTION_THROWN":            CategoryException,
+		"FATAL_ERROR":                 CategoryException,
+		"LIMIT_USAGE_FOR_NS":          CategoryLimit,
+		"CUMULATIVE_LIMIT_USAGE":      CategoryLimit,
+		"CODE_UNIT_STARTED":           CategoryCodeUnit,
+		"METHOD_ENTRY":                CategoryMethod,
+		"SYSTEM_METHOD_EXIT":          CategoryMethod,
+		"VALIDATION_RULE":             CategoryValidation,
+		"FLOW_START_INTERVIEWS_BEGIN": CategoryFlow,
+		"HEAP_ALLOCATE":               CategoryOther,
+	}
+	for event, want := range tests {
+		if got := CategoryOf(event); got != want {
+			t.Errorf("CategoryOf(%q) = %s, want %s", event, got, want)
+		}
+	}
+}
+
+func TestParseTruncation(t *testing.T) {
+	tests := []struct {
+		line    string
+		skipped int
+		ok      bool
+	}{
+		{"*** Skipped 1234567 bytes of detailed log", 1234567, true},
+		{"*********** MAXIMUM DEBUG LOG SIZE REACHED ***********", 0, true},
+		{"12:00:00.0 (1)|USER_DEBUG|[1]|DEBUG|*** Skipped 12 bytes of detailed log", 0, false},
+		{"*** not a marker", 0, false},
+	}
+	for _, tt := range tests {
+		skipped, ok := ParseTruncation(tt.line)
+		if skipped != tt.skipped || ok != tt.ok {
+			t.Errorf("ParseTruncation(%q) = %d, %t, want %d, %t", tt.line, skipped, ok, tt.skipped, tt.ok)
+		}
+	}
+
+	if !IsTruncated("61.0 APEX_CODE,FINEST\n*** Skipped 12 bytes of detailed log\n") {
+		t.Errorf("expected the body to be truncated")
+	}
+	if IsTruncated("61.0 APEX_CODE,FINEST\n12:00:00.0 (1)|USER_DEBUG|[1]|DEBUG|hello") {
+		t.Errorf("expected the body not to be truncated")
+	}
+	if IsTruncated("61.0 APEX_CODE,FINEST\n12:00:00.0 (1)|USER_DEBUG|[1]|DEBUG|MAXIMUM DEBUG LOG SIZE REACHED") {
+		t.Errorf("expected a marker inside an event not to truncate the body")
+	}
+}
+
+func TestLocationClass(t *testing.T) {
+	tests := []struct {
+		loc       Location
+		namespace string
+		want      string
+	}{
+		{Location{Name: "AccountService"}, "", "AccountService"},
+		{Location{Name: "AccountService.Helper"}, "", "AccountService"},
+		{Location{Name: "acme.AccountService"}, "acme", "AccountService"},
+		{Location{Name: "ACME.AccountService.Helper"}, "acme", "AccountService"},
+		{Location{Name: "AccountService.Helper"}, "acme", "AccountService"},
+		{Location{Name: "acme.AccountTrigger", Trigger: true}, "acme", "acme.AccountTrigger"},
+	}
+	for _, tt := range tests {
+		if got := tt.loc.Class(tt.namespace); got != tt.want {
+			t.Errorf("%+v.Class(%q) = %q, want %q", tt.loc, tt.namespace, got, tt.want)
+		}
+	}
+}
+
+func TestSourceLocation(t *testing.T) {
+	lines := []string{
+		"61.0 APEX_CODE,FINEST",
+		"10:00:00.0 (1)|CODE_UNIT_STARTED|[EXTERNAL]|execute_anonymous_apex",
+		"10:00:00.0 (2)|METHOD_ENTRY|[3]|01p000000000001|AccountService.doWork()",
+		"10:00:00.0 (3)|USER_DEBUG|[12]|DEBUG|first line",
+		"second line",
+		"10:00:00.0 (4)|METHOD_ENTRY|[14]|01p000000000001|AccountService.Helper.run(Id)",
+		"10:00:00.0 (5)|METHOD_EXIT|[14]|01p000000000001|AccountService.Helper.run(Id)",
+		"10:00:00.0 (6)|SOQL_EXECUTE_BEGIN|[20]|Aggregations:0|SELECT Id FROM Account",
+		"10:00:00.0 (7)|CODE_UNIT_STARTED|[EXTERNAL]|01q000000000001|AccountTrigger on Account trigger event BeforeInsert|__sfdc_trigger/AccountTrigger",
+		"10:00:00.0 (8)|DML_BEGIN|[5]|Op:Update|Type:Account|Rows:1",
+		"10:00:00.0 (9)|CODE_UNIT_FINISHED|AccountTrigger on Account trigger event BeforeInsert",
+		"10:00:00.0 (10)|CONSTRUCTOR_ENTRY|[22]|01p000000000002|<init>()|Invoice",
+		"10:00:00.0 (11)|STATEMENT_EXECUTE|[8]",
+		"10:00:00.0 (12)|METHOD_ENTRY|[9]|01p000000000003|acme.Billing.Invoice.total()",
+		"10:00:00.0 (13)|STATEMENT_EXECUTE|[31]",
+	}
+	tests := map[int]Location{
+		2:  {},
+		3:  {Name: "AccountService", Line: 12},
+		4:  {Name: "AccountService", Line: 12},
+		5:  {Name: "AccountService", Line: 14},
+		7:  {Name: "AccountService", Line: 20},
+		9:  {Name: "AccountTrigger", Trigger: true, Line: 5},
+		11: {Name: "AccountService", Line: 22},
+		12: {Name: "Invoice", Line: 8},
+		14: {Name: "acme.Billing.Invoice", Line: 31},
+	}
+	for i, want := range tests {
+		got, ok := SourceLocation(lines, i)
+		if ok != (want != Location{}) || got != want {
+			t.Errorf("line %d: expected %+v, got %+v (%t)", i, want, got, ok)
+		}
+	}
+	for _, i := range []int{0, 1, 10} {
+		if loc, ok := SourceLocation(lines, i); ok {
+			t.Errorf("line %d: expected no location, got %+v", i, loc)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package apexlog
+
+import (
+	"strings"
+)
+
+// A Category groups related event types.
+type Category int
+
+const (
+	CategoryOther Category = iota
+	CategorySOQL
+	CategoryDML
+	CategoryDebug
+	CategoryException
+	CategoryLimit
+	CategoryCodeUnit
+	CategoryMethod
+	CategoryValidation
+	CategoryFlow
+)
+
+var categoryNames = map[Category]string{
+	CategoryOther:      "Other",
+	CategorySOQL:       "SOQL",
+	CategoryDML:        "DML",
+	CategoryDebug:      "Debug",
+	CategoryException:  "Exception",
+	CategoryLimit:      "Limit",
+	CategoryCodeUnit:   "Code unit",
+	CategoryMethod:     "Method",
+	CategoryValidation: "Validation",
+	CategoryFlow:       "Flow",
+}
+
+func (c Category) String() string {
+	return categoryNames[c]
+}
+
+// categoryPrefixes maps the prefixes of the event types to their category.
+// Longer prefixes must come first when they overlap.
+var categoryPrefixes = []struct {
+	prefix   string
+	category Category
+}{
+	{"SOQL_", CategorySOQL},
+	{"SOSL_", CategorySOQL},
+	{"QUERY_MORE_", CategorySOQL},
+	{"DML_", CategoryDML},
+	{"USER_DEBUG", CategoryDebug},
+	{"EXCEPTION_", CategoryException},
+	{"FATAL_ERROR", CategoryException},
+	{"LIMIT_USAGE", CategoryLimit},
+	{"CUMULATIVE_LIMIT_USAGE", CategoryLimit},
+	{"CUMULATIVE_PROFILING", CategoryLimit},
+	{"TESTING_LIMITS", CategoryLimit},
+	{"CODE_UNIT_", CategoryCodeUnit},
+	{"EXECUTION_", CategoryCodeUnit},
+	{"METHOD_", CategoryMethod},
+	{"CONSTRUCTOR_", CategoryMethod},
+	{"SYSTEM_METHOD_", CategoryMethod},
+	{"SYSTEM_CONSTRUCTOR_", CategoryMethod},
+	{"VALIDATION_", CategoryValidation},
+	{"FLOW_", CategoryFlow},
+	{"WF_", CategoryFlow},
+}
+
+// CategoryOf returns the category of the given event type.
+func CategoryOf(eventType string) Category {
+	for _, p := range categoryPrefixes {
+		if strings.HasPrefix(eventType, p.prefix) {
+			return p.category
+		}
+	}
+	return CategoryOther
+}
@@ -0,0 +1,145 @@
+package apexlog
+
+import (
+	"strconv"
+	"strings"
+)
+
+// triggerPrefix precedes the name of a trigger in its CODE_UNIT_STARTED event.
+const triggerPrefix = "__sfdc_trigger/"
+
+// A Location is a line of the source of an Apex class or trigger.
+type Location struct {
+	// Name is the name of the trigger or of the class, qualified like in the
+	// log by its outer class and namespace, e.g. ns.AccountService.Helper.
+	// See [Location.Class] for the class the source file is named after.
+	Name    string
+	Trigger bool
+	// Line is the one based line number in the source.
+	Line int
+}
+
+// SourceLocation returns the location in the Apex source of the event on the
+// zero based line i, for events with a line number like
+//
+//	15:50:17.5 (5559211)|USER_DEBUG|[45]|DEBUG|message
+//
+// The line number belongs to the class or trigger being executed, found by
+// going back to the method or code unit the event is in, so the line of a
+// METHOD_ENTRY is the line of the call. Continuation lines are located by the
+// event they belong to. It returns false when the event has no line number or
+// runs in code without a source, like anonymous Apex.
+func SourceLocation(lines []string, i int) (Location, bool) {
+	for ; i >= 0; i-- {
+		if _, ok := ParseLine(lines[i]); ok {
+			break
+		}
+	}
+	if i < 0 {
+		return Location{}, false
+	}
+
+	l, _ := ParseLine(lines[i])
+	if len(l.Fields) == 0 {
+		return Location{}, false
+	}
+	n, ok := parseLineNumber(l.Fields[0].Text(lines[i]))
+	if !ok {
+		return Location{}, false
+	}
+
+	// Frames closed after the enclosing one was entered are skipped.
+	depth := 0
+	for i--; i >= 0; i-- {
+		l, ok := ParseLine(lines[i])
+		if !ok {
+			continue
+		}
+		switch l.EventType(lines[i]) {
+		case "CODE_UNIT_FINISHED", "METHOD_EXIT", "CONSTRUCTOR_EXIT":
+			depth++
+		case "CODE_UNIT_STARTED", "METHOD_ENTRY", "CONSTRUCTOR_ENTRY":
+			if depth > 0 {
+				depth--
+				continue
+			}
+			loc, ok := frameSource(lines[i], l)
+			if !ok {
+				return Location{}, false
+			}
+			loc.Line = n
+			return loc, true
+		}
+	}
+	return Location{}, false
+}
+
+// frameSource returns the class or trigger entered by the event l, like
+//
+//	METHOD_ENTRY|[4]|01p...|AccountService.doWork()
+//	CONSTRUCTOR_ENTRY|[4]|01p...|<init>()|AccountService
+//	CODE_UNIT_STARTED|[EXTERNAL]|01q...|AccountTrigger on Account trigger event BeforeInsert|__sfdc_trigger/AccountTrigger
+func frameSource(line string, l Line) (Location, bool) {
+	if len(l.Fields) == 0 {
+		return Location{}, false
+	}
+	last := l.Fields[len(l.Fields)-1].Text(line)
+	if name, ok := strings.CutPrefix(last, triggerPrefix); ok {
+		return Location{Name: name, Trigger: true}, true
+	}
+
+	if l.EventType(line) == "CONSTRUCTOR_ENTRY" {
+		return className(last)
+	}
+	// Methods are qualified by their class, e.g. Outer.Inner.method(Id).
+	paren := strings.IndexByte(last, '(')
+	if paren < 0 {
+		return Location{}, false
+	}
+	dot := strings.LastIndexByte(last[:paren], '.')
+	if dot < 0 {
+		return Location{}, false
+	}
+	return className(last[:dot])
+}
+
+// className returns the location of a qualified class name like ns.Outer.Inner.
+func className(s string) (Location, bool) {
+	for _, part := range strings.Split(s, ".") {
+		if part == "" || strings.ContainsAny(part, " <>:") {
+			return Location{}, false
+		}
+	}
+	return Location{Name: s}, true
+}
+
+// Class returns the top level class of the location, which is the one the
+// source file is named after, removing the given namespace from its name.
+// Names are qualified by the namespace of their package, which is ambiguous
+// with an outer class, so the namespace of the project is needed.
+// The name of a trigger is returned unchanged.
+func (l Location) Class(namespace string) string {
+	if l.Trigger {
+		return l.Name
+	}
+	name := l.Name
+	if ns, rest, ok := strings.Cut(name, "."); ok && namespace != "" && strings.EqualFold(ns, namespace) {
+		name = rest
+	}
+	name, _, _ = strings.Cut(name, ".")
+	return name
+}
+
+// parseLineNumber parses a line number field like [45].
+func parseLineNumber(s string) (int, bool) {
+	s, ok := strings.CutPrefix(s, "[")
+	if !ok {
+		return 0, false
+	}
+	s, ok = strings.CutSuffix(s, "]")
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.Atoi(s)
+	return n, err == nil && n > 0
+}
@@ -0,0 +1,43 @@
+package apexlog
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Salesforce replaces the events that do not fit in the maximum size of a log
+// with these markers.
+const (
+	skippedPrefix = "*** Skipped "
+	skippedSuffix = " bytes of detailed log"
+	maxSizeMarker = "MAXIMUM DEBUG LOG SIZE REACHED"
+)
+
+// ParseTruncation reports whether the line is one of the markers of a log
+// truncated by Salesforce, returning the number of skipped bytes, or 0 when
+// the marker does not tell.
+func ParseTruncation(line string) (skipped int, ok bool) {
+	// Both markers start with asterisks, which events never do.
+	if !strings.HasPrefix(line, "*") {
+		return 0, false
+	}
+	if n, found := strings.CutPrefix(line, skippedPrefix); found {
+		n, _, _ = strings.Cut(n, skippedSuffix)
+		skipped, err := strconv.Atoi(n)
+		return skipped, err == nil
+	}
+	return 0, strings.Contains(line, maxSizeMarker)
+}
+
+// IsTruncated reports whether the body of a log was truncated by Salesforce,
+// that is whether one of its lines is a marker accepted by [ParseTruncation].
+func IsTruncated(body string) bool {
+	for rest := body; rest != ""; {
+		var line string
+		line, rest, _ = strings.Cut(rest, "\n")
+		if _, ok := ParseTruncation(line); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,175 @@
+// Package apextest runs the tests of Apex classes in a Salesforce org and
+// follows them until they finish, linking every test result to the Apex Log
+// it generated.
+package apextest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	sf "github.com/cdelmoral/apexlogs/internal/salesforce"
+)
+
+// DefaultPollInterval is how often the state of the tests is checked when no interval is given.
+const DefaultPollInterval = 2 * time.Second
+
+// A Result is the outcome of a test method.
+type Result struct {
+	Class      string
+	Method     string
+	Outcome    string
+	Message    string
+	StackTrace string
+	RunTime    time.Duration
+	// LogId is the id of the Apex Log the test generated, empty if it was not traced.
+	LogId string
+}
+
+// Name returns the name of the test like Class.method.
+func (r Result) Name() string {
+	return r.Class + "." + r.Method
+}
+
+// Passed reports whether the test passed.
+func (r Result) Passed() bool {
+	return r.Outcome == sf.TestOutcomePass
+}
+
+// Progress reports the state of a test run.
+type Progress struct {
+	JobId string
+	// Classes is the number of classes enqueued, Finished of them are no longer running.
+	Classes  int
+	Finished int
+	// Results are the outcomes of the test methods run so far, in the order they ran.
+	Results []Result
+	// Errors describe the classes whose tests could not run, like when they do not compile.
+	Errors []string
+}
+
+// Done reports whether every class finished running.
+func (p Progress) Done() bool {
+	return p.Classes > 0 && p.Finished == p.Classes
+}
+
+// Passed returns the number of tests that passed.
+func (p Progress) Passed() int {
+	n := 0
+	for _, r := range p.Results {
+		if r.Passed() {
+			n++
+		}
+	}
+	return n
+}
+
+// Failed returns the number of tests that did not pass.
+func (p Progress) Failed() int {
+	return len(p.Results) - p.Passed()
+}
+
+// A Runner runs Apex tests asynchronously.
+type Runner struct {
+	client       *sf.Client
+	pollInterval time.Duration
+}
+
+// An Option configures a [Runner].
+type Option func(*Runner)
+
+// WithPollInterval sets how often the state of the tests is checked.
+func WithPollInterval(d time.Duration) Option {
+	return func(r *Runner) {
+		if d > 0 {
+			r.pollInterval = d
+		}
+	}
+}
+
+// New creates a [Runner] running the tests with the given client.
+func New(client *sf.Client, opts ...Option) *Runner {
+	r := &Runner{client: client, pollInterval: DefaultPollInterval}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Run enqueues the tests of the given classes and waits for them to finish.
+// progress, if not nil, is called after every check from the calling goroutine.
+//
+// Failed tests are reported by the results, an error is returned only when
+// the tests cannot be enqueued or checked, or when the org has no queued
+// class for the run, which would otherwise never finish. When ctx is cancelled the tests
+// keep running in the org and the context error is returned.
+func (r *Runner) Run(ctx context.Context, classes []string, progress func(Progress)) (Progress, error) {
+	if len(classes) == 0 {
+		return Progress{}, errors.New("no test classes given")
+	}
+	jobId, err := sf.RunTestsAsynchronous(ctx, r.client, classes)
+	if err != nil {
+		return Progress{}, err
+	}
+
+	for {
+		p, err := r.check(ctx, jobId)
+		if err != nil {
+			return p, err
+		}
+		if p.Classes == 0 {
+			return p, fmt.Errorf("no tests queued for the test run %s", jobId)
+		}
+		if progress != nil {
+			progress(p)
+		}
+		if p.Done() {
+			return p, nil
+		}
+
+		t := time.NewTimer(r.pollInterval)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return p, ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// check returns the state of the test run with the given job id.
+func (r *Runner) check(ctx context.Context, jobId string) (Progress, error) {
+	p := Progress{JobId: jobId}
+	items, err := sf.DoQuery[sf.ApexTestQueueItem](ctx, r.client, sf.SelectApexTestQueueItems(jobId))
+	if err != nil {
+		return p, fmt.Errorf("error getting the state of the tests: %w", err)
+	}
+	p.Classes = len(items.Records)
+	for _, i := range items.Records {
+		if !i.Finished() {
+			continue
+		}
+		p.Finished++
+		if i.Status != sf.TestQueueCompleted {
+			p.Errors = append(p.Errors, fmt.Sprintf("%s: %s %s", i.ApexClass.Name, i.Status, i.ExtendedStatus))
+		}
+	}
+
+	results, err := sf.DoQuery[sf.ApexTestResult](ctx, r.client, sf.SelectApexTestResults(jobId))
+	if err != nil {
+		return p, fmt.Errorf("error getting the test results: %w", err)
+	}
+	for _, res := range results.Records {
+		p.Results = append(p.Results, Result{
+			Class:      res.ApexClass.Name,
+			Method:     res.MethodName,
+			Outcome:    res.Outcome,
+			Message:    res.Message,
+			StackTrace: res.StackTrace,
+			RunTime:    time.Duration(res.RunTime) * time.Millisecond,
+			LogId:      res.ApexLogId,
+		})
+	}
+	return p, nil
+}
@@ -0,0 +1,111 @@
+package apextest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	sf "github.com/cdelmoral/apexlogs/internal/salesforce"
+	"github.com/cdelmoral/apexlogs/internal/salesforce/sftest"
+)
+
+func TestRun(t *testing.T) {
+	srv := sftest.New(sftest.FixturesDir())
+	srv.SetRunTests(func(class string) []sftest.TestMethod {
+		return []sftest.TestMethod{
+			{Name: "passes", Outcome: sf.TestOutcomePass, Log: "passes log"},
+			{Name: "fails", Outcome: sf.TestOutcomeFail, Message: "System.AssertException: Assertion Failed", StackTrace: "Class." + class + ".fails: line 12, column 1"},
+		}
+	})
+
+	var updates int
+	p, err := New(srv.Client()).Run(context.Background(), []string{"AccountTest"}, func(Progress) { updates++ })
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !p.Done() || updates != 1 {
+		t.Errorf("expected the run to be done after the first check, got %+v after %d updates", p, updates)
+	}
+	if len(p.Results) != 2 || p.Passed() != 1 || p.Failed() != 1 {
+		t.Fatalf("unexpected results %+v", p.Results)
+	}
+
+	passed, failed := p.Results[0], p.Results[1]
+	if passed.Name() != "AccountTest.passes" || passed.RunTime != 10*time.Millisecond {
+		t.Errorf("unexpected result %+v", passed)
+	}
+	body, err := sf.GetSObjectBody(context.Background(), srv.Client(), "ApexLog", passed.LogId)
+	if err != nil || body != "passes log" {
+		t.Errorf("expected the result to be linked to its log, got %q, %v", body, err)
+	}
+	if failed.Passed() || failed.LogId != "" || failed.Message != "System.AssertException: Assertion Failed" {
+		t.Errorf("unexpected result %+v", failed)
+	}
+}
+
+func TestRunWithoutClasses(t *testing.T) {
+	srv := sftest.New(sftest.FixturesDir())
+	if _, err := New(srv.Client()).Run(context.Background(), nil, nil); err == nil {
+		t.Errorf("expected an error")
+	}
+	if n := len(srv.Requests()); n != 0 {
+		t.Errorf("expected no requests, got %d", n)
+	}
+}
+
+func TestCheckWaitsForEveryClass(t *testing.T) {
+	srv := sftest.New(sftest.FixturesDir())
+	job := "707000000000001"
+	srv.AddRecord("ApexTestQueueItem", map[string]any{"ApexClass": map[string]any{"Name": "AccountTest"}, "ParentJobId": job, "Status": "Completed"})
+	srv.AddRecord("ApexTestQueueItem", map[string]any{"ApexClass": map[string]any{"Name": "ContactTest"}, "ParentJobId": job, "Status": "Processing"})
+	srv.AddRecord("ApexTestQueueItem", map[string]any{"ApexClass": map[string]any{"Name": "LeadTest"}, "ParentJobId": job, "Status": "Failed", "ExtendedStatus": "compile error"})
+	srv.AddRecord("ApexTestQueueItem", map[string]any{"ApexClass": map[string]any{"Name": "OtherTest"}, "ParentJobId": "707000000000002", "Status": "Processing"})
+
+	p, err := New(srv.Client()).check(context.Background(), job)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if p.Done() || p.Classes != 3 || p.Finished != 2 {
+		t.Errorf("expected 2 of 3 classes finished, got %+v", p)
+	}
+	if len(p.Errors) != 1 || p.Errors[0] != "LeadTest: Failed compile error" {
+		t.Errorf("unexpected errors %q", p.Errors)
+	}
+}
+
+// dropQueue serves the requests with a fake that loses the queued classes as soon as the tests are enqueued.
+type dropQueue struct {
+	srv *sftest.Server
+}
+
+func (d dropQueue) RoundTrip(r *http.Request) (*http.Response, error) {
+	resp, err := d.srv.Transport().RoundTrip(r)
+	if err != nil || !strings.HasSuffix(r.URL.Path, "/runTestsAsynchronous/") {
+		return resp, err
+	}
+	for _, item := range d.srv.Records("ApexTestQueueItem") {
+		if err := sf.DeleteSObject(r.Context(), d.srv.Client(), "ApexTestQueueItem", item["Id"].(string)); err != nil {
+			return nil, err
+		}
+	}
+	return resp, nil
+}
+
+func TestRunWithoutQueuedClasses(t *testing.T) {
+	srv := sftest.New(sftest.FixturesDir())
+	client := srv.Client(sf.WithTransport(dropQueue{srv}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var updates int
+	_, err := New(client, WithPollInterval(time.Millisecond)).Run(ctx, []string{"AccountTest"}, func(Progress) { updates++ })
+	if err == nil || errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the run to fail right away, got %v", err)
+	}
+	if updates != 0 {
+		t.Errorf("expected no progress updates, got %d", updates)
+	}
+}
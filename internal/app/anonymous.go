@@ -0,0 +1,88 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	sf "github.com/cdelmoral/apexlogs/internal/salesforce"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// anonymousLogSkew is how long before the execution the log of an anonymous
+// Apex execution may start, allowing for the clock of the org being behind.
+const anonymousLogSkew = time.Minute
+
+// errAnonymousOffline is reported when running anonymous Apex without an org.
+var errAnonymousOffline = errors.New("running anonymous apex requires a connection to the org")
+
+// An anonymousResultMsg delivers the outcome of an anonymous Apex execution,
+// with the id of the log it generated, if any, and the refreshed list of logs.
+// err is set when the code could not be sent, failures of the code itself are
+// reported by the result.
+type anonymousResultMsg struct {
+	result sf.ExecuteAnonymousResult
+	logId  string
+	logs   []sf.ApexLog
+	err    error
+}
+
+// runAnonymousCmd executes the anonymous Apex code and looks for its log
+// among the logs of the given user.
+func runAnonymousCmd(ctx context.Context, client *sf.Client, userId string, source logSource, code string) tea.Cmd {
+	return func() tea.Msg {
+		started := time.Now()
+		// The log of a previous execution may be within the skew too.
+		previous, err := anonymousLogId(ctx, client, userId, started)
+		if err != nil {
+			return anonymousResultMsg{err: fmt.Errorf("error getting the logs of anonymous apex: %w", err)}
+		}
+		res, err := sf.ExecuteAnonymous(ctx, client, code)
+		if err != nil {
+			return anonymousResultMsg{err: err}
+		}
+
+		msg := anonymousResultMsg{result: res}
+		msg.logId, err = anonymousLogId(ctx, client, userId, started)
+		if err != nil {
+			msg.err = fmt.Errorf("error getting the log of the anonymous apex: %w", err)
+			return msg
+		}
+		if msg.logId == previous {
+			msg.logId = ""
+		}
+		msg.logs, err = source.Logs(ctx)
+		if err != nil {
+			msg.err = fmt.Errorf("error getting apex logs: %w", err)
+		}
+		return msg
+	}
+}
+
+// anonymousLogId returns the id of the log of the last anonymous Apex
+// execution of the user started after since, or an empty id if there is none,
+// like when the trace flag is not active. Other users of the org may be
+// running anonymous Apex at the same time.
+func anonymousLogId(ctx context.Context, client *sf.Client, userId string, since time.Time) (string, error) {
+	q := sf.SelectApexLogsWhere(sf.ApexLogFilter{
+		Operation: sf.AnonymousApexOperation,
+		LogUserId: userId,
+		Since:     since.Add(-anonymousLogSkew),
+		Limit:     1,
+	})
+	res, err := sf.DoQuery[sf.ApexLog](ctx, client, q)
+	if err != nil || len(res.Records) == 0 {
+		return "", err
+	}
+	return res.Records[0].ID, nil
+}
+
+// openAnonymous shows the anonymous Apex editor in place of the left panel and focuses it.
+func (m *model) openAnonymous() tea.Cmd {
+	if m.salesforceClient == nil {
+		m.statusbar.SetError(errAnonymousOffline)
+		return nil
+	}
+	return m.openLeft(anonymousPanel)
+}
@@ -0,0 +1,173 @@
+package anonymous
+
+import (
+	"strings"
+
+	"github.com/cdelmoral/apexlogs/internal/theme"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textarea"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	runningMsg = "Running..."
+	successMsg = "Executed successfully"
+	// headerHeight is the height of the title and its bottom border.
+	headerHeight = 2
+	// resultHeight is the height of the result below the editor and its top border.
+	resultHeight = 4
+)
+
+// A RunMsg is sent to execute the anonymous Apex code.
+type RunMsg struct {
+	Code string
+}
+
+// Model is an editor of anonymous Apex code, showing the result of the last
+// execution below it.
+type Model struct {
+	KeyMap  KeyMap
+	style   lipgloss.Style
+	editor  textarea.Model
+	result  string
+	err     error
+	running bool
+	focused bool
+	width   int
+	height  int
+}
+
+// New creates a new [Model].
+func New() Model {
+	editor := textarea.New()
+	editor.Placeholder = "System.debug('Hello world');"
+	editor.CharLimit = 0
+	editor.MaxHeight = 0
+	return Model{
+		KeyMap: DefaultKeyMap(),
+		editor: editor,
+		style: lipgloss.NewStyle().
+			Border(lipgloss.NormalBorder()).
+			BorderForeground(theme.Current().Base).
+			MarginRight(1),
+	}
+}
+
+func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
+	km, ok := msg.(tea.KeyMsg)
+	if !ok || !m.focused {
+		var cmd tea.Cmd
+		m.editor, cmd = m.editor.Update(msg)
+		return m, cmd
+	}
+
+	if key.Matches(km, m.KeyMap.Run) {
+		code := strings.TrimSpace(m.editor.Value())
+		if code == "" || m.running {
+			return m, nil
+		}
+		m.running = true
+		return m, func() tea.Msg { return RunMsg{Code: code} }
+	}
+	var cmd tea.Cmd
+	m.editor, cmd = m.editor.Update(km)
+	return m, cmd
+}
+
+func (m Model) View() string {
+	title := lipgloss.NewStyle().
+		BorderStyle(lipgloss.NormalBorder()).
+		BorderBottom(true).
+		Render("Anonymous Apex")
+
+	var result string
+	switch {
+	case m.running:
+		result = runningMsg
+	case m.err != nil:
+		result = lipgloss.NewStyle().Foreground(theme.Current().Error).Render(m.err.Error())
+	case m.result != "":
+		result = lipgloss.NewStyle().Foreground(theme.Current().Success).Render(m.result)
+	}
+	wc := m.width - m.style.GetHorizontalFrameSize()
+	result = lipgloss.NewStyle().
+		BorderStyle(lipgloss.NormalBorder()).
+		BorderTop(true).
+		Width(max(wc, 1)).
+		Height(resultHeight - 1).
+		MaxHeight(resultHeight).
+		Render(result)
+
+	return m.style.Render(lipgloss.JoinVertical(lipgloss.Left, title, m.editor.View(), result))
+}
+
+// SetCode replaces the code in the editor.
+func (m *Model) SetCode(code string) {
+	m.editor.SetValue(code)
+}
+
+// Code returns the code in the editor.
+func (m Model) Code() string {
+	return m.editor.Value()
+}
+
+// SetRunning marks the code as being executed until [Model.SetResult] is called.
+func (m *Model) SetRunning() {
+	m.running = true
+	m.err = nil
+	m.result = ""
+}
+
+// SetResult displays the outcome of the last execution, err is its compile
+// problem or exception.
+func (m *Model) SetResult(err error) {
+	m.running = false
+	m.err = err
+	m.result = ""
+	if err == nil {
+		m.result = successMsg
+	}
+}
+
+// Running reports whether the code is being executed.
+func (m Model) Running() bool {
+	return m.running
+}
+
+// Typing reports whether the key presses are sent to the editor.
+func (m Model) Typing() bool {
+	return m.focused
+}
+
+func (m *Model) Focus() tea.Cmd {
+	m.focused = true
+	m.style = m.style.BorderForeground(theme.Current().Focused)
+	return m.editor.Focus()
+}
+
+func (m *Model) Blur() {
+	m.focused = false
+	m.style = m.style.BorderForeground(theme.Current().Base)
+	m.editor.Blur()
+}
+
+func (m Model) Focused() bool {
+	return m.focused
+}
+
+// SetHeight sets the total height of the model, including its border.
+func (m *Model) SetHeight(h int) {
+	m.height = h
+	hc := h - m.style.GetVerticalFrameSize()
+	m.editor.SetHeight(max(hc-headerHeight-resultHeight, 1))
+	m.style = m.style.Height(hc).MaxHeight(h)
+}
+
+// SetWidth sets the total width of the model, including its border and margin.
+func (m *Model) SetWidth(w int) {
+	m.width = w
+	wc := w - m.style.GetHorizontalFrameSize()
+	m.editor.SetWidth(max(wc, 1))
+	m.style = m.style.Width(wc).MaxWidth(w)
+}
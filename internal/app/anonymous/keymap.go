@@ -0,0 +1,18 @@
+package anonymous
+
+import (
+	"github.com/charmbracelet/bubbles/key"
+)
+
+type KeyMap struct {
+	Run key.Binding
+}
+
+func DefaultKeyMap() KeyMap {
+	return KeyMap{
+		Run: key.NewBinding(
+			key.WithKeys("ctrl+r"),
+			key.WithHelp("ctrl+r", "run anonymous apex"),
+		),
+	}
+}
@@ -1,16 +1,201 @@
 package app
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"time"
 
+	"github.com/cdelmoral/apexlogs/internal/cache"
+	"github.com/cdelmoral/apexlogs/internal/config"
+	sf "github.com/cdelmoral/apexlogs/internal/salesforce"
+	"github.com/cdelmoral/apexlogs/internal/theme"
+	"github.com/cdelmoral/apexlogs/internal/traceflag"
 	tea "github.com/charmbracelet/bubbletea"
 )
 
+const (
+	cleanupTimeout = 10 * time.Second
+
+	defaultApiVersion     = "61.0"
+	defaultDebugLevelName = "SFDC_DevConsole"
+	defaultListWidth      = 51
+)
+
+// Options configures the application.
+type Options struct {
+	// TraceFlagDuration is how long the trace flag stays active after every renewal.
+	TraceFlagDuration time.Duration
+	// TraceFlagCleanup is the action performed on the trace flag when the application exits.
+	TraceFlagCleanup traceflag.Cleanup
+	// Timeout is the maximum duration of a request to the org, and
+	// BodyTimeout of a request downloading the body of a log.
+	Timeout     time.Duration
+	BodyTimeout time.Duration
+	// CacheDir is the root directory of the local log cache. The cache is disabled when empty.
+	CacheDir string
+	// Offline browses the cached logs without connecting to the org.
+	Offline bool
+	// DownloadDir is the directory the listed logs are downloaded to.
+	DownloadDir string
+	// LocalPaths are log files or directories of log files to view instead of
+	// the logs of the org. The path "-" reads a log from the standard input.
+	LocalPaths []string
+	// StateDir is the directory the user interface state, like the hidden
+	// event types, is remembered in. The state is not saved when empty.
+	StateDir string
+	// ProjectDir is the directory the Salesforce DX project with the Apex
+	// sources of the logs is looked for from, going up to its parents.
+	ProjectDir string
+	// AnonymousApex is anonymous Apex code executed once the org is connected,
+	// opening its log.
+	AnonymousApex string
+	// TestClasses are the Apex classes whose tests are run once the org is
+	// connected, showing their results.
+	TestClasses []string
+	// ApiVersion is the version of the Salesforce API the requests are sent to.
+	ApiVersion string
+	// DebugLevel is the developer name of the debug level of the trace flag,
+	// which is created if it does not exist.
+	DebugLevel string
+	// LogLimit is the number of logs of the org listed.
+	LogLimit int
+	// ListWidth is the width of the list of logs and the other left panels.
+	ListWidth int
+	// KeyProfile is the set of key bindings, "default", "vim" or "emacs",
+	// changed by Keys.
+	KeyProfile string
+	// Keys maps the names of actions to the keys bound to them.
+	Keys map[string][]string
+	// Theme is the name of the theme of the user interface, a built-in one
+	// or one of Themes. The dark or the light theme is chosen depending on the
+	// background of the terminal when empty.
+	Theme  string
+	Themes map[string]theme.Theme
+}
+
+// DefaultOptions returns the options used when nothing is configured.
+func DefaultOptions() Options {
+	// The cache is optional, so it is disabled if there is no cache directory.
+	cacheDir, _ := cache.DefaultDir()
+	stateDir, _ := DefaultStateDir()
+	return Options{
+		TraceFlagDuration: traceflag.DefaultDuration,
+		TraceFlagCleanup:  traceflag.CleanupNone,
+		Timeout:           sf.DefaultTimeout,
+		BodyTimeout:       sf.DefaultBodyTimeout,
+		CacheDir:          cacheDir,
+		DownloadDir:       "apexlogs",
+		StateDir:          stateDir,
+		ProjectDir:        ".",
+		ApiVersion:        defaultApiVersion,
+		DebugLevel:        defaultDebugLevelName,
+		LogLimit:          sf.DefaultApexLogsLimit,
+		ListWidth:         defaultListWidth,
+	}
+}
+
+// LoadOptions returns the default options overridden by the configuration
+// files of the user and of the project of the current directory, see
+// [config.Load]. An error is returned if the configuration is not valid,
+// including keys bound to several actions handled at the same time and
+// unknown themes.
+func LoadOptions() (Options, error) {
+	opts := DefaultOptions()
+	// The configuration of the user is optional, like the cache.
+	path, _ := config.DefaultPath()
+	c, err := config.Load(path, opts.ProjectDir)
+	if err != nil {
+		return opts, err
+	}
+	opts = opts.withConfig(c)
+	if _, err := newKeyMap(opts.KeyProfile, opts.Keys); err != nil {
+		return opts, fmt.Errorf("invalid key bindings: %w", err)
+	}
+	// The background of the terminal does not change whether the theme is valid.
+	if _, err := theme.Resolve(opts.Theme, opts.Themes, func() bool { return true }); err != nil {
+		return opts, err
+	}
+	return opts, nil
+}
+
+// withConfig returns the options with the settings set in c replacing them.
+// The settings must be valid.
+func (o Options) withConfig(c config.Config) Options {
+	if c.ApiVersion != "" {
+		o.ApiVersion = c.ApiVersion
+	}
+	if c.DebugLevel != "" {
+		o.DebugLevel = c.DebugLevel
+	}
+	if c.LogLimit != 0 {
+		o.LogLimit = c.LogLimit
+	}
+	if c.ListWidth != 0 {
+		o.ListWidth = c.ListWidth
+	}
+	if c.TraceFlagDuration != 0 {
+		o.TraceFlagDuration = time.Duration(c.TraceFlagDuration)
+	}
+	if c.TraceFlagCleanup != "" {
+		o.TraceFlagCleanup = traceflag.Cleanup(c.TraceFlagCleanup)
+	}
+	if c.Timeout != 0 {
+		o.Timeout = time.Duration(c.Timeout)
+	}
+	if c.BodyTimeout != 0 {
+		o.BodyTimeout = time.Duration(c.BodyTimeout)
+	}
+	if c.CacheDir != nil {
+		o.CacheDir = *c.CacheDir
+	}
+	if c.DownloadDir != "" {
+		o.DownloadDir = c.DownloadDir
+	}
+	if c.KeyProfile != "" {
+		o.KeyProfile = c.KeyProfile
+	}
+	if c.Keys != nil {
+		o.Keys = c.Keys
+	}
+	if c.Theme != "" {
+		o.Theme = c.Theme
+	}
+	if c.Themes != nil {
+		o.Themes = c.Themes
+	}
+	return o
+}
+
+// clientOptions returns the options of the clients of the org.
+func (o Options) clientOptions() []sf.ClientOption {
+	return []sf.ClientOption{sf.WithTimeout(o.Timeout), sf.WithBodyTimeout(o.BodyTimeout)}
+}
+
 // Start creates a new tea program and runs it.
-func Start() {
-	if _, err := tea.NewProgram(newModel(), tea.WithAltScreen()).Run(); err != nil {
+func Start(opts Options) {
+	final, err := tea.NewProgram(newModel(opts), tea.WithAltScreen()).Run()
+	if m, ok := final.(model); ok {
+		if err := m.stop(); err != nil {
+			fmt.Println("Error cleaning up trace flag: ", err)
+		}
+	}
+	if err != nil {
 		fmt.Println("Error running program: ", err)
 		os.Exit(1)
 	}
 }
+
+// stop cancels the background work of the model and cleans up its trace flag.
+// The program may exit without the quit key, so the model context is
+// cancelled here for the renewals of the trace flag to finish.
+func (m model) stop() error {
+	m.cancel()
+	if m.traceFlags == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cleanupTimeout)
+	defer cancel()
+	return m.traceFlags.Stop(ctx)
+}
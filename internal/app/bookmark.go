@@ -0,0 +1,107 @@
+package app
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/cdelmoral/apexlogs/internal/cache"
+)
+
+// toggleBookmark bookmarks the current line of the open log, or removes its
+// bookmark if it has one.
+func (m *model) toggleBookmark() {
+	line := m.viewport.CurrentLine()
+	if line < 0 {
+		return
+	}
+	var bs []cache.Bookmark
+	found := false
+	for _, b := range m.tabs[m.tab].bookmarks {
+		if b.Line == line {
+			found = true
+			continue
+		}
+		bs = append(bs, b)
+	}
+	if !found {
+		bs = append(bs, cache.Bookmark{Line: line, Text: m.viewport.Line(line)})
+		sort.Slice(bs, func(i, j int) bool { return bs[i].Line < bs[j].Line })
+	}
+	m.setBookmarks(bs)
+	m.bookmarks.Select(line)
+}
+
+// setBookmarks replaces the bookmarks of the current tab and saves them
+// alongside the cached log.
+func (m *model) setBookmarks(bs []cache.Bookmark) {
+	if len(m.tabs) == 0 {
+		return
+	}
+	t := &m.tabs[m.tab]
+	t.bookmarks = bs
+	m.showTabBookmarks()
+	if m.cache == nil {
+		return
+	}
+	if err := m.cache.SaveBookmarks(t.id, bs); err != nil {
+		m.statusbar.SetError(fmt.Errorf("error saving bookmarks: %w", err))
+	}
+}
+
+// showTabBookmarks marks the bookmarked lines of the current tab in the
+// viewport and lists them in the bookmarks panel.
+func (m *model) showTabBookmarks() {
+	var bs []cache.Bookmark
+	if len(m.tabs) > 0 {
+		bs = m.tabs[m.tab].bookmarks
+	}
+	lines := make([]int, len(bs))
+	for i, b := range bs {
+		lines[i] = b.Line
+	}
+	m.viewport.SetBookmarks(lines)
+	m.bookmarks.SetBookmarks(bs)
+}
+
+// gotoBookmark moves the cursor to the first bookmark after the current line,
+// or to the last one before it if dir is negative, wrapping around the log.
+// The cursor stays on the bookmark, so repeated jumps step through all of them.
+func (m *model) gotoBookmark(dir int) {
+	bs := m.tabs[m.tab].bookmarks
+	if len(bs) == 0 {
+		return
+	}
+	cur := m.viewport.CurrentLine()
+	var line int
+	if dir > 0 {
+		line = bs[0].Line
+		for _, b := range bs {
+			if b.Line > cur {
+				line = b.Line
+				break
+			}
+		}
+	} else {
+		line = bs[len(bs)-1].Line
+		for i := len(bs) - 1; i >= 0; i-- {
+			if bs[i].Line < cur {
+				line = bs[i].Line
+				break
+			}
+		}
+	}
+	m.viewport.ScrollToLine(line)
+	m.bookmarks.Select(line)
+}
+
+// loadBookmarks returns the bookmarks saved for the log with the given id.
+func (m *model) loadBookmarks(id string) []cache.Bookmark {
+	if m.cache == nil {
+		return nil
+	}
+	bs, err := m.cache.Bookmarks(id)
+	if err != nil {
+		m.statusbar.SetError(fmt.Errorf("error loading bookmarks: %w", err))
+	}
+	return bs
+}
@@ -0,0 +1,249 @@
+package bookmarks
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cdelmoral/apexlogs/internal/app/text"
+	"github.com/cdelmoral/apexlogs/internal/cache"
+	"github.com/cdelmoral/apexlogs/internal/theme"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	emptyMsg = "No bookmarks"
+	// headerHeight is the height of the title and its bottom border.
+	headerHeight = 2
+	// inputHeight is the height of the note input and its top border.
+	inputHeight = 2
+)
+
+// A JumpMsg is sent to scroll the log to a bookmarked line.
+type JumpMsg struct {
+	Line int
+}
+
+// A ChangedMsg is sent when a bookmark is deleted or its note is edited.
+type ChangedMsg struct {
+	Bookmarks []cache.Bookmark
+}
+
+// Model lists the bookmarks of a log with their notes, to jump to the
+// bookmarked lines and annotate them.
+type Model struct {
+	KeyMap    KeyMap
+	style     lipgloss.Style
+	input     textinput.Model
+	bookmarks []cache.Bookmark
+	cursor    int
+	offset    int
+	height    int
+	width     int
+	focused   bool
+}
+
+// New creates a new [Model].
+func New() Model {
+	input := textinput.New()
+	input.Prompt = "note: "
+	input.Placeholder = "Describe the bookmarked line..."
+	return Model{
+		KeyMap: DefaultKeyMap(),
+		input:  input,
+		style: lipgloss.NewStyle().
+			Border(lipgloss.NormalBorder()).
+			BorderForeground(theme.Current().Base).
+			MarginRight(1),
+	}
+}
+
+func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
+	km, ok := msg.(tea.KeyMsg)
+	if !ok || !m.focused {
+		var cmd tea.Cmd
+		m.input, cmd = m.input.Update(msg)
+		return m, cmd
+	}
+
+	if m.Typing() {
+		switch {
+		case key.Matches(km, m.KeyMap.Confirm):
+			m.bookmarks[m.cursor].Note = strings.TrimSpace(m.input.Value())
+			m.input.Blur()
+			return m, m.changed
+		case key.Matches(km, m.KeyMap.Cancel):
+			m.input.Blur()
+			return m, nil
+		}
+		var cmd tea.Cmd
+		m.input, cmd = m.input.Update(km)
+		return m, cmd
+	}
+
+	if len(m.bookmarks) == 0 {
+		return m, nil
+	}
+	switch {
+	case key.Matches(km, m.KeyMap.Up):
+		m.moveCursor(-1)
+	case key.Matches(km, m.KeyMap.Down):
+		m.moveCursor(1)
+	case key.Matches(km, m.KeyMap.GotoTop):
+		m.moveCursor(-len(m.bookmarks))
+	case key.Matches(km, m.KeyMap.GotoBottom):
+		m.moveCursor(len(m.bookmarks))
+	case key.Matches(km, m.KeyMap.Jump):
+		line := m.bookmarks[m.cursor].Line
+		return m, func() tea.Msg { return JumpMsg{Line: line} }
+	case key.Matches(km, m.KeyMap.Note):
+		m.input.SetValue(m.bookmarks[m.cursor].Note)
+		m.input.CursorEnd()
+		return m, m.input.Focus()
+	case key.Matches(km, m.KeyMap.Delete):
+		m.bookmarks = append(m.bookmarks[:m.cursor:m.cursor], m.bookmarks[m.cursor+1:]...)
+		m.moveCursor(0)
+		return m, m.changed
+	}
+	return m, nil
+}
+
+func (m Model) View() string {
+	title := lipgloss.NewStyle().
+		BorderStyle(lipgloss.NormalBorder()).
+		BorderBottom(true).
+		Render(fmt.Sprintf("Bookmarks (%d)", len(m.bookmarks)))
+
+	var rows []string
+	if len(m.bookmarks) == 0 {
+		rows = append(rows, m.emptyMsg())
+	}
+	end := min(m.offset+m.listHeight(), len(m.bookmarks))
+	for i := m.offset; i < end; i++ {
+		rows = append(rows, m.row(i))
+	}
+
+	parts := []string{title, strings.Join(rows, "\n")}
+	if m.input.Focused() {
+		list := lipgloss.NewStyle().Height(m.listHeight()).Render(parts[1])
+		input := lipgloss.NewStyle().
+			BorderStyle(lipgloss.NormalBorder()).
+			BorderTop(true).
+			Render(m.input.View())
+		parts = []string{title, list, input}
+	}
+	return m.style.Render(lipgloss.JoinVertical(lipgloss.Left, parts...))
+}
+
+// emptyMsg tells how to add a bookmark with the keys in use.
+func (m Model) emptyMsg() string {
+	if !m.KeyMap.Add.Enabled() {
+		return emptyMsg
+	}
+	return fmt.Sprintf("%s, press %s on a line to add one", emptyMsg, m.KeyMap.Add.Help().Key)
+}
+
+// row renders a bookmark like "  123 checked here │ USER_DEBUG|...", with
+// the one based line number and the note before the text of the line.
+func (m Model) row(i int) string {
+	b := m.bookmarks[i]
+	num := fmt.Sprintf("%5d ", b.Line+1)
+	w := max(m.width-m.style.GetHorizontalFrameSize()-len(num), 1)
+	note, line := "", text.Truncate(strings.TrimSpace(b.Text), w)
+	if b.Note != "" {
+		note = text.Truncate(b.Note, w)
+		line = text.Truncate(" │ "+strings.TrimSpace(b.Text), w-len([]rune(note)))
+	}
+
+	if i == m.cursor && m.focused {
+		return lipgloss.NewStyle().
+			Foreground(theme.Current().SelectedForeground).
+			Background(theme.Current().SelectedBackground).
+			Render(num + note + line)
+	}
+	if note != "" {
+		note = lipgloss.NewStyle().Foreground(theme.Current().Bookmark).Render(note)
+	}
+	return num + note + line
+}
+
+// SetBookmarks lists the given bookmarks, sorted by line.
+func (m *Model) SetBookmarks(bs []cache.Bookmark) {
+	m.bookmarks = append([]cache.Bookmark(nil), bs...)
+	m.input.Blur()
+	m.moveCursor(0)
+}
+
+// Bookmarks returns the listed bookmarks.
+func (m Model) Bookmarks() []cache.Bookmark {
+	return append([]cache.Bookmark(nil), m.bookmarks...)
+}
+
+// Select moves the cursor to the bookmark of the given line, if any.
+func (m *Model) Select(line int) {
+	for i, b := range m.bookmarks {
+		if b.Line == line {
+			m.moveCursor(i - m.cursor)
+			return
+		}
+	}
+}
+
+// Typing reports whether the key presses are sent to the note input.
+func (m Model) Typing() bool {
+	return m.focused && m.input.Focused()
+}
+
+func (m Model) changed() tea.Msg {
+	return ChangedMsg{Bookmarks: m.Bookmarks()}
+}
+
+// moveCursor moves the cursor by n bookmarks, scrolling to keep it visible.
+func (m *Model) moveCursor(n int) {
+	m.cursor = max(min(m.cursor+n, len(m.bookmarks)-1), 0)
+	h := m.listHeight()
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	} else if m.cursor >= m.offset+h {
+		m.offset = m.cursor - h + 1
+	}
+}
+
+func (m *Model) Focus() {
+	m.focused = true
+	m.style = m.style.BorderForeground(theme.Current().Focused)
+}
+
+func (m *Model) Blur() {
+	m.focused = false
+	m.style = m.style.BorderForeground(theme.Current().Base)
+	m.input.Blur()
+}
+
+func (m Model) Focused() bool {
+	return m.focused
+}
+
+// listHeight is the number of bookmarks displayed at once, leaving room for
+// the note input.
+func (m Model) listHeight() int {
+	return max(m.height-m.style.GetVerticalFrameSize()-headerHeight-inputHeight, 1)
+}
+
+// SetHeight sets the total height of the model, including its border.
+func (m *Model) SetHeight(h int) {
+	m.height = h
+	hc := h - m.style.GetVerticalFrameSize()
+	m.style = m.style.Height(hc).MaxHeight(h)
+	m.moveCursor(0)
+}
+
+// SetWidth sets the total width of the model, including its border and margin.
+func (m *Model) SetWidth(w int) {
+	m.width = w
+	wc := w - m.style.GetHorizontalFrameSize()
+	m.input.Width = max(wc-lipgloss.Width(m.input.Prompt)-1, 1)
+	m.style = m.style.Width(wc).MaxWidth(w)
+}
@@ -0,0 +1,65 @@
+package bookmarks
+
+import (
+	"github.com/charmbracelet/bubbles/key"
+)
+
+type KeyMap struct {
+	Up         key.Binding
+	Down       key.Binding
+	GotoTop    key.Binding
+	GotoBottom key.Binding
+	Jump       key.Binding
+	Note       key.Binding
+	Delete     key.Binding
+	Confirm    key.Binding
+	Cancel     key.Binding
+	// Add bookmarks a line of the log. It is handled with the log, and only
+	// shown in the list when there are no bookmarks.
+	Add key.Binding
+}
+
+func DefaultKeyMap() KeyMap {
+	return KeyMap{
+		Up: key.NewBinding(
+			key.WithKeys("up", "k"),
+			key.WithHelp("↑/k", "up"),
+		),
+		Down: key.NewBinding(
+			key.WithKeys("down", "j"),
+			key.WithHelp("↓/j", "down"),
+		),
+		GotoTop: key.NewBinding(
+			key.WithKeys("home", "g"),
+			key.WithHelp("g/home", "go to start"),
+		),
+		GotoBottom: key.NewBinding(
+			key.WithKeys("end", "G"),
+			key.WithHelp("G/end", "go to end"),
+		),
+		Jump: key.NewBinding(
+			key.WithKeys("enter"),
+			key.WithHelp("enter", "go to bookmarked line"),
+		),
+		Note: key.NewBinding(
+			key.WithKeys("a"),
+			key.WithHelp("a", "edit note"),
+		),
+		Delete: key.NewBinding(
+			key.WithKeys("d"),
+			key.WithHelp("d", "delete bookmark"),
+		),
+		Confirm: key.NewBinding(
+			key.WithKeys("enter"),
+			key.WithHelp("enter", "save note"),
+		),
+		Cancel: key.NewBinding(
+			key.WithKeys("esc"),
+			key.WithHelp("esc", "discard note"),
+		),
+		Add: key.NewBinding(
+			key.WithKeys("B"),
+			key.WithHelp("B", "toggle bookmark"),
+		),
+	}
+}
@@ -0,0 +1,88 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/aymanbagabas/go-osc52/v2"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/term"
+)
+
+// maxClipboardSize is the largest text copied to the clipboard. Larger texts
+// would flood the terminal with escape sequences that most terminals drop.
+const maxClipboardSize = 1 << 20
+
+// errNoTerminal is reported when the clipboard escape sequences would not
+// reach a terminal, like when stderr is redirected to a file.
+var errNoTerminal = errors.New("cannot copy to the clipboard: stderr is not a terminal")
+
+// A copiedMsg reports that what, like "3 lines", was copied to the clipboard.
+type copiedMsg struct {
+	what string
+}
+
+// copyCmd copies s to the clipboard of the terminal with an OSC52 escape
+// sequence written to w, which works over SSH too. The sequence is wrapped
+// to get through tmux and screen.
+// Nothing is copied if w is a file that is not a terminal or if s is larger
+// than [maxClipboardSize].
+func copyCmd(w io.Writer, s, what string) tea.Cmd {
+	return func() tea.Msg {
+		if f, ok := w.(*os.File); ok && !term.IsTerminal(f.Fd()) {
+			return errMsg{errNoTerminal}
+		}
+		if len(s) > maxClipboardSize {
+			return errMsg{fmt.Errorf("cannot copy %s to the clipboard: %.1f MB is over the limit of %d MB, open it in the pager or the editor instead",
+				what, float64(len(s))/(1<<20), maxClipboardSize>>20)}
+		}
+		seq := osc52.New(s)
+		switch {
+		case os.Getenv("TMUX") != "":
+			seq = seq.Tmux()
+		case strings.HasPrefix(os.Getenv("TERM"), "screen"):
+			seq = seq.Screen()
+		}
+		if _, err := seq.WriteTo(w); err != nil {
+			return errMsg{fmt.Errorf("error copying to the clipboard: %w", err)}
+		}
+		return copiedMsg{what: what}
+	}
+}
+
+// copySelectionCmd copies the selected lines of the open log, ending the
+// selection, or the current line if nothing is selected.
+func (m *model) copySelectionCmd() tea.Cmd {
+	if _, _, ok := m.viewport.Selection(); ok {
+		s, n := m.viewport.SelectedContent()
+		m.viewport.ClearSelection()
+		return copyCmd(m.clipboard, s, countLines(n))
+	}
+	line := m.viewport.CurrentLine()
+	if line < 0 {
+		return nil
+	}
+	return copyCmd(m.clipboard, m.viewport.Line(line), countLines(1))
+}
+
+// copyDisplayedCmd copies the displayed lines of the open log, like the
+// lines matching the filter.
+func (m model) copyDisplayedCmd() tea.Cmd {
+	s := m.viewport.DisplayedContent()
+	return copyCmd(m.clipboard, s, countLines(strings.Count(s, "\n")))
+}
+
+// copyBodyCmd copies the whole body of the open log.
+func (m model) copyBodyCmd() tea.Cmd {
+	return copyCmd(m.clipboard, strings.Join(m.viewport.Lines(), "\n"), "the whole log")
+}
+
+func countLines(n int) string {
+	if n == 1 {
+		return "1 line"
+	}
+	return fmt.Sprintf("%d lines", n)
+}
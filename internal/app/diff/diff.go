@@ -0,0 +1,347 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cdelmoral/apexlogs/internal/app/text"
+	"github.com/cdelmoral/apexlogs/internal/logdiff"
+	"github.com/cdelmoral/apexlogs/internal/theme"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	loadingMsg = "Comparing apex logs..."
+	equalMsg   = "The logs are equal, ignoring times, Ids and addresses"
+	partialMsg = "The logs are too different, the rest of them is a single change"
+	// contextLines is the number of equal lines displayed around every change.
+	contextLines = 3
+	// nameWidth and valueWidth are the widths of the columns of the summary.
+	nameWidth  = 26
+	valueWidth = 14
+	separator  = "--"
+)
+
+type rowKind int
+
+const (
+	textRow rowKind = iota
+	titleRow
+	changedRow
+	equalRow
+	deleteRow
+	insertRow
+)
+
+type row struct {
+	kind rowKind
+	text string
+}
+
+// Model displays the comparison of two apex logs: a summary of their
+// executed code units, queries, limits and debug output, followed by the
+// lines that differ between them with a few lines around.
+type Model struct {
+	KeyMap  KeyMap
+	style   lipgloss.Style
+	spinner spinner.Model
+	rows    []row
+	// changes are the indexes of the rows starting every group of changed lines.
+	changes     []int
+	err         error
+	yOffset     int
+	width       int
+	height      int
+	focused     bool
+	showSpinner bool
+}
+
+// New creates a new [Model].
+func New() Model {
+	return Model{
+		KeyMap: DefaultKeyMap(),
+		style: lipgloss.NewStyle().
+			Border(lipgloss.NormalBorder()).
+			BorderForeground(theme.Current().Base),
+	}
+}
+
+func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case spinner.TickMsg:
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
+	case tea.KeyMsg:
+		if !m.focused {
+			return m, nil
+		}
+		switch {
+		case key.Matches(msg, m.KeyMap.NextChange):
+			for _, c := range m.changes {
+				if c > m.yOffset {
+					m.setYOffset(c)
+					break
+				}
+			}
+		case key.Matches(msg, m.KeyMap.PrevChange):
+			for i := len(m.changes) - 1; i >= 0; i-- {
+				if m.changes[i] < m.yOffset {
+					m.setYOffset(m.changes[i])
+					break
+				}
+			}
+		case key.Matches(msg, m.KeyMap.PageDown):
+			m.setYOffset(m.yOffset + m.contentHeight())
+		case key.Matches(msg, m.KeyMap.PageUp):
+			m.setYOffset(m.yOffset - m.contentHeight())
+		case key.Matches(msg, m.KeyMap.HalfPageDown):
+			m.setYOffset(m.yOffset + m.contentHeight()/2)
+		case key.Matches(msg, m.KeyMap.HalfPageUp):
+			m.setYOffset(m.yOffset - m.contentHeight()/2)
+		case key.Matches(msg, m.KeyMap.Down):
+			m.setYOffset(m.yOffset + 1)
+		case key.Matches(msg, m.KeyMap.Up):
+			m.setYOffset(m.yOffset - 1)
+		}
+	}
+	return m, nil
+}
+
+func (m Model) View() string {
+	w, h := m.width, m.contentHeight()
+	center := lipgloss.NewStyle().Width(w).Height(h).Align(lipgloss.Center, lipgloss.Center)
+	switch {
+	case m.showSpinner:
+		return m.style.Render(center.Render(fmt.Sprintf("%s %s", m.spinner.View(), loadingMsg)))
+	case m.err != nil:
+		return m.style.Render(center.Render(m.err.Error()))
+	}
+
+	styles := map[rowKind]lipgloss.Style{
+		titleRow:   lipgloss.NewStyle().Bold(true),
+		changedRow: lipgloss.NewStyle().Foreground(theme.Current().Warning),
+		deleteRow:  lipgloss.NewStyle().Foreground(theme.Current().Error),
+		insertRow:  lipgloss.NewStyle().Foreground(theme.Current().Success),
+	}
+	end := min(m.yOffset+h, len(m.rows))
+	lines := make([]string, 0, h)
+	for _, r := range m.rows[min(m.yOffset, end):end] {
+		text := text.Truncate(r.text, w)
+		if st, ok := styles[r.kind]; ok {
+			text = st.Render(text)
+		}
+		lines = append(lines, text)
+	}
+
+	content := lipgloss.NewStyle().
+		Width(w).
+		Height(h).
+		MaxHeight(h).
+		Render(strings.Join(lines, "\n"))
+	return m.style.Render(content)
+}
+
+// SetResult displays the comparison of the logs with the given labels, like
+// their start times and operations.
+func (m *Model) SetResult(labels [2]string, r logdiff.Result) {
+	m.showSpinner = false
+	m.err = nil
+	m.yOffset = 0
+	m.rows = nil
+	m.changes = nil
+
+	m.add(titleRow, "A: "+labels[0])
+	m.add(titleRow, "B: "+labels[1])
+	m.add(textRow, "")
+	m.summary(r.Stats[0], r.Stats[1])
+	m.add(textRow, "")
+
+	switch {
+	case r.Changes() == 0:
+		m.add(textRow, equalMsg)
+	case r.Partial:
+		m.add(textRow, fmt.Sprintf("%d lines differ. %s", r.Changes(), partialMsg))
+	default:
+		m.add(textRow, fmt.Sprintf("%d lines differ", r.Changes()))
+	}
+	if r.Changes() > 0 {
+		m.add(textRow, "")
+	}
+	m.hunks(r.Lines)
+}
+
+// SetError displays the error preventing the comparison.
+func (m *Model) SetError(err error) {
+	m.showSpinner = false
+	m.err = err
+	m.rows = nil
+	m.changes = nil
+}
+
+// summary adds a row per statistic of the logs, followed by the code units
+// and exceptions found in only one of them.
+func (m *Model) summary(a, b logdiff.Stats) {
+	m.add(textRow, fmt.Sprintf("%-*s%*s%*s", nameWidth, "", valueWidth, "A", valueWidth, "B"))
+	m.stat("Duration", formatDuration(a.Duration), formatDuration(b.Duration))
+	m.stat("Events", fmt.Sprint(a.Events), fmt.Sprint(b.Events))
+	m.stat("SOQL queries", fmt.Sprint(a.SOQL), fmt.Sprint(b.SOQL))
+	m.stat("DML statements", fmt.Sprint(a.DML), fmt.Sprint(b.DML))
+	m.stat("Code units", fmt.Sprint(len(a.CodeUnits)), fmt.Sprint(len(b.CodeUnits)))
+	m.stat("Exceptions", fmt.Sprint(len(a.Exceptions)), fmt.Sprint(len(b.Exceptions)))
+	m.stat("Debug statements", fmt.Sprint(len(a.Debug)), fmt.Sprint(len(b.Debug)))
+
+	// Only the limits used differently are listed, since logs report dozens.
+	for _, l := range a.Limits {
+		o, _ := b.Limit(l.Name)
+		if o.Used != l.Used {
+			m.stat(l.Name, formatLimit(l), formatLimit(o))
+		}
+	}
+	for _, l := range b.Limits {
+		if _, ok := a.Limit(l.Name); !ok && l.Used != 0 {
+			m.stat(l.Name, formatLimit(logdiff.Limit{}), formatLimit(l))
+		}
+	}
+
+	m.only("Code units only in", a.CodeUnits, b.CodeUnits)
+	m.only("Exceptions only in", a.Exceptions, b.Exceptions)
+	m.only("Debug output only in", a.Debug, b.Debug)
+}
+
+// stat adds a row comparing a statistic, highlighted if it differs.
+func (m *Model) stat(name, a, b string) {
+	kind := textRow
+	if a != b {
+		kind = changedRow
+	}
+	m.add(kind, fmt.Sprintf("%-*s%*s%*s", nameWidth, text.Truncate(name, nameWidth-1), valueWidth, a, valueWidth, b))
+}
+
+// only adds a row per value found more times in one list than in the other.
+func (m *Model) only(title string, a, b []string) {
+	for _, v := range onlyIn(a, b) {
+		m.add(deleteRow, fmt.Sprintf("%s A: %s", title, v))
+	}
+	for _, v := range onlyIn(b, a) {
+		m.add(insertRow, fmt.Sprintf("%s B: %s", title, v))
+	}
+}
+
+// hunks adds the changed lines with contextLines equal lines around them,
+// separating the groups of lines that are not contiguous.
+func (m *Model) hunks(lines []logdiff.Line) {
+	show := make([]bool, len(lines))
+	for i, l := range lines {
+		if l.Op == logdiff.Equal {
+			continue
+		}
+		for j := max(i-contextLines, 0); j <= min(i+contextLines, len(lines)-1); j++ {
+			show[j] = true
+		}
+	}
+
+	prev := -1
+	for i, l := range lines {
+		if !show[i] {
+			continue
+		}
+		if prev >= 0 && prev != i-1 {
+			m.add(textRow, separator)
+		}
+		if l.Op != logdiff.Equal && (i == 0 || lines[i-1].Op == logdiff.Equal) {
+			m.changes = append(m.changes, len(m.rows))
+		}
+		switch l.Op {
+		case logdiff.Delete:
+			m.add(deleteRow, "- "+l.Text)
+		case logdiff.Insert:
+			m.add(insertRow, "+ "+l.Text)
+		default:
+			m.add(equalRow, "  "+l.Text)
+		}
+		prev = i
+	}
+}
+
+func (m *Model) add(kind rowKind, text string) {
+	m.rows = append(m.rows, row{kind, text})
+}
+
+func (m *Model) StartSpinner() tea.Cmd {
+	m.showSpinner = true
+	m.spinner = spinner.New()
+	return m.spinner.Tick
+}
+
+func (m *Model) setYOffset(n int) {
+	m.yOffset = max(min(n, len(m.rows)-m.contentHeight()), 0)
+}
+
+func (m *Model) Focus() {
+	m.focused = true
+	m.style = m.style.BorderForeground(theme.Current().Focused)
+}
+
+func (m *Model) Blur() {
+	m.focused = false
+	m.style = m.style.BorderForeground(theme.Current().Base)
+}
+
+func (m Model) Focused() bool {
+	return m.focused
+}
+
+func (m Model) contentHeight() int {
+	return max(m.height-m.style.GetVerticalFrameSize(), 1)
+}
+
+// SetHeight sets the total height of the model, including its border.
+func (m *Model) SetHeight(h int) {
+	m.height = h
+	hc := h - m.style.GetVerticalFrameSize()
+	m.style = m.style.Height(hc).MaxHeight(h)
+	m.setYOffset(m.yOffset)
+}
+
+// SetWidth sets the total width of the model, including its border.
+func (m *Model) SetWidth(w int) {
+	wc := w - m.style.GetHorizontalFrameSize()
+	m.width = wc
+	m.style = m.style.Width(wc).MaxWidth(w)
+}
+
+// onlyIn returns the distinct values occurring more times in a than in b, in
+// the order they first occur in a.
+func onlyIn(a, b []string) []string {
+	counts := map[string]int{}
+	for _, v := range a {
+		counts[v]++
+	}
+	for _, v := range b {
+		counts[v]--
+	}
+	var only []string
+	for _, v := range a {
+		if counts[v] > 0 {
+			only = append(only, v)
+			counts[v] = 0
+		}
+	}
+	return only
+}
+
+func formatDuration(d time.Duration) string {
+	return fmt.Sprintf("%.2f ms", float64(d)/float64(time.Millisecond))
+}
+
+func formatLimit(l logdiff.Limit) string {
+	if l.Max == 0 {
+		return fmt.Sprint(l.Used)
+	}
+	return fmt.Sprintf("%d / %d", l.Used, l.Max)
+}
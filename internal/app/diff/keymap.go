@@ -0,0 +1,28 @@
+package diff
+
+import (
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/viewport"
+)
+
+type viewportKeyMap = viewport.KeyMap
+
+type KeyMap struct {
+	NextChange key.Binding
+	PrevChange key.Binding
+	viewportKeyMap
+}
+
+func DefaultKeyMap() KeyMap {
+	return KeyMap{
+		NextChange: key.NewBinding(
+			key.WithKeys("n"),
+			key.WithHelp("n", "next change"),
+		),
+		PrevChange: key.NewBinding(
+			key.WithKeys("N"),
+			key.WithHelp("N", "previous change"),
+		),
+		viewportKeyMap: viewport.DefaultKeyMap(),
+	}
+}
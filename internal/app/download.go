@@ -0,0 +1,64 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/cdelmoral/apexlogs/internal/download"
+	sf "github.com/cdelmoral/apexlogs/internal/salesforce"
+	"github.com/charmbracelet/bubbles/progress"
+)
+
+// DownloadOptions configures a bulk download of apex logs.
+type DownloadOptions struct {
+	Options
+	// Filter selects the logs to download.
+	Filter sf.ApexLogFilter
+	// Workers is the number of logs downloaded concurrently.
+	Workers int
+}
+
+// Download saves the logs of the default org matching opts.Filter into
+// opts.DownloadDir, drawing a progress bar on w.
+// Logs downloaded by a previous run into the same directory are skipped.
+func Download(ctx context.Context, opts DownloadOptions, w io.Writer) error {
+	return runDownload(ctx, connectDefaultOrg(opts.ApiVersion, opts.clientOptions()...), opts, w)
+}
+
+func runDownload(ctx context.Context, connect connectFunc, opts DownloadOptions, w io.Writer) error {
+	client, userInfo, err := connect(ctx)
+	if err != nil {
+		return fmt.Errorf("error getting default dx user: %w", err)
+	}
+
+	res, err := sf.DoQuery[sf.ApexLog](ctx, client, sf.SelectApexLogsWhere(opts.Filter))
+	if err != nil {
+		return fmt.Errorf("error getting apex logs: %w", err)
+	}
+
+	// Downloaded bodies are cached too, so they open instantly in the application.
+	c := openCache(opts.CacheDir, userInfo.Username)
+	if c != nil {
+		if err := c.SaveLogs(res.Records); err != nil {
+			return fmt.Errorf("error caching apex logs: %w", err)
+		}
+	}
+	source := orgSource{client: client, cache: c}
+
+	bar := progress.New(progress.WithWidth(40), progress.WithoutPercentage())
+	p, err := download.New(opts.DownloadDir, source.Body, download.WithWorkers(opts.Workers)).
+		Run(ctx, res.Records, func(p download.Progress) {
+			fmt.Fprintf(w, "\r%s %d/%d", bar.ViewAs(float64(p.Finished())/float64(p.Total)), p.Finished(), p.Total)
+		})
+	if p.Total > 0 {
+		fmt.Fprintln(w)
+	}
+
+	fmt.Fprintf(w, "%d logs downloaded, %d already in %s", p.Done, p.Skipped, opts.DownloadDir)
+	if p.Failed > 0 {
+		fmt.Fprintf(w, ", %d failed", p.Failed)
+	}
+	fmt.Fprintln(w)
+	return err
+}
@@ -0,0 +1,153 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/cdelmoral/apexlogs/internal/apexlog"
+	"github.com/cdelmoral/apexlogs/internal/project"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// errNoSource is reported for log lines without a location in the Apex source.
+var errNoSource = errors.New("the line has no apex source location")
+
+// An editSourceMsg opens the given one based line of a source file in the editor.
+type editSourceMsg struct {
+	path string
+	line int
+}
+
+// findSourceCmd looks for the source file of loc in the Salesforce DX project
+// dir is in.
+func findSourceCmd(dir string, loc apexlog.Location) tea.Cmd {
+	return func() tea.Msg {
+		p, err := project.Find(dir)
+		if err != nil {
+			return errMsg{err}
+		}
+		path, err := p.Source(loc.Class(p.Namespace), loc.Trigger)
+		if err != nil {
+			return errMsg{err}
+		}
+		return editSourceMsg{path: path, line: loc.Line}
+	}
+}
+
+// editSourceCmd suspends the application while the editor is open.
+func editSourceCmd(path string, line int) tea.Cmd {
+	return tea.ExecProcess(editorCommand(path, line, false), func(err error) tea.Msg {
+		if err != nil {
+			return errMsg{fmt.Errorf("error running editor: %w", err)}
+		}
+		return nil
+	})
+}
+
+// An externalViewMsg opens a copy of the displayed log in the pager or the editor.
+// The file is removed once they exit.
+type externalViewMsg struct {
+	path   string
+	editor bool
+}
+
+// externalViewCmd writes the content displayed of the log with the given id to
+// a temporary file to open it in the pager, or in the editor if editor is set.
+func externalViewCmd(id, content string, editor bool) tea.Cmd {
+	return func() tea.Msg {
+		f, err := os.CreateTemp("", tempFilePattern(id))
+		if err != nil {
+			return errMsg{fmt.Errorf("error creating temporary file: %w", err)}
+		}
+		_, err = f.WriteString(content)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			os.Remove(f.Name())
+			return errMsg{fmt.Errorf("error writing temporary file: %w", err)}
+		}
+		return externalViewMsg{path: f.Name(), editor: editor}
+	}
+}
+
+// unsafeFileNameRe matches the characters replaced in the names of the temporary files.
+var unsafeFileNameRe = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// tempFilePattern returns the pattern of the name of the temporary file of
+// the log with the given id. The id of a local file is its path, so only its
+// base name is kept.
+func tempFilePattern(id string) string {
+	name := strings.TrimSuffix(filepath.Base(id), ".log")
+	name = unsafeFileNameRe.ReplaceAllString(name, "_")
+	return name + "-*.log"
+}
+
+// openExternalViewCmd suspends the application while the pager or the editor is open.
+func openExternalViewCmd(path string, editor bool) tea.Cmd {
+	c := pagerCommand(path)
+	if editor {
+		// The file is removed once the editor exits, so graphical editors
+		// must wait until it is closed.
+		c = editorCommand(path, 1, true)
+	}
+	return tea.ExecProcess(c, func(err error) tea.Msg {
+		os.Remove(path)
+		if err != nil {
+			return errMsg{fmt.Errorf("error running %s: %w", filepath.Base(c.Path), err)}
+		}
+		return nil
+	})
+}
+
+// pagerCommand returns the command opening path in the pager of the user,
+// from $PAGER, or less if it is not set.
+func pagerCommand(path string) *exec.Cmd {
+	args := strings.Fields(os.Getenv("PAGER"))
+	if len(args) == 0 {
+		args = []string{"less"}
+	}
+	args = append(args, path)
+	return exec.Command(args[0], args[1:]...)
+}
+
+// editorCommand returns the command opening path at the given one based line
+// in the editor of the user, from $VISUAL or $EDITOR, or vi if neither is set.
+// Graphical editors return as soon as the file is handed to their running
+// instance, unless wait is set, which makes them return once it is closed.
+func editorCommand(path string, line int, wait bool) *exec.Cmd {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	args := strings.Fields(editor)
+	if len(args) == 0 {
+		args = []string{"vi"}
+	}
+
+	// Most terminal editors accept +<line>, graphical ones a path:line suffix.
+	name := filepath.Base(args[0])
+	if wait && !slices.Contains(args, "--wait") && !slices.Contains(args, "-w") {
+		switch name {
+		case "code", "code-insiders", "codium", "zed":
+			args = append(args, "--wait")
+		case "subl":
+			args = append(args, "-w")
+		}
+	}
+	switch name {
+	case "code", "code-insiders", "codium":
+		args = append(args, "--goto", fmt.Sprintf("%s:%d", path, line))
+	case "subl", "zed", "hx":
+		args = append(args, fmt.Sprintf("%s:%d", path, line))
+	default:
+		args = append(args, fmt.Sprintf("+%d", line), path)
+	}
+	return exec.Command(args[0], args[1:]...)
+}
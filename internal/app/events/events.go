@@ -0,0 +1,230 @@
+package events
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/cdelmoral/apexlogs/internal/app/text"
+	"github.com/cdelmoral/apexlogs/internal/theme"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	emptyMsg = "No events in the log"
+	// headerHeight is the height of the title and its bottom border.
+	headerHeight = 2
+)
+
+// A ChangedMsg is sent when an event type is hidden or shown.
+type ChangedMsg struct {
+	// Hidden are the sorted event types to hide.
+	Hidden []string
+}
+
+type entry struct {
+	event string
+	count int
+}
+
+// Model is a checklist of the event types of a log, used to hide the noisy
+// ones from the viewport. Hidden event types stay listed after opening
+// another log, so they can be shown again.
+type Model struct {
+	KeyMap  KeyMap
+	style   lipgloss.Style
+	entries []entry
+	hidden  map[string]bool
+	cursor  int
+	offset  int
+	height  int
+	width   int
+	focused bool
+}
+
+// New creates a new [Model] hiding the given event types.
+func New(hidden []string) Model {
+	m := Model{
+		KeyMap: DefaultKeyMap(),
+		hidden: map[string]bool{},
+		style: lipgloss.NewStyle().
+			Border(lipgloss.NormalBorder()).
+			BorderForeground(theme.Current().Base).
+			MarginRight(1),
+	}
+	for _, e := range hidden {
+		m.hidden[e] = true
+	}
+	m.SetCounts(nil)
+	return m
+}
+
+func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
+	km, ok := msg.(tea.KeyMsg)
+	if !ok || !m.focused {
+		return m, nil
+	}
+
+	switch {
+	case key.Matches(km, m.KeyMap.Up):
+		m.moveCursor(-1)
+	case key.Matches(km, m.KeyMap.Down):
+		m.moveCursor(1)
+	case key.Matches(km, m.KeyMap.GotoTop):
+		m.moveCursor(-len(m.entries))
+	case key.Matches(km, m.KeyMap.GotoBottom):
+		m.moveCursor(len(m.entries))
+	case key.Matches(km, m.KeyMap.Toggle):
+		if len(m.entries) == 0 {
+			return m, nil
+		}
+		e := m.entries[m.cursor].event
+		if m.hidden[e] {
+			delete(m.hidden, e)
+		} else {
+			m.hidden[e] = true
+		}
+		return m, m.changed
+	case key.Matches(km, m.KeyMap.ShowAll):
+		if len(m.hidden) == 0 {
+			return m, nil
+		}
+		m.hidden = map[string]bool{}
+		return m, m.changed
+	}
+	return m, nil
+}
+
+func (m Model) View() string {
+	title := lipgloss.NewStyle().
+		BorderStyle(lipgloss.NormalBorder()).
+		BorderBottom(true).
+		Render(m.title())
+
+	var rows []string
+	if len(m.entries) == 0 {
+		rows = append(rows, emptyMsg)
+	}
+	end := min(m.offset+m.listHeight(), len(m.entries))
+	for i := m.offset; i < end; i++ {
+		rows = append(rows, m.row(i))
+	}
+
+	v := lipgloss.JoinVertical(lipgloss.Left, title, strings.Join(rows, "\n"))
+	return m.style.Render(v)
+}
+
+func (m Model) title() string {
+	if len(m.hidden) == 0 {
+		return "Event types"
+	}
+	return fmt.Sprintf("Event types (%d hidden)", len(m.hidden))
+}
+
+// row renders an entry like "[x] USER_DEBUG    12", with the count aligned to the right.
+func (m Model) row(i int) string {
+	e := m.entries[i]
+	check := "[x]"
+	if m.hidden[e.event] {
+		check = "[ ]"
+	}
+	count := fmt.Sprint(e.count)
+	w := m.width - m.style.GetHorizontalFrameSize()
+	name := text.Truncate(e.event, max(w-len(check)-len(count)-2, 1))
+	gap := max(w-len(check)-1-utf8.RuneCountInString(name)-len(count), 1)
+	r := check + " " + name + strings.Repeat(" ", gap) + count
+	if i == m.cursor && m.focused {
+		return lipgloss.NewStyle().
+			Foreground(theme.Current().SelectedForeground).
+			Background(theme.Current().SelectedBackground).
+			Render(r)
+	}
+	return r
+}
+
+// SetCounts lists the event types of a log with the number of times they occur,
+// most frequent first.
+func (m *Model) SetCounts(counts map[string]int) {
+	m.entries = m.entries[:0]
+	for e, n := range counts {
+		if e != "" {
+			m.entries = append(m.entries, entry{e, n})
+		}
+	}
+	for e := range m.hidden {
+		if _, ok := counts[e]; !ok {
+			m.entries = append(m.entries, entry{e, 0})
+		}
+	}
+	sort.Slice(m.entries, func(i, j int) bool {
+		a, b := m.entries[i], m.entries[j]
+		if a.count != b.count {
+			return a.count > b.count
+		}
+		return a.event < b.event
+	})
+	m.cursor = 0
+	m.offset = 0
+}
+
+// Hidden returns the sorted event types to hide.
+func (m Model) Hidden() []string {
+	hidden := make([]string, 0, len(m.hidden))
+	for e := range m.hidden {
+		hidden = append(hidden, e)
+	}
+	sort.Strings(hidden)
+	return hidden
+}
+
+func (m Model) changed() tea.Msg {
+	return ChangedMsg{Hidden: m.Hidden()}
+}
+
+// moveCursor moves the cursor by n entries, scrolling to keep it visible.
+func (m *Model) moveCursor(n int) {
+	m.cursor = max(min(m.cursor+n, len(m.entries)-1), 0)
+	h := m.listHeight()
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	} else if m.cursor >= m.offset+h {
+		m.offset = m.cursor - h + 1
+	}
+}
+
+func (m *Model) Focus() {
+	m.focused = true
+	m.style = m.style.BorderForeground(theme.Current().Focused)
+}
+
+func (m *Model) Blur() {
+	m.focused = false
+	m.style = m.style.BorderForeground(theme.Current().Base)
+}
+
+func (m Model) Focused() bool {
+	return m.focused
+}
+
+// listHeight is the number of entries displayed at once.
+func (m Model) listHeight() int {
+	return max(m.height-m.style.GetVerticalFrameSize()-headerHeight, 1)
+}
+
+// SetHeight sets the total height of the model, including its border.
+func (m *Model) SetHeight(h int) {
+	m.height = h
+	hc := h - m.style.GetVerticalFrameSize()
+	m.style = m.style.Height(hc).MaxHeight(h)
+	m.moveCursor(0)
+}
+
+// SetWidth sets the total width of the model, including its border and margin.
+func (m *Model) SetWidth(w int) {
+	m.width = w
+	wc := w - m.style.GetHorizontalFrameSize()
+	m.style = m.style.Width(wc).MaxWidth(w)
+}
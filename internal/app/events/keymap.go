@@ -0,0 +1,43 @@
+package events
+
+import (
+	"github.com/charmbracelet/bubbles/key"
+)
+
+type KeyMap struct {
+	Up         key.Binding
+	Down       key.Binding
+	GotoTop    key.Binding
+	GotoBottom key.Binding
+	Toggle     key.Binding
+	ShowAll    key.Binding
+}
+
+func DefaultKeyMap() KeyMap {
+	return KeyMap{
+		Up: key.NewBinding(
+			key.WithKeys("up", "k"),
+			key.WithHelp("↑/k", "up"),
+		),
+		Down: key.NewBinding(
+			key.WithKeys("down", "j"),
+			key.WithHelp("↓/j", "down"),
+		),
+		GotoTop: key.NewBinding(
+			key.WithKeys("home", "g"),
+			key.WithHelp("g/home", "go to start"),
+		),
+		GotoBottom: key.NewBinding(
+			key.WithKeys("end", "G"),
+			key.WithHelp("G/end", "go to end"),
+		),
+		Toggle: key.NewBinding(
+			key.WithKeys(" "),
+			key.WithHelp("space", "hide/show event type"),
+		),
+		ShowAll: key.NewBinding(
+			key.WithKeys("a"),
+			key.WithHelp("a", "show all event types"),
+		),
+	}
+}
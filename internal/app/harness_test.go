@@ -0,0 +1,224 @@
+package app
+
+import (
+	"context"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/cdelmoral/apexlogs/internal/salesforce/sftest"
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+func TestMain(m *testing.M) {
+	lipgloss.SetColorProfile(termenv.Ascii)
+	os.Exit(m.Run())
+}
+
+var cmdType = reflect.TypeOf(tea.Cmd(nil))
+
+// A harness drives a model synchronously, running every command it returns
+// and feeding the resulting messages back into the model.
+//
+// Spinner ticks and cursor blinks are dropped, so animations never run and
+// the rendered views are deterministic.
+type harness struct {
+	t        *testing.T
+	model    tea.Model
+	quitting bool
+}
+
+func newHarness(t *testing.T, srv *sftest.Server) *harness {
+	t.Helper()
+	return newHarnessWithOptions(t, srv, testOptions(t))
+}
+
+func newHarnessWithOptions(t *testing.T, srv *sftest.Server, opts Options) *harness {
+	t.Helper()
+	m := newModel(opts)
+	m.connect = fakeConnect(srv)
+	m.clipboard = io.Discard
+	m.defaultUsername = func(ctx context.Context) (string, error) {
+		return srv.UserInfo().Username, nil
+	}
+	t.Cleanup(m.cancel)
+	return &harness{t: t, model: m}
+}
+
+// testOptions returns the default options with a cache and state private to the test.
+func testOptions(t *testing.T) Options {
+	opts := DefaultOptions()
+	opts.CacheDir = t.TempDir()
+	opts.StateDir = t.TempDir()
+	return opts
+}
+
+// start sets the terminal size and runs the initial commands of the model.
+func (h *harness) start(width, height int) *harness {
+	h.send(tea.WindowSizeMsg{Width: width, Height: height})
+	h.run(h.model.Init())
+	return h
+}
+
+func (h *harness) send(msg tea.Msg) {
+	h.t.Helper()
+	var cmd tea.Cmd
+	h.model, cmd = h.model.Update(msg)
+	h.run(cmd)
+}
+
+// press sends a key message for every key.
+// Keys are named like in [tea.KeyMsg.String], e.g. "enter", "ctrl+c" or "q".
+func (h *harness) press(keys ...string) *harness {
+	h.t.Helper()
+	for _, k := range keys {
+		h.send(keyMsg(k))
+	}
+	return h
+}
+
+// typeText sends a key message for every rune of s.
+func (h *harness) typeText(s string) *harness {
+	h.t.Helper()
+	for _, r := range s {
+		h.send(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	return h
+}
+
+func (h *harness) resize(width, height int) *harness {
+	h.t.Helper()
+	h.send(tea.WindowSizeMsg{Width: width, Height: height})
+	return h
+}
+
+func (h *harness) run(cmd tea.Cmd) {
+	h.t.Helper()
+	if cmd == nil || h.quitting || isBlocking(cmd) {
+		return
+	}
+
+	msg := cmd()
+	switch msg := msg.(type) {
+	case nil, spinner.TickMsg:
+		return
+	case tea.QuitMsg:
+		h.quitting = true
+		return
+	case tea.BatchMsg:
+		for _, c := range msg {
+			h.run(c)
+		}
+		return
+	}
+
+	v := reflect.ValueOf(msg)
+	if v.Kind() == reflect.Slice && v.Type().Elem() == cmdType {
+		// tea.Sequence returns an unexported slice of commands.
+		for i := range v.Len() {
+			h.run(v.Index(i).Interface().(tea.Cmd))
+		}
+		return
+	}
+	if strings.HasSuffix(v.Type().PkgPath(), "bubbles/cursor") {
+		return
+	}
+
+	h.send(msg)
+}
+
+// blockingCmds are the prefixes of the names of the commands that wait for a
+// timer or a background event before returning a message.
+var blockingCmds = []string{
+	"github.com/charmbracelet/bubbles/cursor.",
+	"github.com/charmbracelet/bubbletea.Tick.",
+	"github.com/cdelmoral/apexlogs/internal/app.waitForTraceFlagStatus.",
+}
+
+// isBlocking reports whether cmd is one of the blockingCmds.
+func isBlocking(cmd tea.Cmd) bool {
+	name := runtime.FuncForPC(reflect.ValueOf(cmd).Pointer()).Name()
+	for _, prefix := range blockingCmds {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *harness) view() string {
+	return h.model.View()
+}
+
+// assertGolden compares the current view with testdata/<name>.golden.
+// The golden file is rewritten instead when the tests run with -update.
+func (h *harness) assertGolden(name string) {
+	h.t.Helper()
+	got := h.view()
+	path := filepath.Join("testdata", name+".golden")
+
+	if *update {
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			h.t.Fatalf("error updating golden file: %s", err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		h.t.Fatalf("error reading golden file, run the tests with -update to create it: %s", err)
+	}
+	if got != string(want) {
+		h.t.Errorf("view does not match %s\n--- got:\n%s\n--- want:\n%s", path, got, want)
+	}
+}
+
+// assertFits checks that the view fills the terminal without overflowing it.
+func (h *harness) assertFits(width, height int) {
+	h.t.Helper()
+	v := h.view()
+	if got := lipgloss.Height(v); got != height {
+		h.t.Errorf("expected view height %d, got %d", height, got)
+	}
+	if got := lipgloss.Width(v); got > width {
+		h.t.Errorf("expected view width at most %d, got %d", width, got)
+	}
+}
+
+func keyMsg(k string) tea.KeyMsg {
+	for t, name := range keyNames {
+		if name == k {
+			return tea.KeyMsg{Type: t}
+		}
+	}
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+}
+
+var keyNames = map[tea.KeyType]string{
+	tea.KeyEnter:     "enter",
+	tea.KeyTab:       "tab",
+	tea.KeyShiftTab:  "shift+tab",
+	tea.KeyEsc:       "esc",
+	tea.KeyBackspace: "backspace",
+	tea.KeySpace:     " ",
+	tea.KeyUp:        "up",
+	tea.KeyDown:      "down",
+	tea.KeyLeft:      "left",
+	tea.KeyRight:     "right",
+	tea.KeyPgUp:      "pgup",
+	tea.KeyPgDown:    "pgdown",
+	tea.KeyHome:      "home",
+	tea.KeyEnd:       "end",
+	tea.KeyCtrlC:     "ctrl+c",
+	tea.KeyCtrlF:     "ctrl+f",
+}
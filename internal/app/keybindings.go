@@ -0,0 +1,290 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/bubbles/key"
+)
+
+// The key profiles are sets of key bindings applied before the keys of the
+// configuration.
+const (
+	keyProfileDefault = "default"
+	keyProfileVim     = "vim"
+	keyProfileEmacs   = "emacs"
+)
+
+// keyProfiles are the keys of the actions each profile changes.
+var keyProfiles = map[string]map[string][]string{
+	keyProfileDefault: {},
+	keyProfileVim: {
+		"pageDown":     {"ctrl+f", "pgdown"},
+		"pageUp":       {"ctrl+b", "pgup"},
+		"halfPageDown": {"ctrl+d"},
+		"halfPageUp":   {"ctrl+u"},
+		// ctrl+f scrolls, so the logs are searched like with grep.
+		"search": {"ctrl+g"},
+	},
+	keyProfileEmacs: {
+		"up":         {"up", "ctrl+p"},
+		"down":       {"down", "ctrl+n"},
+		"pageDown":   {"pgdown", "ctrl+v"},
+		"pageUp":     {"pgup", "alt+v"},
+		"gotoTop":    {"home", "alt+<"},
+		"gotoBottom": {"end", "alt+>"},
+		"filter":     {"ctrl+s", "/"},
+		"close":      {"esc", "ctrl+g"},
+	},
+}
+
+// A keyContext is a group of actions handled at the same time, which cannot
+// share keys.
+type keyContext struct {
+	name    string
+	actions []string
+}
+
+var (
+	globalActions = []string{"quit", "help", "switchFocus", "search"}
+	tableActions  = []string{"up", "down", "pageUp", "pageDown", "halfPageUp", "halfPageDown", "gotoTop", "gotoBottom"}
+	listActions   = []string{"up", "down", "gotoTop", "gotoBottom"}
+	scrollActions = []string{"up", "down", "pageUp", "pageDown", "halfPageUp", "halfPageDown"}
+	tabActions    = []string{"nextTab", "prevTab", "closeTab", "anonymous", "tests", "copy"}
+)
+
+var keyContexts = []keyContext{
+	{"list of logs", concat(globalActions, tabActions, tableActions,
+		[]string{"open", "newTab", "refresh", "download", "compare"})},
+	{"log", concat(globalActions, tabActions, scrollActions,
+		[]string{"events", "bookmark", "bookmarks", "nextBookmark", "prevBookmark", "source", "pager", "editor",
+			"copyDisplayed", "copyBody", "filter", "close", "nextMatch", "prevMatch", "filterMode",
+			"moreContext", "lessContext", "syntax", "select"})},
+	{"filter box", []string{"confirm", "close"}},
+	{"search results", concat(globalActions, tableActions, []string{"open", "close"})},
+	{"search box", []string{"open", "close", "search"}},
+	{"event types", concat(globalActions, listActions, []string{"events", "close", "toggleEvent", "showAllEvents"})},
+	{"bookmarks", concat(globalActions, listActions, []string{"bookmarks", "close", "open", "editNote", "deleteBookmark"})},
+	{"bookmark note", []string{"confirm", "close"}},
+	{"anonymous apex editor", []string{"runAnonymous", "close"}},
+	{"test results", concat(globalActions, tableActions, []string{"open", "close"})},
+	{"comparison", concat(globalActions, scrollActions, []string{"nextChange", "prevChange", "close"})},
+}
+
+func concat(lists ...[]string) []string {
+	var all []string
+	for _, l := range lists {
+		all = append(all, l...)
+	}
+	return all
+}
+
+// actions returns the bindings of every action by its name in the
+// configuration. Actions done the same way in several panels, like moving
+// up, change the bindings of all of them.
+func (k *keyMap) actions() map[string][]*key.Binding {
+	return map[string][]*key.Binding{
+		"quit":           {&k.quit},
+		"help":           {&k.help},
+		"switchFocus":    {&k.tab},
+		"open":           {&k.enter, &k.bookmarksKeys.Jump, &k.testrunKeys.Open, &k.resultsKeys.Search},
+		"confirm":        {&k.viewportKeys.Enter, &k.bookmarksKeys.Confirm},
+		"close":          {&k.closeSearch, &k.closeEvents, &k.closeBookmarks, &k.closeAnonymous, &k.closeTests, &k.closeDiff, &k.viewportKeys.Esc, &k.bookmarksKeys.Cancel},
+		"refresh":        {&k.refresh},
+		"download":       {&k.download},
+		"search":         {&k.search},
+		"compare":        {&k.compare},
+		"newTab":         {&k.newTab},
+		"nextTab":        {&k.nextTab},
+		"prevTab":        {&k.prevTab},
+		"closeTab":       {&k.closeTab},
+		"events":         {&k.events},
+		"toggleEvent":    {&k.eventsKeys.Toggle},
+		"showAllEvents":  {&k.eventsKeys.ShowAll},
+		"bookmark":       {&k.bookmark, &k.bookmarksKeys.Add},
+		"bookmarks":      {&k.bookmarks},
+		"nextBookmark":   {&k.nextBookmark},
+		"prevBookmark":   {&k.prevBookmark},
+		"editNote":       {&k.bookmarksKeys.Note},
+		"deleteBookmark": {&k.bookmarksKeys.Delete},
+		"source":         {&k.source},
+		"pager":          {&k.pager},
+		"editor":         {&k.editor},
+		"copy":           {&k.copyId, &k.copyLine},
+		"copyDisplayed":  {&k.copyDisplayed},
+		"copyBody":       {&k.copyBody},
+		"anonymous":      {&k.anonymous},
+		"runAnonymous":   {&k.anonymousKeys.Run},
+		"tests":          {&k.tests},
+		"filter":         {&k.viewportKeys.Slash},
+		"nextMatch":      {&k.viewportKeys.NextMatch},
+		"prevMatch":      {&k.viewportKeys.PrevMatch},
+		"filterMode":     {&k.viewportKeys.ToggleMode},
+		"moreContext":    {&k.viewportKeys.MoreContext},
+		"lessContext":    {&k.viewportKeys.LessContext},
+		"syntax":         {&k.viewportKeys.Syntax},
+		"select":         {&k.viewportKeys.Select},
+		"nextChange":     {&k.diffKeys.NextChange},
+		"prevChange":     {&k.diffKeys.PrevChange},
+		"up":             {&k.tableKeys.LineUp, &k.viewportKeys.Up, &k.eventsKeys.Up, &k.bookmarksKeys.Up, &k.diffKeys.Up},
+		"down":           {&k.tableKeys.LineDown, &k.viewportKeys.Down, &k.eventsKeys.Down, &k.bookmarksKeys.Down, &k.diffKeys.Down},
+		"pageUp":         {&k.tableKeys.PageUp, &k.viewportKeys.PageUp, &k.diffKeys.PageUp},
+		"pageDown":       {&k.tableKeys.PageDown, &k.viewportKeys.PageDown, &k.diffKeys.PageDown},
+		"halfPageUp":     {&k.tableKeys.HalfPageUp, &k.viewportKeys.HalfPageUp, &k.diffKeys.HalfPageUp},
+		"halfPageDown":   {&k.tableKeys.HalfPageDown, &k.viewportKeys.HalfPageDown, &k.diffKeys.HalfPageDown},
+		"gotoTop":        {&k.tableKeys.GotoTop, &k.eventsKeys.GotoTop, &k.bookmarksKeys.GotoTop},
+		"gotoBottom":     {&k.tableKeys.GotoBottom, &k.eventsKeys.GotoBottom, &k.bookmarksKeys.GotoBottom},
+	}
+}
+
+// newKeyMap returns the default key bindings changed by the given profile,
+// which is the default one when empty, and then by the keys of the given
+// actions. An error is returned if a name or a key is not valid, or if a key
+// is bound to several actions handled at the same time.
+func newKeyMap(profile string, overrides map[string][]string) (keyMap, error) {
+	k := keys
+	if profile == "" {
+		profile = keyProfileDefault
+	}
+	base, ok := keyProfiles[profile]
+	if !ok {
+		return keys, fmt.Errorf("unknown key profile %q, expected %s, %s or %s", profile, keyProfileDefault, keyProfileVim, keyProfileEmacs)
+	}
+
+	actions := k.actions()
+	var errs []error
+	for _, bindings := range []map[string][]string{base, overrides} {
+		names := make([]string, 0, len(bindings))
+		for name := range bindings {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			bs, ok := actions[name]
+			if !ok {
+				errs = append(errs, fmt.Errorf("unknown action %q", name))
+				continue
+			}
+			ks, err := parseKeys(bindings[name])
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", name, err))
+				continue
+			}
+			for _, b := range bs {
+				b.SetKeys(ks...)
+				b.SetHelp(keysHelp(ks), b.Help().Desc)
+			}
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return keys, err
+	}
+
+	// The lists of event types and bookmarks are also closed with the keys
+	// opening them.
+	closeKeys := k.closeSearch.Keys()
+	k.closeEvents.SetKeys(concat(closeKeys, k.events.Keys())...)
+	k.closeBookmarks.SetKeys(concat(closeKeys, k.bookmarks.Keys())...)
+
+	if err := k.conflicts(); err != nil {
+		return keys, err
+	}
+	return k, nil
+}
+
+// conflicts returns an error for every key bound to several actions of a context.
+func (k *keyMap) conflicts() error {
+	actions := k.actions()
+	var errs []error
+	reported := map[string]bool{}
+	for _, c := range keyContexts {
+		bound := map[string]string{}
+		for _, action := range c.actions {
+			for _, s := range actions[action][0].Keys() {
+				other, ok := bound[s]
+				if ok && other != action && !reported[other+" "+action+" "+s] {
+					reported[other+" "+action+" "+s] = true
+					errs = append(errs, fmt.Errorf("key %q is bound to both %s and %s in the %s", keyName(s), other, action, c.name))
+				}
+				bound[s] = action
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// namedKeys are the names of the keys that do not type a character.
+var namedKeys = map[string]bool{
+	"enter": true, "tab": true, "shift+tab": true, "esc": true, "backspace": true, "delete": true,
+	"insert": true, "space": true, "up": true, "down": true, "left": true, "right": true,
+	"home": true, "end": true, "pgup": true, "pgdown": true,
+}
+
+// functionKeyRe matches the names of the function keys, from f1 to f20.
+var functionKeyRe = regexp.MustCompile(`^f([1-9]|1[0-9]|20)$`)
+
+// parseKeys validates the names of the keys of an action, returning them
+// like the key messages name them.
+func parseKeys(names []string) ([]string, error) {
+	ks := make([]string, 0, len(names))
+	for _, name := range names {
+		if !validKey(name) {
+			return nil, fmt.Errorf("invalid key %q, expected a character or a name like \"ctrl+x\", \"alt+x\" or \"pgdown\"", name)
+		}
+		if name == "space" {
+			name = " "
+		}
+		ks = append(ks, name)
+	}
+	return ks, nil
+}
+
+func validKey(name string) bool {
+	name = strings.TrimPrefix(name, "alt+")
+	if utf8.RuneCountInString(name) == 1 {
+		return name != " "
+	}
+	if namedKeys[name] {
+		return true
+	}
+	if rest, ok := strings.CutPrefix(name, "ctrl+"); ok && len(rest) == 1 {
+		return rest[0] >= 'a' && rest[0] <= 'z' || strings.Contains(`@[\]^_`, rest)
+	}
+	if functionKeyRe.MatchString(name) {
+		return true
+	}
+	// The arrows and the keys moving the cursor can be combined with ctrl and shift.
+	switch strings.TrimPrefix(strings.TrimPrefix(name, "ctrl+"), "shift+") {
+	case "up", "down", "left", "right", "home", "end", "pgup", "pgdown":
+		return true
+	}
+	return false
+}
+
+// keysHelp describes the first two keys of an action in the help.
+func keysHelp(ks []string) string {
+	names := make([]string, 0, 2)
+	for _, k := range ks[:min(len(ks), 2)] {
+		names = append(names, keyName(k))
+	}
+	return strings.Join(names, "/")
+}
+
+// keyName returns the name of a key displayed to the user.
+func keyName(k string) string {
+	switch k {
+	case " ":
+		return "space"
+	case "up":
+		return "↑"
+	case "down":
+		return "↓"
+	case "pgdown":
+		return "pgdn"
+	}
+	return k
+}
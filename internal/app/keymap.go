@@ -1,20 +1,70 @@
 package app
 
 import (
+	"github.com/cdelmoral/apexlogs/internal/app/anonymous"
+	"github.com/cdelmoral/apexlogs/internal/app/bookmarks"
+	"github.com/cdelmoral/apexlogs/internal/app/diff"
+	"github.com/cdelmoral/apexlogs/internal/app/events"
+	"github.com/cdelmoral/apexlogs/internal/app/results"
+	"github.com/cdelmoral/apexlogs/internal/app/testrun"
 	"github.com/cdelmoral/apexlogs/internal/app/viewport"
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/table"
 )
 
 type keyMap struct {
-	quit         key.Binding
-	enter        key.Binding
-	tab          key.Binding
-	help         key.Binding
-	refresh      key.Binding
-	filter       key.Binding
-	showTable    bool
-	showViewport bool
+	quit           key.Binding
+	enter          key.Binding
+	tab            key.Binding
+	help           key.Binding
+	refresh        key.Binding
+	download       key.Binding
+	filter         key.Binding
+	search         key.Binding
+	closeSearch    key.Binding
+	events         key.Binding
+	closeEvents    key.Binding
+	compare        key.Binding
+	closeDiff      key.Binding
+	newTab         key.Binding
+	nextTab        key.Binding
+	prevTab        key.Binding
+	closeTab       key.Binding
+	bookmark       key.Binding
+	bookmarks      key.Binding
+	closeBookmarks key.Binding
+	nextBookmark   key.Binding
+	prevBookmark   key.Binding
+	source         key.Binding
+	pager          key.Binding
+	editor         key.Binding
+	copyId         key.Binding
+	copyLine       key.Binding
+	copyDisplayed  key.Binding
+	copyBody       key.Binding
+	anonymous      key.Binding
+	closeAnonymous key.Binding
+	tests          key.Binding
+	closeTests     key.Binding
+	// The key bindings of the panels, which are remapped together with the
+	// ones of the application.
+	tableKeys     table.KeyMap
+	resultsKeys   results.KeyMap
+	viewportKeys  viewport.KeyMap
+	eventsKeys    events.KeyMap
+	bookmarksKeys bookmarks.KeyMap
+	anonymousKeys anonymous.KeyMap
+	testrunKeys   testrun.KeyMap
+	diffKeys      diff.KeyMap
+	showTable     bool
+	showResults   bool
+	showEvents    bool
+	showBookmarks bool
+	showAnonymous bool
+	showTests     bool
+	showViewport  bool
+	showDiff      bool
+	showTabs      bool
 }
 
 func (k keyMap) ShortHelp() []key.Binding {
@@ -24,10 +74,18 @@ func (k keyMap) ShortHelp() []key.Binding {
 func (k keyMap) FullHelp() [][]key.Binding {
 	var ks [][]key.Binding
 	if k.showTable {
-		tk := table.DefaultKeyMap()
+		tk := k.tableKeys
 		ks = append(ks, []key.Binding{
 			k.enter,
+			k.newTab,
 			k.refresh,
+			k.download,
+			k.compare,
+			k.copyId,
+			k.search,
+			k.anonymous,
+			k.tests,
+		}, []key.Binding{
 			tk.LineUp,
 			tk.LineDown,
 			tk.PageUp,
@@ -38,22 +96,117 @@ func (k keyMap) FullHelp() [][]key.Binding {
 			tk.GotoBottom,
 		})
 	}
+	if k.showResults {
+		tk := k.tableKeys
+		ks = append(ks, []key.Binding{
+			k.search,
+			key.NewBinding(key.WithKeys(k.enter.Keys()...), key.WithHelp(k.enter.Help().Key, "search/open selected line")),
+			k.closeSearch,
+			tk.LineUp,
+			tk.LineDown,
+			tk.PageUp,
+			tk.PageDown,
+			tk.GotoTop,
+			tk.GotoBottom,
+		})
+	}
+	if k.showEvents {
+		ek := k.eventsKeys
+		ks = append(ks, []key.Binding{
+			ek.Toggle,
+			ek.ShowAll,
+			k.closeEvents,
+			ek.Up,
+			ek.Down,
+			ek.GotoTop,
+			ek.GotoBottom,
+		})
+	}
 	if k.showViewport {
-		vk := viewport.DefaultKeyMap()
+		vk := k.viewportKeys
 		ks = append(ks, []key.Binding{
 			vk.Slash,
 			vk.Enter,
 			vk.Esc,
+			vk.NextMatch,
+			vk.PrevMatch,
+			vk.ToggleMode,
+			vk.MoreContext,
+			vk.LessContext,
+		}, []key.Binding{
+			vk.Syntax,
+			k.events,
 			vk.PageDown,
 			vk.PageUp,
 			vk.HalfPageUp,
 			vk.HalfPageDown,
 			vk.Down,
 			vk.Up,
+		}, []key.Binding{
+			k.bookmark,
+			k.nextBookmark,
+			k.prevBookmark,
+			k.bookmarks,
+			k.source,
+			k.pager,
+			k.editor,
+			vk.Select,
+			k.copyLine,
+			k.copyDisplayed,
+			k.copyBody,
+		})
+	}
+	if k.showBookmarks {
+		bk := k.bookmarksKeys
+		ks = append(ks, []key.Binding{
+			bk.Jump,
+			bk.Note,
+			bk.Delete,
+			k.closeBookmarks,
+			bk.Up,
+			bk.Down,
+			bk.GotoTop,
+			bk.GotoBottom,
+		})
+	}
+
+	if k.showAnonymous {
+		ak := k.anonymousKeys
+		ks = append(ks, []key.Binding{ak.Run, k.closeAnonymous})
+	}
+	if k.showTests {
+		tk := k.tableKeys
+		ks = append(ks, []key.Binding{
+			k.testrunKeys.Open,
+			k.closeTests,
+			tk.LineUp,
+			tk.LineDown,
+			tk.PageUp,
+			tk.PageDown,
+			tk.GotoTop,
+			tk.GotoBottom,
+		})
+	}
+	if k.showDiff {
+		dk := k.diffKeys
+		ks = append(ks, []key.Binding{
+			dk.NextChange,
+			dk.PrevChange,
+			k.closeDiff,
+			dk.PageDown,
+			dk.PageUp,
+			dk.HalfPageUp,
+			dk.HalfPageDown,
+			dk.Down,
+			dk.Up,
 		})
 	}
-	ks = append(ks, []key.Binding{k.tab, k.help, k.quit})
-	return ks
+	// The tab keys share the last column to fit the help in narrow terminals.
+	last := []key.Binding{k.tab, k.help, k.quit}
+	if k.showTabs && (k.showTable || k.showViewport) {
+		last = append(last, k.nextTab, k.prevTab, k.closeTab)
+	}
+	return append(ks, last)
 }
 
 var keys = keyMap{
@@ -69,6 +222,10 @@ var keys = keyMap{
 		key.WithKeys("r"),
 		key.WithHelp("r", "refresh apex logs"),
 	),
+	download: key.NewBinding(
+		key.WithKeys("D"),
+		key.WithHelp("D", "download listed apex logs"),
+	),
 	tab: key.NewBinding(
 		key.WithKeys("tab"),
 		key.WithHelp("tab", "switch focus"),
@@ -77,4 +234,118 @@ var keys = keyMap{
 		key.WithKeys("?"),
 		key.WithHelp("?", "toggle help"),
 	),
+	search: key.NewBinding(
+		key.WithKeys("ctrl+f"),
+		key.WithHelp("ctrl+f", "search all logs"),
+	),
+	closeSearch: key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "close search results"),
+	),
+	events: key.NewBinding(
+		key.WithKeys("e"),
+		key.WithHelp("e", "hide/show event types"),
+	),
+	closeEvents: key.NewBinding(
+		key.WithKeys("esc", "e"),
+		key.WithHelp("esc", "close event types"),
+	),
+	compare: key.NewBinding(
+		key.WithKeys("c"),
+		key.WithHelp("c", "mark log to compare"),
+	),
+	closeDiff: key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "close comparison"),
+	),
+	newTab: key.NewBinding(
+		key.WithKeys("t"),
+		key.WithHelp("t", "open selected apex log in new tab"),
+	),
+	nextTab: key.NewBinding(
+		key.WithKeys("]"),
+		key.WithHelp("]", "next tab"),
+	),
+	prevTab: key.NewBinding(
+		key.WithKeys("["),
+		key.WithHelp("[", "previous tab"),
+	),
+	closeTab: key.NewBinding(
+		key.WithKeys("x"),
+		key.WithHelp("x", "close tab"),
+	),
+	bookmark: key.NewBinding(
+		key.WithKeys("B"),
+		key.WithHelp("B", "toggle bookmark"),
+	),
+	bookmarks: key.NewBinding(
+		key.WithKeys("L"),
+		key.WithHelp("L", "list bookmarks"),
+	),
+	closeBookmarks: key.NewBinding(
+		key.WithKeys("esc", "L"),
+		key.WithHelp("esc", "close bookmarks"),
+	),
+	nextBookmark: key.NewBinding(
+		key.WithKeys(">"),
+		key.WithHelp(">", "next bookmark"),
+	),
+	prevBookmark: key.NewBinding(
+		key.WithKeys("<"),
+		key.WithHelp("<", "previous bookmark"),
+	),
+	source: key.NewBinding(
+		key.WithKeys("o"),
+		key.WithHelp("o", "open apex source"),
+	),
+	copyId: key.NewBinding(
+		key.WithKeys("y"),
+		key.WithHelp("y", "copy log id"),
+	),
+	copyLine: key.NewBinding(
+		key.WithKeys("y"),
+		key.WithHelp("y", "copy line/selection"),
+	),
+	copyDisplayed: key.NewBinding(
+		key.WithKeys("Y"),
+		key.WithHelp("Y", "copy displayed lines"),
+	),
+	copyBody: key.NewBinding(
+		key.WithKeys("ctrl+y"),
+		key.WithHelp("ctrl+y", "copy whole log"),
+	),
+	anonymous: key.NewBinding(
+		key.WithKeys("A"),
+		key.WithHelp("A", "run anonymous apex"),
+	),
+	closeAnonymous: key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "close anonymous apex"),
+	),
+	tests: key.NewBinding(
+		key.WithKeys("T"),
+		key.WithHelp("T", "show test results"),
+		// Enabled once tests are run.
+		key.WithDisabled(),
+	),
+	closeTests: key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "close test results"),
+	),
+	pager: key.NewBinding(
+		key.WithKeys("v"),
+		key.WithHelp("v", "view lines in pager"),
+	),
+	editor: key.NewBinding(
+		key.WithKeys("V"),
+		key.WithHelp("V", "open lines in editor"),
+	),
+	tableKeys:     table.DefaultKeyMap(),
+	resultsKeys:   results.DefaultKeyMap(),
+	viewportKeys:  viewport.DefaultKeyMap(),
+	eventsKeys:    events.DefaultKeyMap(),
+	bookmarksKeys: bookmarks.DefaultKeyMap(),
+	anonymousKeys: anonymous.DefaultKeyMap(),
+	testrunKeys:   testrun.DefaultKeyMap(),
+	diffKeys:      diff.DefaultKeyMap(),
 }
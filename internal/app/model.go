@@ -1,13 +1,31 @@
 package app
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log"
+	"os"
 	"time"
 
+	"github.com/cdelmoral/apexlogs/internal/apexlog"
+	"github.com/cdelmoral/apexlogs/internal/app/anonymous"
+	"github.com/cdelmoral/apexlogs/internal/app/bookmarks"
+	"github.com/cdelmoral/apexlogs/internal/app/diff"
+	"github.com/cdelmoral/apexlogs/internal/app/events"
+	"github.com/cdelmoral/apexlogs/internal/app/results"
+	"github.com/cdelmoral/apexlogs/internal/app/statusbar"
 	apptable "github.com/cdelmoral/apexlogs/internal/app/table"
+	"github.com/cdelmoral/apexlogs/internal/app/testrun"
 	"github.com/cdelmoral/apexlogs/internal/app/viewport"
+	"github.com/cdelmoral/apexlogs/internal/cache"
+	"github.com/cdelmoral/apexlogs/internal/download"
+	"github.com/cdelmoral/apexlogs/internal/logdiff"
 	sf "github.com/cdelmoral/apexlogs/internal/salesforce"
+	"github.com/cdelmoral/apexlogs/internal/search"
+	"github.com/cdelmoral/apexlogs/internal/theme"
+	"github.com/cdelmoral/apexlogs/internal/traceflag"
 	"github.com/charmbracelet/bubbles/help"
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/table"
@@ -16,58 +34,215 @@ import (
 )
 
 const (
-	defaultDebugLevelName = "SFDC_DevConsole"
+	traceFlagTickPeriod = 30 * time.Second
+	// logChunkSize is the amount of an apex log read before displaying it while it is downloaded.
+	logChunkSize = 1 << 20
 )
 
-// A TraceFlagNotFoundError is an error that occurs when a trace flag is not found.
-type TraceFlagNotFoundError struct {
-	s string
-}
-
-func (t *TraceFlagNotFoundError) Error() string {
-	return t.s
-}
-
 type startFetchingLogsMsg struct{}
 
+// A selectApexLogMsg opens the apex log with the given id, scrolled to the
+// given zero based line, in the current tab or in a new one. A log already
+// open is switched to, keeping its scroll position unless scroll is set.
 type selectApexLogMsg struct {
-	id string
+	id     string
+	line   int
+	newTab bool
+	scroll bool
 }
 
 type apexLogsMsg struct {
+	logs []sf.ApexLog
+}
+
+// An orgConnectedMsg is sent once the org is ready to generate and fetch logs.
+// The client and the trace flag manager are nil when browsing the logs offline
+// or viewing local files. The search index is nil when searching is not
+// available, otherwise indexLogs fills it in the background, returning the
+// ids of the logs truncated by Salesforce. The cache is nil when the logs are
+// not cached, so bookmarks are not saved.
+type orgConnectedMsg struct {
+	source           logSource
+	cache            *cache.Cache
 	salesforceClient *sf.Client
+	userId           string
+	traceFlags       *traceflag.Manager
+	traceFlag        traceflag.Status
+	index            *search.Index
+	indexLogs        func() []string
 	logs             []sf.ApexLog
+	local            bool
+}
+
+// An errMsg reports an error that does not prevent the application from running.
+type errMsg struct {
+	err error
 }
 
-type apexLogBodyMsg struct {
-	body string
+type traceFlagStatusMsg traceflag.Status
+
+type traceFlagTickMsg time.Time
+
+// An apexLogChunkMsg delivers the next part of the apex log being opened.
+// The message with done set carries the whole body, and updates is closed after it.
+type apexLogChunkMsg struct {
+	id    string
+	chunk string
+	// read is the number of bytes read so far, out of size, which is -1 if unknown.
+	read    int64
+	size    int64
+	first   bool
+	done    bool
+	err     error
+	updates <-chan apexLogChunkMsg
 }
 
+// A loadError is an error loading the apex log with the given id.
+type loadError struct {
+	id  string
+	err error
+}
+
+func (e *loadError) Error() string {
+	return fmt.Sprintf("error getting apex log: %s", e.err)
+}
+
+func (e *loadError) Unwrap() error {
+	return e.err
+}
+
+// A downloadProgressMsg reports the progress of a bulk download.
+// updates is closed after the message with done set.
+type downloadProgressMsg struct {
+	progress download.Progress
+	err      error
+	done     bool
+	updates  <-chan downloadProgressMsg
+}
+
+// A truncatedLogsMsg reports the logs found to be truncated by Salesforce.
+type truncatedLogsMsg struct {
+	ids []string
+}
+
+// A diffResultMsg delivers the comparison of the logs with the given ids.
+type diffResultMsg struct {
+	ids    [2]string
+	result logdiff.Result
+	err    error
+}
+
+type searchResultsMsg struct {
+	results []search.Result
+	err     error
+}
+
+// A connectFunc returns a client for the Salesforce org to fetch the logs from,
+// together with the information of the user the logs are traced for.
+type connectFunc func(ctx context.Context) (*sf.Client, sf.UserInfo, error)
+
 type model struct {
+	options          Options
+	connect          connectFunc
+	defaultUsername  func(ctx context.Context) (string, error)
+	stdin            io.Reader
+	clipboard        io.Writer
+	source           logSource
+	cache            *cache.Cache
+	ctx              context.Context
+	cancel           context.CancelFunc
 	help             help.Model
 	salesforceClient *sf.Client
-	logBody          string
-	selectedLogId    string
+	userId           string
+	traceFlags       *traceflag.Manager
+	index            *search.Index
+	logs             []sf.ApexLog
+	tabs             []logTab
+	tab              int
+	marked           []string
 	keys             keyMap
 	viewport         viewport.Model
 	table            apptable.Model
+	results          results.Model
+	events           events.Model
+	bookmarks        bookmarks.Model
+	anonymous        anonymous.Model
+	testrun          testrun.Model
+	diff             diff.Model
+	statusbar        statusbar.Model
 	terminalHeight   int
 	terminalWidth    int
 	viewportReady    bool
+	left             leftPanel
+	under            leftPanel
+	showDiff         bool
+	downloading      bool
 	quitting         bool
 }
 
-func newModel() model {
+func newModel(opts Options) model {
+	// The theme is set before creating the panels, which read it.
+	th, err := theme.Resolve(opts.Theme, opts.Themes, lipgloss.HasDarkBackground)
+	if err != nil {
+		log.Printf("error loading theme: %s", err)
+		th, _ = theme.Resolve(theme.Auto, nil, lipgloss.HasDarkBackground)
+	}
+	theme.Set(th)
+
 	t := apptable.New(table.WithFocused(true), table.WithHeight(10))
 	t.Focus()
 
-	keys.showTable = true
-	keys.showViewport = false
+	// The options loaded with LoadOptions have valid keys, the defaults are
+	// used otherwise.
+	km, err := newKeyMap(opts.KeyProfile, opts.Keys)
+	if err != nil {
+		log.Printf("error loading key bindings: %s", err)
+	}
+	km.showTable = true
+	km.showViewport = false
+	t.KeyMap = km.tableKeys
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// A broken state file is not fatal, everything is shown instead.
+	st, err := loadState(opts.StateDir)
+	if err != nil {
+		log.Printf("error loading state: %s", err)
+	}
+
+	rs := results.New()
+	rs.KeyMap = km.resultsKeys
+	rs.SetTableKeyMap(km.tableKeys)
+	ev := events.New(st.HiddenEvents)
+	ev.KeyMap = km.eventsKeys
+	bm := bookmarks.New()
+	bm.KeyMap = km.bookmarksKeys
+	an := anonymous.New()
+	an.KeyMap = km.anonymousKeys
+	tr := testrun.New()
+	tr.KeyMap = km.testrunKeys
+	tr.SetTableKeyMap(km.tableKeys)
+	df := diff.New()
+	df.KeyMap = km.diffKeys
 
 	return model{
-		table: t,
-		keys:  keys,
-		help:  help.New(),
+		options:         opts,
+		connect:         connectDefaultOrg(opts.ApiVersion, opts.clientOptions()...),
+		defaultUsername: sf.GetDefaultUsername,
+		stdin:           os.Stdin,
+		clipboard:       os.Stderr,
+		ctx:             ctx,
+		cancel:          cancel,
+		table:           t,
+		results:         rs,
+		events:          ev,
+		bookmarks:       bm,
+		anonymous:       an,
+		testrun:         tr,
+		diff:            df,
+		statusbar:       statusbar.New(),
+		keys:            km,
+		help:            help.New(),
 	}
 }
 
@@ -75,7 +250,13 @@ func (m model) Init() tea.Cmd {
 	startSpinners := func() tea.Msg {
 		return startFetchingLogsMsg{}
 	}
-	return tea.Sequence(startSpinners, initApexLogs)
+	if len(m.options.LocalPaths) > 0 {
+		return tea.Sequence(startSpinners, initLocalLogsCmd(m.ctx, m.options.LocalPaths, m.stdin))
+	}
+	if m.options.Offline {
+		return tea.Sequence(startSpinners, initOfflineLogsCmd(m.ctx, m.defaultUsername, m.options))
+	}
+	return tea.Sequence(startSpinners, initApexLogsCmd(m.ctx, m.connect, m.options))
 }
 
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -84,27 +265,163 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		if m.left == resultsPanel && m.results.Focused() {
+			switch {
+			case key.Matches(msg, m.keys.enter):
+				if m.results.Typing() {
+					return m, m.searchLogs()
+				}
+				if r, ok := m.results.Selected(); ok {
+					return m, func() tea.Msg { return selectApexLogMsg{id: r.LogId, line: r.Line, scroll: true} }
+				}
+				return m, nil
+			case key.Matches(msg, m.keys.closeSearch):
+				m.closeLeft()
+				return m, nil
+			case key.Matches(msg, m.keys.search):
+				return m, m.results.EditQuery()
+			}
+		}
+		if m.left == eventsPanel && m.events.Focused() && key.Matches(msg, m.keys.closeEvents) {
+			m.closeLeft()
+			return m, nil
+		}
+		if m.left == bookmarksPanel && m.bookmarks.Focused() && !m.bookmarks.Typing() && key.Matches(msg, m.keys.closeBookmarks) {
+			m.closeLeft()
+			return m, nil
+		}
+		if m.left == anonymousPanel && m.anonymous.Focused() && key.Matches(msg, m.keys.closeAnonymous) {
+			m.closeLeft()
+			return m, nil
+		}
+		if m.left == testsPanel && m.testrun.Focused() && key.Matches(msg, m.keys.closeTests) {
+			m.closeLeft()
+			return m, nil
+		}
+		if m.showDiff && m.diff.Focused() && key.Matches(msg, m.keys.closeDiff) {
+			m.closeDiff()
+			return m, nil
+		}
+		if m.typing() && msg.Type != tea.KeyCtrlC {
+			break
+		}
+
 		switch {
 		case key.Matches(msg, m.keys.quit):
 			m.quitting = true
+			m.cancel()
 			return m, tea.Quit
+		case key.Matches(msg, m.keys.search):
+			return m, m.openResults()
 		case key.Matches(msg, m.keys.tab):
-			m.switchFocus()
+			cmd = m.switchFocus()
 			m.resize()
-			return m, nil
+			return m, cmd
 		case key.Matches(msg, m.keys.enter):
 			if m.table.Focused() {
 				return m, m.selectApexLog
 			}
+		case key.Matches(msg, m.keys.newTab):
+			if m.table.Focused() {
+				return m, m.selectApexLogInNewTab
+			}
+		case key.Matches(msg, m.keys.nextTab):
+			if m.table.Focused() || m.viewport.Focused() {
+				m.switchTab((m.tab + 1) % max(len(m.tabs), 1))
+				return m, nil
+			}
+		case key.Matches(msg, m.keys.prevTab):
+			if m.table.Focused() || m.viewport.Focused() {
+				m.switchTab((m.tab + len(m.tabs) - 1) % max(len(m.tabs), 1))
+				return m, nil
+			}
+		case key.Matches(msg, m.keys.closeTab):
+			if m.table.Focused() || m.viewport.Focused() {
+				m.closeTab()
+				return m, nil
+			}
 		case key.Matches(msg, m.keys.help):
 			m.help.ShowAll = !m.help.ShowAll
 			m.resize()
 			return m, nil
+		case key.Matches(msg, m.keys.events):
+			if m.viewport.Focused() {
+				return m, m.openLeft(eventsPanel)
+			}
+		case key.Matches(msg, m.keys.bookmark):
+			if m.logReady() {
+				m.toggleBookmark()
+				return m, nil
+			}
+		case key.Matches(msg, m.keys.bookmarks):
+			if m.viewport.Focused() && len(m.tabs) > 0 {
+				return m, m.openLeft(bookmarksPanel)
+			}
+		case key.Matches(msg, m.keys.nextBookmark):
+			if m.viewport.Focused() && len(m.tabs) > 0 {
+				m.gotoBookmark(1)
+				return m, nil
+			}
+		case key.Matches(msg, m.keys.prevBookmark):
+			if m.viewport.Focused() && len(m.tabs) > 0 {
+				m.gotoBookmark(-1)
+				return m, nil
+			}
+		case key.Matches(msg, m.keys.source):
+			if m.logReady() {
+				loc, ok := apexlog.SourceLocation(m.viewport.Lines(), m.viewport.CurrentLine())
+				if !ok {
+					m.statusbar.SetError(errNoSource)
+					return m, nil
+				}
+				return m, findSourceCmd(m.options.ProjectDir, loc)
+			}
+		case key.Matches(msg, m.keys.pager), key.Matches(msg, m.keys.editor):
+			if m.logReady() {
+				editor := key.Matches(msg, m.keys.editor)
+				return m, externalViewCmd(m.tabs[m.tab].id, m.viewport.DisplayedContent(), editor)
+			}
+		case key.Matches(msg, m.keys.copyId, m.keys.copyLine):
+			if m.table.Focused() {
+				if id := m.table.SelectedLogId(); id != "" {
+					return m, copyCmd(m.clipboard, id, "the log id")
+				}
+				return m, nil
+			}
+			if m.logReady() {
+				return m, m.copySelectionCmd()
+			}
+		case key.Matches(msg, m.keys.copyDisplayed):
+			if m.logReady() {
+				return m, m.copyDisplayedCmd()
+			}
+		case key.Matches(msg, m.keys.copyBody):
+			if m.logReady() {
+				return m, m.copyBodyCmd()
+			}
+		case key.Matches(msg, m.keys.anonymous):
+			if m.table.Focused() || m.viewport.Focused() {
+				return m, m.openAnonymous()
+			}
+		case key.Matches(msg, m.keys.tests):
+			if m.table.Focused() || m.viewport.Focused() {
+				return m, m.openLeft(testsPanel)
+			}
+		case key.Matches(msg, m.keys.compare):
+			if m.table.Focused() && m.source != nil {
+				return m, m.markLog()
+			}
+		case key.Matches(msg, m.keys.download):
+			if m.table.Focused() && !m.downloading && m.source != nil {
+				m.downloading = true
+				m.statusbar.SetDownload(download.Progress{Total: len(m.logs)}, m.options.DownloadDir, true)
+				return m, downloadApexLogsCmd(m.ctx, m.source, m.logs, m.options.DownloadDir)
+			}
 		case key.Matches(msg, m.keys.refresh):
 			if m.table.Focused() {
 				m.table.SetLogs([]sf.ApexLog{})
 				cmds = append(cmds, m.table.StartSpinner())
-				cmds = append(cmds, refreshApexLogsCmd(m.salesforceClient))
+				cmds = append(cmds, refreshApexLogsCmd(m.ctx, m.source))
 				return m, tea.Sequence(cmds...)
 			}
 		}
@@ -112,22 +429,238 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		cmd = m.table.StartSpinner()
 		m.viewport.SetContent("")
 		return m, cmd
-	case apexLogsMsg:
+	case orgConnectedMsg:
 		m.table.StopSpinner()
 		m.table.SetLogs(msg.logs)
+		m.logs = msg.logs
+		m.source = msg.source
+		m.cache = msg.cache
 		m.salesforceClient = msg.salesforceClient
+		m.userId = msg.userId
+		m.traceFlags = msg.traceFlags
+		m.index = msg.index
+		if msg.indexLogs != nil {
+			cmds = append(cmds, indexLogsCmd(msg.indexLogs))
+		}
+		if msg.local {
+			m.statusbar.SetLocal(true)
+			return m, tea.Batch(cmds...)
+		}
+		if m.traceFlags == nil {
+			m.statusbar.SetOffline(true)
+			return m, tea.Batch(cmds...)
+		}
+		m.statusbar.SetTraceFlag(msg.traceFlag, time.Now())
+		m.updateApiUsage()
+		cmds = append(cmds, waitForTraceFlagStatus(m.traceFlags), traceFlagTick())
+		if code := m.options.AnonymousApex; code != "" {
+			cmds = append(cmds, m.openAnonymous())
+			m.anonymous.SetCode(code)
+			m.anonymous.SetRunning()
+			cmds = append(cmds, runAnonymousCmd(m.ctx, m.salesforceClient, m.userId, m.source, code))
+		}
+		if classes := m.options.TestClasses; len(classes) > 0 {
+			cmds = append(cmds, m.startTests(classes))
+		}
+		return m, tea.Batch(cmds...)
+	case traceFlagStatusMsg:
+		m.statusbar.SetTraceFlag(traceflag.Status(msg), time.Now())
+		m.updateApiUsage()
+		return m, waitForTraceFlagStatus(m.traceFlags)
+	case traceFlagTickMsg:
+		m.statusbar.SetTime(time.Time(msg))
+		return m, traceFlagTick()
+	case apexLogsMsg:
+		m.table.StopSpinner()
+		m.table.SetLogs(msg.logs)
+		m.logs = msg.logs
+		m.statusbar.SetError(nil)
+		m.updateApiUsage()
+		return m, nil
+	case errMsg:
+		m.table.StopSpinner()
+		m.viewport.StopSpinner()
+		m.statusbar.SetError(msg.err)
+		m.updateApiUsage()
 		return m, nil
 	case selectApexLogMsg:
+		m.showDiff = false
+		if i := m.tabIndex(msg.id); i >= 0 {
+			m.switchTab(i)
+			if msg.scroll {
+				m.tabs[i].line = msg.line
+				m.viewport.GotoLine(msg.line)
+			}
+			m.focusViewport()
+			m.resize()
+			return m, nil
+		}
+
+		ctx, cancel := context.WithCancel(m.ctx)
+		t := logTab{id: msg.id, line: msg.line, cancel: cancel, loading: true, bookmarks: m.loadBookmarks(msg.id)}
+		if msg.newTab {
+			m.addTab(t)
+		} else {
+			m.replaceTab(t)
+		}
+		m.showTabBookmarks()
 		cmd = m.viewport.StartSpinner()
 		cmds = append(cmds, cmd)
-		m.selectedLogId = msg.id
-		cmds = append(cmds, m.fetchApexLog)
+		cmds = append(cmds, fetchApexLogCmd(ctx, m.source, msg.id))
 		return m, tea.Sequence(cmds...)
-	case apexLogBodyMsg:
-		m.switchFocus()
-		m.viewport.StopSpinner()
-		m.viewport.SetContent(msg.body)
+	case apexLogChunkMsg:
+		// The chunks of a log still loading when another tab is displayed are
+		// appended to the viewport of its tab.
+		i := m.tabIndex(msg.id)
+		if i < 0 {
+			return m, nil
+		}
+		current := i == m.tab
+		vp := m.tabViewport(i)
+		if msg.err != nil {
+			m.tabs[i].loading = false
+			vp.StopSpinner()
+			if current {
+				m.statusbar.SetLoading(msg.read, msg.size, false)
+			}
+			m.statusbar.SetError(msg.err)
+			m.updateApiUsage()
+			return m, nil
+		}
+		if msg.first {
+			if current {
+				m.focusViewport()
+			}
+			vp.StopSpinner()
+			vp.SetContent("")
+		}
+		vp.AppendContent(msg.chunk)
+		if current {
+			m.statusbar.SetLoading(msg.read, msg.size, !msg.done)
+		}
+		if !msg.done {
+			return m, waitForApexLogChunk(msg.updates)
+		}
+
+		m.tabs[i].loading = false
+		vp.GotoLine(m.tabs[i].line)
+		if current {
+			m.events.SetCounts(vp.EventCounts())
+		}
+		if vp.Truncated() {
+			m.table.SetTruncated(msg.id)
+		}
+		// Only a previous failure to load the same log is cleared, the other
+		// errors are unrelated to it.
+		var loadErr *loadError
+		if errors.As(m.statusbar.Err(), &loadErr) && loadErr.id == msg.id {
+			m.statusbar.SetError(nil)
+		}
+		m.updateApiUsage()
+		if m.index != nil {
+			return m, indexApexLogCmd(m.index, msg.id)
+		}
 		return m, nil
+	case downloadProgressMsg:
+		m.statusbar.SetDownload(msg.progress, m.options.DownloadDir, !msg.done)
+		m.updateApiUsage()
+		if !msg.done {
+			return m, waitForDownloadProgress(msg.updates)
+		}
+		m.downloading = false
+		if msg.err != nil && !errors.Is(msg.err, context.Canceled) {
+			log.Printf("error downloading apex logs: %s", msg.err)
+		}
+		return m, nil
+	case events.ChangedMsg:
+		m.viewport.SetHiddenEvents(msg.Hidden)
+		for i := range m.tabs {
+			if i != m.tab {
+				m.tabs[i].viewport.SetHiddenEvents(msg.Hidden)
+			}
+		}
+		if err := saveState(m.options.StateDir, state{HiddenEvents: msg.Hidden}); err != nil {
+			m.statusbar.SetError(fmt.Errorf("error saving hidden event types: %w", err))
+		}
+		return m, nil
+	case editSourceMsg:
+		m.statusbar.SetError(nil)
+		return m, editSourceCmd(msg.path, msg.line)
+	case copiedMsg:
+		m.statusbar.SetMessage(fmt.Sprintf("Copied %s to the clipboard", msg.what))
+		return m, nil
+	case externalViewMsg:
+		return m, openExternalViewCmd(msg.path, msg.editor)
+	case anonymous.RunMsg:
+		return m, runAnonymousCmd(m.ctx, m.salesforceClient, m.userId, m.source, msg.Code)
+	case anonymousResultMsg:
+		if msg.logs != nil {
+			m.table.SetLogs(msg.logs)
+			m.logs = msg.logs
+		}
+		m.updateApiUsage()
+		err := msg.err
+		if err == nil {
+			err = msg.result.Err()
+		}
+		m.anonymous.SetResult(err)
+		m.statusbar.SetError(err)
+		if msg.logId == "" {
+			if msg.err == nil {
+				m.statusbar.SetError(errors.New("the anonymous apex did not generate a log"))
+			}
+			return m, nil
+		}
+		id := msg.logId
+		return m, func() tea.Msg { return selectApexLogMsg{id: id, newTab: true} }
+	case testProgressMsg:
+		m.updateApiUsage()
+		if msg.err != nil {
+			m.testrun.SetError(msg.err)
+			m.statusbar.SetError(msg.err)
+			return m, nil
+		}
+		m.testrun.SetProgress(msg.progress)
+		if !msg.done {
+			return m, waitForTestProgress(msg.updates)
+		}
+		m.table.SetLogs(msg.logs)
+		m.logs = msg.logs
+		return m, nil
+	case testrun.OpenMsg:
+		if msg.Result.LogId == "" {
+			m.statusbar.SetError(errNoTestLog)
+			return m, nil
+		}
+		id := msg.Result.LogId
+		return m, func() tea.Msg { return selectApexLogMsg{id: id} }
+	case bookmarks.JumpMsg:
+		m.viewport.ScrollToLine(msg.Line)
+		m.focusViewport()
+		return m, nil
+	case bookmarks.ChangedMsg:
+		m.setBookmarks(msg.Bookmarks)
+		return m, nil
+	case diffResultMsg:
+		if msg.err != nil {
+			m.diff.SetError(msg.err)
+			return m, nil
+		}
+		m.diff.SetResult([2]string{m.logLabel(msg.ids[0]), m.logLabel(msg.ids[1])}, msg.result)
+		return m, nil
+	case truncatedLogsMsg:
+		for _, id := range msg.ids {
+			m.table.SetTruncated(id)
+		}
+		return m, nil
+	case searchResultsMsg:
+		m.results.SetResults(msg.results, m.logs, msg.err)
+		if len(msg.results) > 0 {
+			m.results.Focus()
+		} else {
+			cmd = m.results.EditQuery()
+		}
+		return m, cmd
 	case tea.WindowSizeMsg:
 		m.terminalWidth = msg.Width
 		m.terminalHeight = msg.Height
@@ -137,6 +670,18 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	m.table, cmd = m.table.Update(msg)
 	cmds = append(cmds, cmd)
+	m.results, cmd = m.results.Update(msg)
+	cmds = append(cmds, cmd)
+	m.events, cmd = m.events.Update(msg)
+	cmds = append(cmds, cmd)
+	m.bookmarks, cmd = m.bookmarks.Update(msg)
+	cmds = append(cmds, cmd)
+	m.anonymous, cmd = m.anonymous.Update(msg)
+	cmds = append(cmds, cmd)
+	m.testrun, cmd = m.testrun.Update(msg)
+	cmds = append(cmds, cmd)
+	m.diff, cmd = m.diff.Update(msg)
+	cmds = append(cmds, cmd)
 	m.viewport, cmd = m.viewport.Update(msg)
 	cmds = append(cmds, cmd)
 
@@ -148,190 +693,512 @@ func (m model) View() string {
 		return ""
 	}
 
-	v := lipgloss.JoinHorizontal(
-		lipgloss.Top,
-		m.table.View(),
-		m.viewport.View(),
-	)
+	var left string
+	switch m.left {
+	case resultsPanel:
+		left = m.results.View()
+	case anonymousPanel:
+		left = m.anonymous.View()
+	case testsPanel:
+		left = m.testrun.View()
+	case eventsPanel:
+		left = m.events.View()
+	case bookmarksPanel:
+		left = m.bookmarks.View()
+	default:
+		left = m.table.View()
+	}
+	right := m.viewport.View()
+	if m.tabsHeight() > 0 {
+		right = lipgloss.JoinVertical(lipgloss.Left, m.tabsView(lipgloss.Width(right)), right)
+	}
+	if m.showDiff {
+		right = m.diff.View()
+	}
+	v := lipgloss.JoinHorizontal(lipgloss.Top, left, right)
 	helpView := lipgloss.NewStyle().MarginTop(0).Render(m.help.View(m.keys))
 
-	return lipgloss.JoinVertical(lipgloss.Left, v, helpView)
+	return lipgloss.JoinVertical(lipgloss.Left, v, m.statusbar.View(), helpView)
 }
 
-func (m *model) switchFocus() {
-	if m.table.Focused() {
-		m.table.Blur()
-		m.keys.showTable = false
-		m.keys.showViewport = true
-		m.viewport.Focus()
-	} else {
-		m.table.Focus()
-		m.keys.showTable = true
-		m.keys.showViewport = false
-		m.viewport.Blur()
+// typing reports whether a text input has the focus, so keys are not shortcuts.
+func (m model) typing() bool {
+	return m.results.Typing() || m.viewport.Typing() || m.bookmarks.Typing() || m.anonymous.Typing()
+}
+
+// openResults shows the search results in place of the left panel and focuses the search input.
+func (m *model) openResults() tea.Cmd {
+	if m.index == nil {
+		m.statusbar.SetError(errors.New("searching all logs requires the local cache"))
+		return nil
+	}
+	m.openLeft(resultsPanel)
+	return m.results.EditQuery()
+}
+
+// markLog marks the selected log to be compared, or unmarks it if it was
+// marked. Marking a second log compares them.
+func (m *model) markLog() tea.Cmd {
+	id := m.table.SelectedLogId()
+	if id == "" {
+		return nil
+	}
+	marked := make([]string, 0, 2)
+	for _, mid := range m.marked {
+		if mid != id {
+			marked = append(marked, mid)
+		}
+	}
+	if len(marked) == len(m.marked) {
+		marked = append(marked, id)
+	}
+	m.marked = marked
+	if len(marked) < 2 {
+		m.table.SetMarked(marked)
+		return nil
 	}
+
+	m.marked = nil
+	m.table.SetMarked(nil)
+	m.openDiff()
+	return tea.Batch(m.diff.StartSpinner(), compareApexLogsCmd(m.ctx, m.source, [2]string{marked[0], marked[1]}))
+}
+
+// openDiff shows the comparison of two logs in place of the viewport and focuses it.
+func (m *model) openDiff() {
+	m.showDiff = true
+	m.focusViewport()
+	m.resize()
+}
+
+// closeDiff restores the viewport and focuses the left panel the logs were marked in.
+func (m *model) closeDiff() {
+	m.showDiff = false
+	m.focusLeft()
+	m.resize()
+}
+
+// logLabel describes the log with the given id by its start time and operation.
+func (m model) logLabel(id string) string {
+	for _, l := range m.logs {
+		if l.ID != id {
+			continue
+		}
+		start := l.StartTime
+		if st, err := time.Parse(sf.DateTimeLayout, l.StartTime); err == nil {
+			start = st.Format("02 Jan 15:04:05")
+		}
+		return fmt.Sprintf("%s %s (%s)", start, l.Operation, id)
+	}
+	return id
+}
+
+func (m *model) searchLogs() tea.Cmd {
+	q := search.ParseQuery(m.results.Query())
+	if q.Pattern == "" {
+		return nil
+	}
+	return tea.Batch(m.results.StartSearch(), searchLogsCmd(m.index, q))
 }
 
 func (m *model) resize() {
+	m.keys.showTabs = len(m.tabs) > 0
 	helpView := m.help.View(m.keys)
 	helpViewHeight := lipgloss.Height(helpView)
 
 	m.help.Width = m.terminalWidth
+	m.statusbar.SetWidth(m.terminalWidth)
 
-	ht := m.terminalHeight - helpViewHeight
-	wl := 51
+	ht := m.terminalHeight - helpViewHeight - m.statusbar.Height()
+	wl := m.options.ListWidth
+	if wl <= 0 {
+		wl = defaultListWidth
+	}
 	wr := m.terminalWidth - wl
 
 	m.table.SetWidth(wl)
 	m.table.SetHeight(ht)
+	m.results.SetWidth(wl)
+	m.results.SetHeight(ht)
+	m.events.SetWidth(wl)
+	m.events.SetHeight(ht)
+	m.bookmarks.SetWidth(wl)
+	m.bookmarks.SetHeight(ht)
+	m.anonymous.SetWidth(wl)
+	m.anonymous.SetHeight(ht)
+	m.testrun.SetWidth(wl)
+	m.testrun.SetHeight(ht)
+	m.diff.SetWidth(wr)
+	m.diff.SetHeight(ht)
 
 	if !m.viewportReady {
-		m.viewport = viewport.New(wr, ht)
-		m.viewport.HighPerformanceRendering = false
-		m.viewport.SetContent(m.logBody)
+		m.viewport = m.newViewport()
 	}
 	m.viewportReady = true
+	// The tab bar is displayed above the viewports.
+	hv := ht - m.tabsHeight()
 	m.viewport.SetWidth(wr)
-	m.viewport.SetHeight(ht)
+	m.viewport.SetHeight(hv)
+	for i := range m.tabs {
+		if i != m.tab {
+			m.tabs[i].viewport.SetWidth(wr)
+			m.tabs[i].viewport.SetHeight(hv)
+		}
+	}
 }
 
-func (m model) updateChildModels(msg tea.Msg) (tea.Model, tea.Cmd) {
-	var cmd tea.Cmd
-	var cmds []tea.Cmd
+// updateApiUsage refreshes the API usage displayed in the status bar with the
+// latest value reported to the Salesforce client.
+func (m *model) updateApiUsage() {
+	if m.salesforceClient == nil {
+		return
+	}
+	m.statusbar.SetApiUsage(m.salesforceClient.ApiUsage())
+}
 
-	m.viewport, cmd = m.viewport.Update(msg)
-	cmds = append(cmds, cmd)
+// fetchApexLogCmd loads the body of the apex log with the given id in the
+// background, sending it in chunks as it is downloaded.
+// The download is abandoned without a message when ctx is cancelled, which
+// happens when another log is selected or the application quits.
+func fetchApexLogCmd(ctx context.Context, source logSource, id string) tea.Cmd {
+	updates := make(chan apexLogChunkMsg)
+	go func() {
+		defer close(updates)
+		send := func(msg apexLogChunkMsg) bool {
+			msg.id = id
+			msg.updates = updates
+			select {
+			case updates <- msg:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
 
-	return m, tea.Batch(cmds...)
+		r, size, err := source.OpenBody(ctx, id)
+		if errors.Is(err, context.Canceled) {
+			return
+		}
+		if err != nil {
+			send(apexLogChunkMsg{err: &loadError{id: id, err: err}, done: true})
+			return
+		}
+		defer r.Close()
+
+		var read int64
+		buf := make([]byte, logChunkSize)
+		for first := true; ; first = false {
+			n, err := readChunk(r, buf)
+			read += int64(n)
+			done := err == io.EOF
+			if err != nil && !done {
+				if ctx.Err() == nil {
+					send(apexLogChunkMsg{read: read, size: size, err: &loadError{id: id, err: err}, done: true})
+				}
+				return
+			}
+
+			msg := apexLogChunkMsg{chunk: string(buf[:n]), read: read, size: size, first: first, done: done}
+			if !send(msg) || done {
+				return
+			}
+		}
+	}()
+	return waitForApexLogChunk(updates)
 }
 
-func (m model) fetchApexLog() tea.Msg {
-	body, err := sf.GetSObjectBody(m.salesforceClient, "ApexLog", m.selectedLogId)
-	if err != nil {
-		log.Fatalf("error getting apex log: %v", err)
+// readChunk reads from r until buf is full or the read fails.
+// Unlike [io.ReadFull], only the end of the body is reported as [io.EOF], so a
+// download cut short with [io.ErrUnexpectedEOF] is not taken for a complete log.
+func readChunk(r io.Reader, buf []byte) (int, error) {
+	n := 0
+	for n < len(buf) {
+		m, err := r.Read(buf[n:])
+		n += m
+		if err != nil {
+			return n, err
+		}
 	}
-	return apexLogBodyMsg{body: body}
+	return n, nil
 }
 
-func (m model) selectApexLog() tea.Msg {
-	return selectApexLogMsg{id: m.table.SelectedLogId()}
+func waitForApexLogChunk(updates <-chan apexLogChunkMsg) tea.Cmd {
+	return func() tea.Msg {
+		msg, ok := <-updates
+		if !ok {
+			return nil
+		}
+		return msg
+	}
 }
 
-func refreshApexLogs(client *sf.Client) tea.Msg {
-	apexLogsQuery := sf.SelectApexLogs()
-	apexLogs, err := sf.DoQuery[sf.ApexLog](client, apexLogsQuery)
-	if err != nil {
-		log.Fatalf("error getting apex logs: %s", err)
+// indexLogsCmd fills the search index in the background.
+func indexLogsCmd(indexLogs func() []string) tea.Cmd {
+	return func() tea.Msg {
+		return truncatedLogsMsg{ids: indexLogs()}
+	}
+}
+
+// indexApexLogCmd indexes a downloaded log body, so it can be found by later
+// searches. The body is read from the cache, it is saved there while it is
+// downloaded.
+func indexApexLogCmd(ix *search.Index, id string) tea.Cmd {
+	return func() tea.Msg {
+		if err := ix.AddLoaded(id); err != nil {
+			log.Printf("error indexing apex log: %s", err)
+		}
+		return nil
 	}
+}
 
-	return apexLogsMsg{logs: apexLogs.Records, salesforceClient: client}
+// downloadApexLogsCmd downloads the bodies of the given logs into dir in the background.
+func downloadApexLogsCmd(ctx context.Context, source logSource, logs []sf.ApexLog, dir string) tea.Cmd {
+	// Every log sends a single update, so the download never waits for the model.
+	updates := make(chan downloadProgressMsg, len(logs)+1)
+	go func() {
+		defer close(updates)
+		p, err := download.New(dir, source.Body).Run(ctx, logs, func(p download.Progress) {
+			updates <- downloadProgressMsg{progress: p, updates: updates}
+		})
+		updates <- downloadProgressMsg{progress: p, err: err, done: true, updates: updates}
+	}()
+	return waitForDownloadProgress(updates)
 }
 
-func refreshApexLogsCmd(client *sf.Client) tea.Cmd {
+func waitForDownloadProgress(updates <-chan downloadProgressMsg) tea.Cmd {
 	return func() tea.Msg {
-		return refreshApexLogs(client)
+		return <-updates
 	}
 }
 
-func initApexLogs() tea.Msg {
-	userInfo, err := sf.GetDefaultUserInfo()
-	if err != nil {
-		log.Fatalf("error getting default dx user: %s", err)
+// compareApexLogsCmd compares the bodies of the logs with the given ids in the background.
+func compareApexLogsCmd(ctx context.Context, source logSource, ids [2]string) tea.Cmd {
+	return func() tea.Msg {
+		var bodies [2]string
+		for i, id := range ids {
+			body, err := source.Body(ctx, id)
+			if err != nil {
+				return diffResultMsg{ids: ids, err: fmt.Errorf("error getting apex log: %w", err)}
+			}
+			bodies[i] = body
+		}
+		return diffResultMsg{ids: ids, result: logdiff.Compare(bodies[0], bodies[1])}
 	}
-	orgInfo := sf.ScratchOrgInfo{
-		AccessToken: userInfo.AccessToken,
-		InstanceUrl: userInfo.InstanceUrl,
-		ApiVersion:  "61.0",
-		Alias:       userInfo.Alias,
+}
+
+func searchLogsCmd(ix *search.Index, q search.Query) tea.Cmd {
+	return func() tea.Msg {
+		rs, err := ix.Search(q, search.DefaultLimit)
+		if err != nil {
+			err = fmt.Errorf("error searching apex logs: %w", err)
+		}
+		return searchResultsMsg{results: rs, err: err}
 	}
+}
 
-	client := sf.NewClient(orgInfo)
-	debugLevelId := initSalesforceDebugLog(client)
-	initSalesforceTraceFlag(client, userInfo.Id, debugLevelId)
+func (m model) selectApexLog() tea.Msg {
+	return selectApexLogMsg{id: m.table.SelectedLogId()}
+}
 
-	return refreshApexLogs(client)
+func (m model) selectApexLogInNewTab() tea.Msg {
+	return selectApexLogMsg{id: m.table.SelectedLogId(), newTab: true}
 }
 
-func initSalesforceDebugLog(client *sf.Client) string {
-	debugLevelQuery := sf.SelectDebugLogByDeveloperName(defaultDebugLevelName)
-	debugLevelResponse, err := sf.DoQuery[sf.DebugLevel](client, debugLevelQuery)
-	if err != nil {
-		log.Fatalf("error querying debug level record: %s", err)
+func refreshApexLogsCmd(ctx context.Context, source logSource) tea.Cmd {
+	return func() tea.Msg {
+		logs, err := source.Logs(ctx)
+		if err != nil {
+			return errMsg{fmt.Errorf("error getting apex logs: %w", err)}
+		}
+		return apexLogsMsg{logs: logs}
 	}
+}
 
-	if debugLevelResponse.TotalSize > 0 {
-		return debugLevelResponse.Records[0].Id
+func initOfflineLogsCmd(ctx context.Context, defaultUsername func(context.Context) (string, error), opts Options) tea.Cmd {
+	return func() tea.Msg {
+		username, err := defaultUsername(ctx)
+		if err != nil {
+			log.Fatalf("error getting default dx user: %s", err)
+		}
+
+		c := openCache(opts.CacheDir, username)
+		if c == nil {
+			log.Fatalf("the local cache is required to browse apex logs offline")
+		}
+
+		source := cacheSource{cache: c}
+		logs, err := source.Logs(ctx)
+		if err != nil {
+			log.Fatalf("error getting cached apex logs: %s", err)
+		}
+
+		ix := newSearchIndex(c)
+		return orgConnectedMsg{
+			source:    source,
+			cache:     c,
+			index:     ix,
+			indexLogs: func() []string { return indexCachedLogs(ix, c) },
+			logs:      logs,
+		}
 	}
+}
 
-	postDebugLevelResponse, err := sf.PostSObject(client, "DebugLevel", sf.DebugLevel{})
-	if err != nil {
-		log.Fatalf("error sending new debug level record request: %s", err)
+// initLocalLogsCmd lists the log files in the given paths, "-" reads a log from stdin.
+func initLocalLogsCmd(ctx context.Context, paths []string, stdin io.Reader) tea.Cmd {
+	return func() tea.Msg {
+		source, err := newFileSource(paths, stdin)
+		if err != nil {
+			log.Fatalf("error reading log files: %s", err)
+		}
+		logs, err := source.Logs(ctx)
+		if err != nil {
+			log.Fatalf("error reading log files: %s", err)
+		}
+
+		// Local files are indexed from disk, since they are not in the cache.
+		ix := search.NewIndex(func(id string) (string, error) {
+			return source.Body(ctx, id)
+		})
+		indexLogs := func() []string {
+			var truncated []string
+			for _, l := range logs {
+				body, err := source.Body(ctx, l.ID)
+				if err != nil {
+					log.Printf("error indexing log file: %s", err)
+					continue
+				}
+				ix.Add(l.ID, body)
+				if apexlog.IsTruncated(body) {
+					truncated = append(truncated, l.ID)
+				}
+			}
+			return truncated
+		}
+
+		return orgConnectedMsg{source: source, index: ix, indexLogs: indexLogs, logs: logs, local: true}
 	}
+}
 
-	if !postDebugLevelResponse.Success {
-		log.Fatalf("error creating debug level record: %s", err)
+func initApexLogsCmd(ctx context.Context, connect connectFunc, opts Options) tea.Cmd {
+	return func() tea.Msg {
+		return initApexLogs(ctx, connect, opts)
 	}
+}
 
-	return postDebugLevelResponse.Id
+// connectDefaultOrg returns a function connecting to the default org of the
+// Salesforce CLI with the given API version and client options.
+func connectDefaultOrg(apiVersion string, clientOpts ...sf.ClientOption) connectFunc {
+	return func(ctx context.Context) (*sf.Client, sf.UserInfo, error) {
+		userInfo, err := sf.GetDefaultUserInfo(ctx)
+		if err != nil {
+			return nil, sf.UserInfo{}, err
+		}
+		orgInfo := sf.ScratchOrgInfo{
+			AccessToken: userInfo.AccessToken,
+			InstanceUrl: userInfo.InstanceUrl,
+			ApiVersion:  apiVersion,
+			Alias:       userInfo.Alias,
+		}
+
+		return sf.NewClient(orgInfo, clientOpts...), userInfo, nil
+	}
 }
 
-func refreshSalesforceTraceFlag(client *sf.Client, userId string) error {
-	traceFlagQuery := sf.SelectDebugLogTraceFlagByTracedId(userId)
-	queryResult, err := sf.DoQuery[sf.TraceFlag](client, traceFlagQuery)
+func initApexLogs(ctx context.Context, connect connectFunc, opts Options) tea.Msg {
+	client, userInfo, err := connect(ctx)
 	if err != nil {
-		return fmt.Errorf("error querying trace flag record: %s", err)
+		log.Fatalf("error getting default dx user: %s", err)
 	}
 
-	if queryResult.TotalSize == 0 {
-		return &TraceFlagNotFoundError{"trace flag of type debug log not found"}
+	debugLevelId := initSalesforceDebugLog(ctx, client, opts.DebugLevel)
+
+	traceFlags, err := traceflag.New(
+		client,
+		userInfo.Id,
+		debugLevelId,
+		traceflag.WithDuration(opts.TraceFlagDuration),
+		traceflag.WithCleanup(opts.TraceFlagCleanup),
+	)
+	if err != nil {
+		log.Fatalf("error configuring trace flag: %s", err)
+	}
+	traceFlag, err := traceFlags.Start(ctx)
+	if err != nil {
+		log.Fatalf("error activating trace flag: %s", err)
 	}
 
-	traceFlag := queryResult.Records[0]
-	expirationDate, err := time.Parse(sf.DateTimeLayout, traceFlag.ExpirationDate)
+	c := openCache(opts.CacheDir, userInfo.Username)
+	source := orgSource{client: client, cache: c, limit: opts.LogLimit}
+	logs, err := source.Logs(ctx)
 	if err != nil {
-		return fmt.Errorf("unexpected format found for trace flag expiration date: %s", traceFlag.ExpirationDate)
+		log.Fatalf("error getting apex logs: %s", err)
 	}
 
-	if expirationDate.Unix() < time.Now().Add(time.Minute*10).UTC().Unix() {
-		patchPayload := map[string]string{
-			"ExpirationDate": time.Now().Add(time.Minute * 30).UTC().Format(sf.DateTimeLayout),
-			"StartDate":      time.Now().UTC().Format(sf.DateTimeLayout),
-		}
-		err := sf.PatchSObject(client, "TraceFlag", traceFlag.Id, patchPayload)
-		if err != nil {
-			return fmt.Errorf("error sending request to update trace flag with id %s: %s", traceFlag.Id, err)
-		}
+	msg := orgConnectedMsg{
+		source:           source,
+		cache:            c,
+		salesforceClient: client,
+		userId:           userInfo.Id,
+		traceFlags:       traceFlags,
+		traceFlag:        traceFlag,
+		logs:             logs,
+	}
+	if ix := newSearchIndex(c); ix != nil {
+		msg.index = ix
+		msg.indexLogs = func() []string { return indexCachedLogs(ix, c) }
 	}
+	return msg
+}
 
-	time.AfterFunc(time.Minute*15, func() { refreshSalesforceTraceFlag(client, userId) })
+// waitForTraceFlagStatus waits for the next renewal of the trace flag.
+func waitForTraceFlagStatus(traceFlags *traceflag.Manager) tea.Cmd {
+	return func() tea.Msg {
+		return traceFlagStatusMsg(<-traceFlags.Updates())
+	}
+}
 
-	return nil
+// traceFlagTick refreshes the remaining time of the trace flag displayed in the status bar.
+func traceFlagTick() tea.Cmd {
+	return tea.Tick(traceFlagTickPeriod, func(t time.Time) tea.Msg {
+		return traceFlagTickMsg(t)
+	})
 }
 
-func initSalesforceTraceFlag(client *sf.Client, userId, debugLevelId string) {
-	err := refreshSalesforceTraceFlag(client, userId)
+// initSalesforceDebugLog returns the id of the debug level with the given
+// developer name, creating it if it does not exist.
+func initSalesforceDebugLog(ctx context.Context, client *sf.Client, name string) string {
+	debugLevelQuery := sf.SelectDebugLogByDeveloperName(name)
+	debugLevelResponse, err := sf.DoQuery[sf.DebugLevel](ctx, client, debugLevelQuery)
+	if err != nil {
+		log.Fatalf("error querying debug level record: %s", err)
+	}
 
-	if _, ok := err.(*TraceFlagNotFoundError); ok {
-		traceFlag := map[string]any{
-			"TracedEntityId": userId,
-			"DebugLevelId":   debugLevelId,
-			"LogType":        "DEVELOPER_LOG",
-			"StartDate":      time.Now().UTC().Format(sf.DateTimeLayout),
-			"ExpirationDate": time.Now().Add(time.Minute * 30).UTC().Format(sf.DateTimeLayout),
-		}
-		postResult, err := sf.PostSObject(client, "TraceFlag", traceFlag)
-		if err != nil {
-			log.Fatalf("error sending new trace flag request: %s", err)
-		}
+	if debugLevelResponse.TotalSize > 0 {
+		return debugLevelResponse.Records[0].Id
+	}
 
-		if !postResult.Success {
-			log.Fatalf("error creating trace flag record: %s", err)
-		}
+	debugLevel := map[string]string{
+		"DeveloperName": name,
+		"MasterLabel":   name,
+		"ApexCode":      "FINEST",
+		"ApexProfiling": "INFO",
+		"Callout":       "INFO",
+		"Database":      "INFO",
+		"System":        "DEBUG",
+		"Validation":    "INFO",
+		"Visualforce":   "INFO",
+		"Workflow":      "INFO",
+	}
+	postDebugLevelResponse, err := sf.PostSObject(ctx, client, "DebugLevel", debugLevel)
+	if err != nil {
+		log.Fatalf("error sending new debug level record request: %s", err)
+	}
 
-		time.AfterFunc(time.Minute*15, func() { refreshSalesforceTraceFlag(client, userId) })
-	} else if err != nil {
-		log.Fatalf("error refreshing trace flag: %s", err)
+	if !postDebugLevelResponse.Success {
+		log.Fatalf("error creating debug level record: %s", err)
 	}
+
+	return postDebugLevelResponse.Id
 }
 
 func percentInt(a, b int) int {
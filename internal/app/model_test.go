@@ -0,0 +1,1110 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aymanbagabas/go-osc52/v2"
+	"github.com/cdelmoral/apexlogs/internal/project"
+	sf "github.com/cdelmoral/apexlogs/internal/salesforce"
+	"github.com/cdelmoral/apexlogs/internal/salesforce/sftest"
+	"github.com/cdelmoral/apexlogs/internal/theme"
+	"github.com/cdelmoral/apexlogs/internal/traceflag"
+)
+
+func fakeConnect(srv *sftest.Server) connectFunc {
+	return func(ctx context.Context) (*sf.Client, sf.UserInfo, error) {
+		return srv.Client(), srv.UserInfo(), nil
+	}
+}
+
+func TestInitApexLogs(t *testing.T) {
+	srv := sftest.New(sftest.FixturesDir())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	msg := initApexLogs(ctx, fakeConnect(srv), testOptions(t))
+
+	logsMsg, ok := msg.(orgConnectedMsg)
+	if !ok {
+		t.Fatalf("expected orgConnectedMsg, got %T", msg)
+	}
+	if len(logsMsg.logs) != 10 {
+		t.Errorf("expected 10 apex logs, got %d", len(logsMsg.logs))
+	}
+
+	if n := len(srv.Records("DebugLevel")); n != 1 {
+		t.Errorf("expected a debug level to be created, got %d", n)
+	}
+	traceFlags := srv.Records("TraceFlag")
+	if len(traceFlags) != 1 || traceFlags[0]["TracedEntityId"] != sftest.UserId {
+		t.Errorf("expected a trace flag for the user to be created, got %v", traceFlags)
+	}
+	if logsMsg.traceFlag.TraceFlagId != traceFlags[0]["Id"] {
+		t.Errorf("unexpected trace flag status: %+v", logsMsg.traceFlag)
+	}
+}
+
+func TestInitApexLogsReusesExistingRecords(t *testing.T) {
+	srv := sftest.New(sftest.FixturesDir())
+	connect := fakeConnect(srv)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	initApexLogs(ctx, connect, testOptions(t))
+	initApexLogs(ctx, connect, testOptions(t))
+
+	if n := len(srv.Records("DebugLevel")); n != 1 {
+		t.Errorf("expected a single debug level, got %d", n)
+	}
+	if n := len(srv.Records("TraceFlag")); n != 1 {
+		t.Errorf("expected a single trace flag, got %d", n)
+	}
+}
+
+func TestInitApexLogsWithConfiguredOptions(t *testing.T) {
+	srv := sftest.New(sftest.FixturesDir())
+	opts := testOptions(t)
+	opts.CacheDir = ""
+	opts.DebugLevel = "Apexlogs_Finest"
+	opts.LogLimit = 3
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	msg := initApexLogs(ctx, fakeConnect(srv), opts).(orgConnectedMsg)
+	if len(msg.logs) != 3 {
+		t.Errorf("expected 3 apex logs, got %d", len(msg.logs))
+	}
+	levels := srv.Records("DebugLevel")
+	if len(levels) != 1 || levels[0]["DeveloperName"] != "Apexlogs_Finest" {
+		t.Errorf("expected the configured debug level to be created, got %v", levels)
+	}
+}
+
+func TestStopWithoutQuitKey(t *testing.T) {
+	srv := sftest.New(sftest.FixturesDir())
+	opts := testOptions(t)
+	opts.TraceFlagCleanup = traceflag.CleanupDelete
+	h := newHarnessWithOptions(t, srv, opts).start(120, 30)
+
+	m := h.model.(model)
+	if m.traceFlags == nil {
+		t.Fatalf("expected the trace flag to be started")
+	}
+
+	start := time.Now()
+	if err := m.stop(); err != nil {
+		t.Fatalf("unexpected error stopping: %s", err)
+	}
+	if time.Since(start) > time.Second {
+		t.Errorf("stopping waited for the cleanup timeout")
+	}
+	if n := len(srv.Records("TraceFlag")); n != 0 {
+		t.Errorf("expected the trace flag to be deleted, got %d", n)
+	}
+}
+
+func TestLoadOptions(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	path := filepath.Join(dir, "apexlogs", "config.json")
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(`{"listWidth": 60, "cacheDir": "", "traceFlagCleanup": "delete", "timeout": "1m"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	opts, err := LoadOptions()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if opts.ListWidth != 60 || opts.CacheDir != "" || opts.TraceFlagCleanup != traceflag.CleanupDelete || opts.Timeout != time.Minute {
+		t.Errorf("expected the configured options, got %+v", opts)
+	}
+	if opts.ApiVersion != defaultApiVersion || opts.LogLimit != sf.DefaultApexLogsLimit || opts.BodyTimeout != sf.DefaultBodyTimeout {
+		t.Errorf("expected the defaults of the settings not configured, got %+v", opts)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"listWidth": 5}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadOptions(); err == nil || !strings.Contains(err.Error(), "listWidth") {
+		t.Errorf("expected the invalid setting to be reported, got %v", err)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"keys": {"quit": ["?"]}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadOptions(); err == nil || !strings.Contains(err.Error(), "bound to both quit and help") {
+		t.Errorf("expected the conflicting keys to be reported, got %v", err)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"theme": "solarized"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadOptions(); err == nil || !strings.Contains(err.Error(), `unknown theme "solarized"`) {
+		t.Errorf("expected the unknown theme to be reported, got %v", err)
+	}
+}
+
+func TestTheme(t *testing.T) {
+	t.Cleanup(func() {
+		dark, _ := theme.Builtin(theme.Dark)
+		theme.Set(dark)
+	})
+	opts := testOptions(t)
+	opts.Theme = "solarized"
+	opts.Themes = map[string]theme.Theme{"solarized": {Extends: theme.Light, Focused: "#268bd2"}}
+
+	newHarnessWithOptions(t, sftest.New(sftest.FixturesDir()), opts)
+	light, _ := theme.Builtin(theme.Light)
+	if got := theme.Current(); got.Focused != "#268bd2" || got.Base != light.Base {
+		t.Errorf("expected the user theme extending the light one, got %+v", got)
+	}
+}
+
+func TestRemapKeys(t *testing.T) {
+	srv := sftest.New(sftest.FixturesDir())
+	opts := testOptions(t)
+	opts.KeyProfile = keyProfileVim
+	opts.Keys = map[string][]string{"open": {"o"}, "refresh": {"R"}, "bookmark": {"M"}}
+
+	h := newHarnessWithOptions(t, srv, opts).start(120, 30).press("?")
+	for _, want := range []string{`o\s+open selected apex log`, `R\s+refresh apex logs`, `ctrl\+g\s+search all logs`, `ctrl\+f/pgdn\s+page down`} {
+		if !regexp.MustCompile(want).MatchString(h.view()) {
+			t.Errorf("expected the help to match %q, got:\n%s", want, h.view())
+		}
+	}
+
+	h.press("end", "enter")
+	if h.model.(model).viewport.Focused() {
+		t.Fatal("expected enter not to open the log")
+	}
+	h.press("o")
+	if !h.model.(model).viewport.Focused() {
+		t.Error("expected o to open the log")
+	}
+
+	m := h.model.(model)
+	if v := m.bookmarks.View(); !strings.Contains(v, "press M on a line to add one") {
+		t.Errorf("expected the hint of the bookmarks to use the remapped key:\n%s", v)
+	}
+	if v := m.results.View(); !strings.Contains(v, "and press o") {
+		t.Errorf("expected the search prompt to use the remapped key:\n%s", v)
+	}
+}
+
+func TestNewKeyMap(t *testing.T) {
+	for profile := range keyProfiles {
+		if _, err := newKeyMap(profile, nil); err != nil {
+			t.Errorf("expected the %s profile to be valid, got %s", profile, err)
+		}
+	}
+
+	for name, tc := range map[string]struct {
+		profile string
+		keys    map[string][]string
+		want    string
+	}{
+		"unknown profile": {"nano", nil, `unknown key profile "nano"`},
+		"unknown action":  {"", map[string][]string{"opne": {"o"}}, `unknown action "opne"`},
+		"invalid key":     {"", map[string][]string{"quit": {"ctrl+shift+q"}}, `quit: invalid key "ctrl+shift+q"`},
+		"conflict":        {"", map[string][]string{"download": {"r"}}, `key "r" is bound to both refresh and download in the list of logs`},
+		"profile conflict": {
+			keyProfileVim, map[string][]string{"search": {"ctrl+f"}},
+			`key "ctrl+f" is bound to both search and pageDown in the list of logs`,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := newKeyMap(tc.profile, tc.keys)
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("expected an error containing %q, got %v", tc.want, err)
+			}
+		})
+	}
+}
+
+func TestOpenedLogsAreCached(t *testing.T) {
+	srv := sftest.New(sftest.FixturesDir())
+	opts := testOptions(t)
+
+	newHarnessWithOptions(t, srv, opts).start(120, 30).press("end", "enter", "tab", "enter")
+
+	bodyRequests := 0
+	for _, r := range srv.Requests() {
+		if strings.HasSuffix(r.Resource, "/Body") {
+			bodyRequests++
+		}
+	}
+	if bodyRequests != 1 {
+		t.Errorf("expected the log body to be downloaded once, got %d requests", bodyRequests)
+	}
+}
+
+func TestOfflineListsCachedLogs(t *testing.T) {
+	srv := sftest.New(sftest.FixturesDir())
+	opts := testOptions(t)
+	newHarnessWithOptions(t, srv, opts).start(120, 30).press("end", "enter")
+
+	offline := sftest.New(t.TempDir())
+	opts.Offline = true
+	h := newHarnessWithOptions(t, offline, opts).start(120, 30)
+
+	m := h.model.(model)
+	if n := len(m.table.Rows()); n != 1 {
+		t.Fatalf("expected the opened log to be listed offline, got %d logs", n)
+	}
+	if len(offline.Requests()) != 0 {
+		t.Errorf("expected no requests while offline, got %v", offline.Requests())
+	}
+
+	h.press("enter")
+	if !strings.Contains(h.view(), "EXECUTION_STARTED") {
+		t.Errorf("expected the cached log to be displayed")
+	}
+}
+
+func TestSearchOpensMatchingLine(t *testing.T) {
+	srv := sftest.New(sftest.FixturesDir())
+	lines := make([]string, 200)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("12:00:00.0 (%d)|STATEMENT_EXECUTE|[%d]", i, i)
+	}
+	lines[150] = "12:00:00.0 (150)|FATAL_ERROR|System.NullPointerException"
+	id := srv.AddLog(map[string]any{
+		"Operation": "/apex",
+		"Status":    "Success",
+		"StartTime": "2024-06-16T10:00:00.000+0000",
+	}, strings.Join(lines, "\n"))
+
+	h := newHarness(t, srv).start(120, 30)
+	h.press("enter", "tab", "ctrl+f").typeText("NullPointerException").press("enter")
+
+	m := h.model.(model)
+	r, ok := m.results.Selected()
+	if !ok {
+		t.Fatalf("expected a search result")
+	}
+	if r.LogId != id || r.Line != 150 {
+		t.Errorf("unexpected search result: %+v", r)
+	}
+
+	h.press("enter")
+	m = h.model.(model)
+	if m.viewport.YOffset() != 150 {
+		t.Errorf("expected the viewport to scroll to line 150, got %d", m.viewport.YOffset())
+	}
+	if !m.keys.showViewport {
+		t.Errorf("expected the viewport to be focused")
+	}
+}
+
+func TestTypingDoesNotTriggerShortcuts(t *testing.T) {
+	srv := sftest.New(sftest.FixturesDir())
+	h := newHarness(t, srv).start(120, 30)
+
+	h.press("end", "enter", "/").typeText("q?")
+	if h.quitting {
+		t.Fatalf("expected typing in the filter box not to quit")
+	}
+	h.press("enter", "ctrl+f").typeText("q")
+	if h.quitting {
+		t.Fatalf("expected typing in the search box not to quit")
+	}
+	if got := h.model.(model).results.Query(); got != "q" {
+		t.Errorf("expected the typed query, got %q", got)
+	}
+}
+
+func TestDownloadListedLogs(t *testing.T) {
+	srv := sftest.New(sftest.FixturesDir())
+	opts := testOptions(t)
+	opts.DownloadDir = t.TempDir()
+	h := newHarnessWithOptions(t, srv, opts).start(160, 30).press("D")
+
+	// Only one of the fixture logs has a body.
+	files, err := os.ReadDir(opts.DownloadDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 || files[0].Name() != "20240615T225017Z_aura_07L0500000G0f5pEAB.log" {
+		t.Errorf("unexpected downloaded files: %v", files)
+	}
+	if !strings.Contains(h.view(), "1 logs saved to "+opts.DownloadDir+", 9 failed") {
+		t.Errorf("expected the download result in the status bar:\n%s", h.view())
+	}
+}
+
+func TestRunDownload(t *testing.T) {
+	srv := sftest.New(sftest.FixturesDir())
+	for i := range 3 {
+		srv.AddLog(map[string]any{
+			"Operation": "/apex/Page",
+			"Status":    "Success",
+			"StartTime": fmt.Sprintf("2024-06-16T10:00:0%d.000+0000", i),
+		}, fmt.Sprintf("body %d", i))
+	}
+
+	opts := DownloadOptions{Options: testOptions(t), Filter: sf.ApexLogFilter{Operation: "/apex/%"}}
+	opts.DownloadDir = t.TempDir()
+
+	var out strings.Builder
+	if err := runDownload(context.Background(), fakeConnect(srv), opts, &out); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !strings.HasSuffix(out.String(), "3 logs downloaded, 0 already in "+opts.DownloadDir+"\n") {
+		t.Errorf("unexpected output: %q", out.String())
+	}
+
+	out.Reset()
+	if err := runDownload(context.Background(), fakeConnect(srv), opts, &out); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !strings.HasSuffix(out.String(), "0 logs downloaded, 3 already in "+opts.DownloadDir+"\n") {
+		t.Errorf("expected the second download to skip every log, got %q", out.String())
+	}
+}
+
+func TestViewLocalFiles(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"first.log":         "61.0 APEX_CODE,FINEST\n12:00:00.0 (1)|EXECUTION_STARTED",
+		"nested/second.log": "61.0 APEX_CODE,FINEST\n12:00:00.0 (1)|USER_DEBUG|[1]|DEBUG|hello",
+		"notes.txt":         "not a log",
+	}
+	for name, body := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	modTime := time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC)
+	os.Chtimes(filepath.Join(dir, "nested/second.log"), modTime, modTime.Add(time.Hour))
+	os.Chtimes(filepath.Join(dir, "first.log"), modTime, modTime)
+
+	srv := sftest.New(sftest.FixturesDir())
+	opts := testOptions(t)
+	opts.LocalPaths = []string{dir}
+	h := newHarnessWithOptions(t, srv, opts).start(120, 30)
+
+	m := h.model.(model)
+	if n := len(m.table.Rows()); n != 2 {
+		t.Fatalf("expected the 2 log files to be listed, got %d", n)
+	}
+	h.press("enter")
+	if !strings.Contains(h.view(), "USER_DEBUG │ [1] │ DEBUG │ hello") {
+		t.Errorf("expected the most recent log file to be displayed:\n%s", h.view())
+	}
+	if !strings.Contains(h.view(), "Local files") {
+		t.Errorf("expected the status bar to show local files")
+	}
+	if len(srv.Requests()) != 0 {
+		t.Errorf("expected no requests to the org, got %v", srv.Requests())
+	}
+}
+
+func TestViewStdin(t *testing.T) {
+	srv := sftest.New(sftest.FixturesDir())
+	opts := testOptions(t)
+	opts.LocalPaths = []string{"-"}
+	h := newHarnessWithOptions(t, srv, opts)
+	m := h.model.(model)
+	m.stdin = strings.NewReader("61.0 APEX_CODE,FINEST\n12:00:00.0 (1)|FATAL_ERROR|System.LimitException")
+	h.model = m
+
+	h.start(120, 30).press("enter", "tab", "ctrl+f").typeText("LimitException").press("enter")
+	if !strings.Contains(h.view(), "1 matching lines") {
+		t.Errorf("expected the log read from stdin to be searchable:\n%s", h.view())
+	}
+}
+
+func TestHiddenEventsAreRemembered(t *testing.T) {
+	srv := sftest.New(sftest.FixturesDir())
+	opts := testOptions(t)
+	h := newHarnessWithOptions(t, srv, opts).start(120, 30).press("end", "enter", "e", " ", "esc")
+	if strings.Contains(h.view(), "CODE_UNIT_FINISHED") {
+		t.Fatalf("expected the most frequent event type to be hidden:\n%s", h.view())
+	}
+
+	h = newHarnessWithOptions(t, srv, opts).start(120, 30).press("end", "enter")
+	if strings.Contains(h.view(), "CODE_UNIT_FINISHED") {
+		t.Errorf("expected the hidden event types to be remembered:\n%s", h.view())
+	}
+	h.press("e", "a", "esc")
+	if !strings.Contains(h.view(), "CODE_UNIT_FINISHED") {
+		t.Errorf("expected every event type to be shown:\n%s", h.view())
+	}
+}
+
+func TestOpenLogInChunks(t *testing.T) {
+	srv := sftest.New(sftest.FixturesDir())
+	// The log is several times the chunk size, so it is displayed in parts.
+	lines := make([]string, 3*logChunkSize/40)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("12:00:00.0 (%d)|STATEMENT_EXECUTE|[%d]", i, i)
+	}
+	lines[len(lines)-1] = "12:00:00.0 (1)|USER_DEBUG|[1]|DEBUG|last line"
+	body := strings.Join(lines, "\n")
+	srv.AddLog(map[string]any{
+		"Operation": "/apex",
+		"Status":    "Success",
+		"StartTime": "2024-06-16T10:00:00.000+0000",
+	}, body)
+
+	opts := testOptions(t)
+	h := newHarnessWithOptions(t, srv, opts).start(120, 30).press("enter")
+	m := h.model.(model)
+	if n := m.viewport.TotalLineCount(); n != len(lines) {
+		t.Fatalf("expected %d lines, got %d", len(lines), n)
+	}
+	if strings.Contains(h.view(), "Loading log") {
+		t.Errorf("expected the loading progress to be hidden once the log is read:\n%s", h.view())
+	}
+	h.press("/").typeText("last line")
+	if !strings.Contains(h.view(), "DEBUG │ last line") {
+		t.Errorf("expected the line of the last chunk to be displayed:\n%s", h.view())
+	}
+
+	c := openCache(opts.CacheDir, srv.UserInfo().Username)
+	if cached, err := c.Body(m.tabs[m.tab].id); err != nil || cached != body {
+		t.Errorf("expected the streamed log to be cached, got %d bytes, error %v", len(cached), err)
+	}
+}
+
+func TestOpenLogCutShort(t *testing.T) {
+	srv := sftest.New(sftest.FixturesDir())
+	lines := make([]string, 3*logChunkSize/40)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("12:00:00.0 (%d)|STATEMENT_EXECUTE|[%d]", i, i)
+	}
+	body := strings.Join(lines, "\n")
+	srv.AddLog(map[string]any{
+		"Operation": "/apex",
+		"Status":    "Success",
+		"StartTime": "2024-06-16T10:00:00.000+0000",
+	}, body)
+	srv.CutBodies(len(body) / 2)
+
+	opts := testOptions(t)
+	h := newHarnessWithOptions(t, srv, opts).start(120, 30).press("enter")
+	m := h.model.(model)
+	if !strings.Contains(h.view(), "unexpected EOF") {
+		t.Errorf("expected the download error to be displayed:\n%s", h.view())
+	}
+	if m.tabs[m.tab].loading {
+		t.Errorf("expected the log to stop loading")
+	}
+
+	c := openCache(opts.CacheDir, srv.UserInfo().Username)
+	if _, err := c.Body(m.tabs[m.tab].id); err == nil {
+		t.Errorf("expected the incomplete log not to be cached")
+	}
+}
+
+func TestTruncatedLog(t *testing.T) {
+	srv := sftest.New(sftest.FixturesDir())
+	srv.AddLog(map[string]any{
+		"Operation": "/apex",
+		"Status":    "Success",
+		"StartTime": "2024-06-16T10:00:00.000+0000",
+	}, "61.0 APEX_CODE,FINEST\n12:00:00.0 (1)|USER_DEBUG|[1]|DEBUG|hello\n*** Skipped 2097152 bytes of detailed log\n12:00:00.0 (2)|CODE_UNIT_FINISHED|Test")
+
+	opts := testOptions(t)
+	h := newHarnessWithOptions(t, srv, opts).start(160, 30)
+	if strings.Contains(h.view(), "! Success") {
+		t.Fatalf("expected the log not to be marked before it is read:\n%s", h.view())
+	}
+	h.press("enter")
+	if !strings.Contains(h.view(), "Truncated log, 2.0 MB skipped") {
+		t.Errorf("expected a truncation banner:\n%s", h.view())
+	}
+	if !strings.Contains(h.view(), "! Success") {
+		t.Errorf("expected the log to be marked as truncated:\n%s", h.view())
+	}
+
+	// The cached body is checked when the logs are indexed on the next run.
+	h = newHarnessWithOptions(t, srv, opts).start(160, 30)
+	if !strings.Contains(h.view(), "! Success") {
+		t.Errorf("expected the cached log to be marked as truncated:\n%s", h.view())
+	}
+}
+
+func TestCompareLogs(t *testing.T) {
+	srv := sftest.New(sftest.FixturesDir())
+	srv.AddLog(map[string]any{
+		"Operation": "/apex",
+		"Status":    "Success",
+		"StartTime": "2024-06-16T10:00:00.000+0000",
+	}, "12:00:00.0 (1)|EXECUTION_STARTED\n12:00:00.0 (2)|SOQL_EXECUTE_BEGIN|[3]|Aggregations:0|SELECT Id FROM Account\n12:00:00.0 (3)|USER_DEBUG|[4]|DEBUG|before\n12:00:00.0 (4)|EXECUTION_FINISHED")
+	srv.AddLog(map[string]any{
+		"Operation": "/apex",
+		"Status":    "Success",
+		"StartTime": "2024-06-16T11:00:00.000+0000",
+	}, "13:00:00.0 (5)|EXECUTION_STARTED\n13:00:00.0 (6)|SOQL_EXECUTE_BEGIN|[3]|Aggregations:0|SELECT Id FROM Account\n13:00:00.0 (7)|SOQL_EXECUTE_BEGIN|[5]|Aggregations:0|SELECT Id FROM Contact\n13:00:00.0 (8)|USER_DEBUG|[4]|DEBUG|after\n13:00:00.0 (9)|EXECUTION_FINISHED")
+
+	h := newHarness(t, srv).start(160, 40).press("c")
+	if !strings.Contains(h.view(), "A /apex") {
+		t.Fatalf("expected the log to be marked:\n%s", h.view())
+	}
+	h.press("c")
+	if strings.Contains(h.view(), "A /apex") {
+		t.Fatalf("expected the log to be unmarked:\n%s", h.view())
+	}
+
+	// The older log is compared with the newer one above it.
+	h.press("down", "c", "up", "c")
+	v := h.view()
+	for _, want := range []string{
+		fmt.Sprintf("%-26s%14d%14d", "SOQL queries", 1, 2),
+		"Debug output only in A: before",
+		"- 12:00:00.0 (3)|USER_DEBUG|[4]|DEBUG|before",
+		"+ 13:00:00.0 (7)|SOQL_EXECUTE_BEGIN|[5]|Aggregations:0|SELECT Id FROM Contact",
+	} {
+		if !strings.Contains(v, want) {
+			t.Errorf("expected the comparison to contain %q:\n%s", want, v)
+		}
+	}
+	if strings.Contains(v, "- 12:00:00.0 (1)|EXECUTION_STARTED") {
+		t.Errorf("expected the lines differing only in their time to be equal:\n%s", v)
+	}
+
+	h.press("esc")
+	m := h.model.(model)
+	if m.showDiff || !m.table.Focused() {
+		t.Errorf("expected the comparison to be closed and the table focused")
+	}
+}
+
+func TestTabs(t *testing.T) {
+	srv := sftest.New(sftest.FixturesDir())
+	srv.AddLog(map[string]any{
+		"Operation": "/apex/first",
+		"Status":    "Success",
+		"StartTime": "2024-06-16T10:00:00.000+0000",
+	}, "12:00:00.0 (1)|USER_DEBUG|[1]|DEBUG|first log")
+	srv.AddLog(map[string]any{
+		"Operation": "/apex/second",
+		"Status":    "Success",
+		"StartTime": "2024-06-16T11:00:00.000+0000",
+	}, "13:00:00.0 (1)|USER_DEBUG|[1]|DEBUG|second log")
+
+	h := newHarness(t, srv).start(160, 30).press("down", "enter")
+	h.press("/").typeText("first").press("enter", "tab", "up", "t")
+	v := h.view()
+	if !strings.Contains(v, " 1: 10:00:00 /apex/first  [2: 11:00:00 /apex/second]") {
+		t.Fatalf("expected a tab per open log:\n%s", v)
+	}
+	if !strings.Contains(v, "second log") || strings.Contains(v, "first log") {
+		t.Errorf("expected the log of the new tab to be displayed:\n%s", v)
+	}
+
+	h.press("[")
+	v = h.view()
+	if !strings.Contains(v, "first log") || !strings.Contains(v, "filter 1/1") {
+		t.Errorf("expected the first tab to keep its filter:\n%s", v)
+	}
+
+	// Opening a log already open switches to its tab.
+	h.press("enter", "tab", "enter")
+	m := h.model.(model)
+	if len(m.tabs) != 2 || m.tab != 1 || !m.viewport.Focused() {
+		t.Errorf("expected the tab of the log to be focused, got tab %d of %d", m.tab, len(m.tabs))
+	}
+
+	h.press("x")
+	v = h.view()
+	if strings.Contains(v, "[2:") || !strings.Contains(v, "first log") {
+		t.Errorf("expected the closed tab to be removed:\n%s", v)
+	}
+	// The filter box of the first tab has the focus again.
+	h.press("enter", "x")
+	m = h.model.(model)
+	if len(m.tabs) != 0 || !m.table.Focused() {
+		t.Errorf("expected the table to be focused after closing the last tab")
+	}
+}
+
+func TestReplaceTabWithFailedLog(t *testing.T) {
+	srv := sftest.New(sftest.FixturesDir())
+	srv.AddLog(map[string]any{
+		"Operation": "/apex/first",
+		"Status":    "Success",
+		"StartTime": "2024-06-16T11:00:00.000+0000",
+	}, "12:00:00.0 (1)|USER_DEBUG|[1]|DEBUG|first log")
+	// The log has no body, so downloading it fails.
+	srv.AddRecord("ApexLog", map[string]any{
+		"Operation": "/apex/missing",
+		"Status":    "Success",
+		"StartTime": "2024-06-16T10:00:00.000+0000",
+		"LogLength": 100,
+	})
+	opts := testOptions(t)
+
+	h := newHarnessWithOptions(t, srv, opts).start(160, 30).press("enter", "tab", "down", "enter")
+	v := h.view()
+	if strings.Contains(v, "first log") {
+		t.Errorf("expected the previous log to be cleared:\n%s", v)
+	}
+	if !strings.Contains(v, "error getting apex log") {
+		t.Errorf("expected the download error to be displayed:\n%s", v)
+	}
+
+	// The actions on the lines do nothing without the content of the log.
+	h.press("tab", "B")
+	m := h.model.(model)
+	if len(m.tabs[m.tab].bookmarks) != 0 {
+		t.Errorf("expected no bookmark on the failed log, got %+v", m.tabs[m.tab].bookmarks)
+	}
+	if n := len(m.viewport.Lines()); n != 0 {
+		t.Errorf("expected an empty viewport, got %d lines", n)
+	}
+}
+
+func TestLoadingKeepsUnrelatedErrors(t *testing.T) {
+	srv := sftest.New(sftest.FixturesDir())
+	h := newHarness(t, srv).start(160, 30)
+	h.send(errMsg{errors.New("error saving bookmarks")})
+	h.press("end", "enter")
+	if m := h.model.(model); len(m.tabs) != 1 || m.tabs[0].loading || len(m.viewport.Lines()) == 0 {
+		t.Fatalf("expected the log to be loaded")
+	}
+	if v := h.view(); !strings.Contains(v, "error saving bookmarks") {
+		t.Errorf("expected the unrelated error to be kept once the log is loaded:\n%s", v)
+	}
+}
+
+func TestBookmarks(t *testing.T) {
+	var lines []string
+	for i := range 60 {
+		lines = append(lines, fmt.Sprintf("12:00:00.0 (%d)|USER_DEBUG|[1]|DEBUG|line %d", i, i))
+	}
+	srv := sftest.New(sftest.FixturesDir())
+	srv.AddLog(map[string]any{
+		"Operation": "/apex/bookmarks",
+		"Status":    "Success",
+		"StartTime": "2024-06-16T10:00:00.000+0000",
+	}, strings.Join(lines, "\n"))
+	opts := testOptions(t)
+
+	h := newHarnessWithOptions(t, srv, opts).start(160, 30).press("enter", "B", "f", "B")
+	m := h.model.(model)
+	bs := m.tabs[m.tab].bookmarks
+	if len(bs) != 2 || bs[0].Line != 0 || bs[1].Line == 0 {
+		t.Fatalf("expected the first line and the line after a page to be bookmarked, got %+v", bs)
+	}
+	second := bs[1].Line
+
+	h.press("<")
+	if top := h.model.(model).viewport.TopLine(); top != 0 {
+		t.Errorf("expected the previous bookmark at the top, got line %d", top)
+	}
+	h.press(">")
+	if top := h.model.(model).viewport.TopLine(); top != second {
+		t.Errorf("expected the next bookmark at line %d, got line %d", second, top)
+	}
+
+	// The last lines can be bookmarked even though they never reach the top.
+	h.press("f", "f", "f", "B", "up", "B")
+	m = h.model.(model)
+	bs = m.tabs[m.tab].bookmarks
+	if len(bs) != 4 || bs[2].Line != 58 || bs[3].Line != 59 {
+		t.Fatalf("expected the last two lines to be bookmarked, got %+v", bs)
+	}
+	for _, want := range []int{59, 0, second, 58, 59} {
+		h.press(">")
+		if cur := h.model.(model).viewport.CurrentLine(); cur != want {
+			t.Errorf("expected the next bookmark at line %d, got line %d", want, cur)
+		}
+	}
+	for _, want := range []int{58, second, 0, 59} {
+		h.press("<")
+		if cur := h.model.(model).viewport.CurrentLine(); cur != want {
+			t.Errorf("expected the previous bookmark at line %d, got line %d", want, cur)
+		}
+	}
+
+	h.press("L", "a").typeText("checked here").press("enter", "esc")
+
+	// Bookmarks are saved alongside the cached log.
+	h = newHarnessWithOptions(t, srv, opts).start(160, 30).press("enter", "L")
+	v := h.view()
+	if !strings.Contains(v, "Bookmarks (4)") || !strings.Contains(v, "checked here │") {
+		t.Fatalf("expected the saved bookmarks to be listed:\n%s", v)
+	}
+
+	h.press("down", "enter")
+	m = h.model.(model)
+	if m.left != bookmarksPanel || !m.viewport.Focused() {
+		t.Errorf("expected the viewport to be focused next to the bookmarks after jumping")
+	}
+	if top := m.viewport.TopLine(); top != second {
+		t.Errorf("expected the jump to line %d, got line %d", second, top)
+	}
+}
+
+func TestOpenSource(t *testing.T) {
+	srv := sftest.New(sftest.FixturesDir())
+	srv.AddLog(map[string]any{
+		"Operation": "/apex/source",
+		"Status":    "Success",
+		"StartTime": "2024-06-16T10:00:00.000+0000",
+	}, strings.Join([]string{
+		"10:00:00.0 (1)|CODE_UNIT_STARTED|[EXTERNAL]|execute_anonymous_apex",
+		"10:00:00.0 (2)|METHOD_ENTRY|[1]|01p000000000001|AccountService.doWork()",
+		"10:00:00.0 (3)|USER_DEBUG|[12]|DEBUG|working",
+	}, "\n"))
+	opts := testOptions(t)
+	opts.ProjectDir = t.TempDir()
+
+	h := newHarnessWithOptions(t, srv, opts).start(160, 30).press("enter", "o")
+	if v := h.view(); !strings.Contains(v, errNoSource.Error()) {
+		t.Errorf("expected anonymous apex to have no source:\n%s", v)
+	}
+
+	h.press("/").typeText("working").press("enter", "o")
+	if v := h.view(); !strings.Contains(v, project.ErrNotFound.Error()) {
+		t.Errorf("expected an error outside of a project:\n%s", v)
+	}
+
+	writeFile := func(path, content string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeFile(filepath.Join(opts.ProjectDir, project.ConfigFile), `{"packageDirectories": [{"path": "force-app"}]}`)
+	h.press("o")
+	if v := h.view(); !strings.Contains(v, project.ErrSourceNotFound.Error()) {
+		t.Errorf("expected an error for a missing class:\n%s", v)
+	}
+
+	writeFile(filepath.Join(opts.ProjectDir, "force-app", "classes", "AccountService.cls"), "public class AccountService {}")
+	h.press("o")
+	if v := h.view(); strings.Contains(v, "not found") {
+		t.Errorf("expected the class to be found:\n%s", v)
+	}
+}
+
+func TestEditorCommand(t *testing.T) {
+	tests := map[string][]string{
+		"":          {"vi", "+12", "Foo.cls"},
+		"nvim":      {"nvim", "+12", "Foo.cls"},
+		"emacs -nw": {"emacs", "-nw", "+12", "Foo.cls"},
+		"code -w":   {"code", "-w", "--goto", "Foo.cls:12"},
+		"subl":      {"subl", "Foo.cls:12"},
+	}
+	for editor, want := range tests {
+		t.Setenv("VISUAL", "")
+		t.Setenv("EDITOR", editor)
+		if got := editorCommand("Foo.cls", 12, false).Args; !slices.Equal(got, want) {
+			t.Errorf("%q: expected %q, got %q", editor, want, got)
+		}
+	}
+
+	// Graphical editors wait for temporary files to be closed before they are removed.
+	waiting := map[string][]string{
+		"":        {"vi", "+1", "log.log"},
+		"code":    {"code", "--wait", "--goto", "log.log:1"},
+		"code -w": {"code", "-w", "--goto", "log.log:1"},
+		"codium":  {"codium", "--wait", "--goto", "log.log:1"},
+		"zed":     {"zed", "--wait", "log.log:1"},
+		"subl":    {"subl", "-w", "log.log:1"},
+	}
+	for editor, want := range waiting {
+		t.Setenv("VISUAL", "")
+		t.Setenv("EDITOR", editor)
+		if got := editorCommand("log.log", 1, true).Args; !slices.Equal(got, want) {
+			t.Errorf("%q waiting: expected %q, got %q", editor, want, got)
+		}
+	}
+}
+
+func TestExternalView(t *testing.T) {
+	srv := sftest.New(sftest.FixturesDir())
+	srv.AddLog(map[string]any{
+		"Operation": "/apex/external",
+		"Status":    "Success",
+		"StartTime": "2024-06-16T10:00:00.000+0000",
+	}, strings.Join([]string{
+		"10:00:00.0 (1)|USER_DEBUG|[1]|DEBUG|first",
+		"10:00:00.0 (2)|STATEMENT_EXECUTE|[2]",
+		"10:00:00.0 (3)|STATEMENT_EXECUTE|[3]",
+		"10:00:00.0 (4)|USER_DEBUG|[4]|DEBUG|second",
+	}, "\n"))
+	tmp := t.TempDir()
+	t.Setenv("TMPDIR", tmp)
+
+	h := newHarnessWithOptions(t, srv, testOptions(t)).start(160, 30)
+	h.press("enter", "/").typeText("user_debug").press("enter", "v")
+
+	files, err := filepath.Glob(filepath.Join(tmp, "*.log"))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("expected the displayed lines to be written to a file, got %v (%v)", files, err)
+	}
+	b, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "10:00:00.0 (1)|USER_DEBUG|[1]|DEBUG|first\n10:00:00.0 (4)|USER_DEBUG|[4]|DEBUG|second\n"
+	if string(b) != want {
+		t.Errorf("expected the filtered lines %q, got %q", want, b)
+	}
+}
+
+func TestExternalViewLocalFile(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "my logs")
+	path := filepath.Join(dir, "trace #1.log")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("61.0 APEX_CODE,FINEST\n12:00:00.0 (1)|USER_DEBUG|[1]|DEBUG|hello"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tmp := t.TempDir()
+	t.Setenv("TMPDIR", tmp)
+
+	opts := testOptions(t)
+	opts.LocalPaths = []string{path}
+	h := newHarnessWithOptions(t, sftest.New(sftest.FixturesDir()), opts).start(160, 30)
+	h.press("enter", "V")
+
+	if strings.Contains(h.view(), "error creating temporary file") {
+		t.Fatalf("expected the temporary file to be created, got:\n%s", h.view())
+	}
+	files, err := filepath.Glob(filepath.Join(tmp, "trace_1-*.log"))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("expected the log to be written to a file named after it, got %v (%v)", files, err)
+	}
+}
+
+func TestCopyToClipboard(t *testing.T) {
+	srv := sftest.New(sftest.FixturesDir())
+	id := srv.AddLog(map[string]any{
+		"Operation": "/apex/copy",
+		"Status":    "Success",
+		"StartTime": "2024-06-16T10:00:00.000+0000",
+	}, strings.Join([]string{
+		"10:00:00.0 (1)|USER_DEBUG|[1]|DEBUG|first",
+		"10:00:00.0 (2)|USER_DEBUG|[2]|DEBUG|second",
+		"10:00:00.0 (3)|USER_DEBUG|[3]|DEBUG|third",
+	}, "\n"))
+
+	// The sequences are not wrapped outside of tmux and screen.
+	t.Setenv("TMUX", "")
+	t.Setenv("TERM", "xterm-256color")
+	var clipboard bytes.Buffer
+	h := newHarness(t, srv)
+	m := h.model.(model)
+	m.clipboard = &clipboard
+	h.model = m
+	copied := func(want string) {
+		t.Helper()
+		if got := clipboard.String(); got != osc52.New(want).String() {
+			t.Errorf("expected %q to be copied, got %q", want, got)
+		}
+		clipboard.Reset()
+	}
+
+	h.start(160, 30).press("y")
+	copied(id)
+	if v := h.view(); !strings.Contains(v, "Copied the log id to the clipboard") {
+		t.Errorf("expected the copy to be reported:\n%s", v)
+	}
+
+	h.press("enter", "y")
+	copied("10:00:00.0 (1)|USER_DEBUG|[1]|DEBUG|first")
+
+	h.press("S", "/").typeText("third").press("enter", "m", "y")
+	copied("10:00:00.0 (1)|USER_DEBUG|[1]|DEBUG|first\n10:00:00.0 (2)|USER_DEBUG|[2]|DEBUG|second\n10:00:00.0 (3)|USER_DEBUG|[3]|DEBUG|third")
+	if v := h.view(); !strings.Contains(v, "Copied 3 lines to the clipboard") {
+		t.Errorf("expected the copied lines to be counted:\n%s", v)
+	}
+
+	// The lines removed by the filter are not copied with the selection.
+	h.press("esc", "up", "up", "S", "/").typeText("third").press("enter", "m", "y")
+	copied("10:00:00.0 (3)|USER_DEBUG|[3]|DEBUG|third")
+	if v := h.view(); !strings.Contains(v, "Copied 1 line to the clipboard") {
+		t.Errorf("expected only the displayed lines to be counted:\n%s", v)
+	}
+
+	h.press("Y")
+	copied("10:00:00.0 (3)|USER_DEBUG|[3]|DEBUG|third\n")
+}
+
+func TestCopyCmdFailures(t *testing.T) {
+	f, err := os.CreateTemp(t.TempDir(), "stderr")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	// A redirected stderr does not reach the terminal.
+	if msg, ok := copyCmd(f, "text", "1 line")().(errMsg); !ok || !errors.Is(msg.err, errNoTerminal) {
+		t.Errorf("expected the copy to a file to fail, got %v", msg)
+	}
+	if info, _ := f.Stat(); info.Size() != 0 {
+		t.Errorf("expected nothing written to the file, got %d bytes", info.Size())
+	}
+
+	var clipboard bytes.Buffer
+	if _, ok := copyCmd(&clipboard, strings.Repeat("x", maxClipboardSize+1), "the whole log")().(errMsg); !ok {
+		t.Errorf("expected the copy of a large text to fail")
+	}
+	if clipboard.Len() != 0 {
+		t.Errorf("expected nothing copied, got %d bytes", clipboard.Len())
+	}
+}
+
+func TestRunAnonymousApex(t *testing.T) {
+	srv := sftest.New(sftest.FixturesDir())
+	h := newHarness(t, srv).start(160, 30).press("A").typeText("System.debug('hello');").press("ctrl+r")
+
+	m := h.model.(model)
+	if len(m.tabs) != 1 || !strings.HasPrefix(m.tabs[0].id, "07L") {
+		t.Fatalf("expected the log of the execution to be opened, got %+v", m.tabs)
+	}
+	v := h.view()
+	if !strings.Contains(v, "Executed successfully") || !strings.Contains(v, "Execute Anonymous: System.debug('hello');") {
+		t.Errorf("expected the result and the log of the execution:\n%s", v)
+	}
+	if m.logs[0].ID != m.tabs[0].id {
+		t.Errorf("expected the list of logs to include the new log")
+	}
+	if !m.viewport.Focused() {
+		t.Errorf("expected the log to be focused once loaded")
+	}
+
+	srv.SetExecuteAnonymous(func(code string) (sf.ExecuteAnonymousResult, string) {
+		return sf.ExecuteAnonymousResult{Line: 1, Column: 7, CompileProblem: "Unexpected token 'debug'."}, ""
+	})
+	h.press("tab", "ctrl+r")
+	if v := h.view(); !strings.Contains(v, "compile error at line 1, column 7") {
+		t.Errorf("expected the compile error to be shown:\n%s", v)
+	}
+
+	h.press("esc")
+	if m := h.model.(model); m.left == anonymousPanel || !m.table.Focused() {
+		t.Errorf("expected the table to be focused after closing the editor")
+	}
+}
+
+func TestAnonymousLogIdIgnoresOtherUsers(t *testing.T) {
+	srv := sftest.New(sftest.FixturesDir())
+	addLog := func(userId, startTime string) string {
+		return srv.AddLog(map[string]any{
+			"LogUserId": userId,
+			"Operation": "/services/data/v61.0/tooling/executeAnonymous/",
+			"Status":    "Success",
+			"StartTime": startTime,
+		}, "10:00:00.0 (1)|EXECUTION_STARTED")
+	}
+	own := addLog(sftest.UserId, "2024-06-16T10:00:01.000+0000")
+	addLog("005000000000002AAA", "2024-06-16T10:00:02.000+0000")
+
+	since := time.Date(2024, 6, 16, 10, 0, 0, 0, time.UTC)
+	id, err := anonymousLogId(context.Background(), srv.Client(), sftest.UserId, since)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if id != own {
+		t.Errorf("expected the log of the user %s, got %s", own, id)
+	}
+}
+
+func TestRunAnonymousApexOnStart(t *testing.T) {
+	srv := sftest.New(sftest.FixturesDir())
+	opts := testOptions(t)
+	opts.AnonymousApex = "System.debug('on start');"
+	h := newHarnessWithOptions(t, srv, opts).start(160, 30)
+
+	if v := h.view(); !strings.Contains(v, "Execute Anonymous: System.debug('on start');") {
+		t.Errorf("expected the code to run once the org is connected:\n%s", v)
+	}
+}
+
+func TestRunApexTests(t *testing.T) {
+	srv := sftest.New(sftest.FixturesDir())
+	srv.SetRunTests(func(class string) []sftest.TestMethod {
+		return []sftest.TestMethod{
+			{Name: "createsAccount", Outcome: sf.TestOutcomePass, Log: "10:00:00.0 (1)|USER_DEBUG|[1]|DEBUG|creating account"},
+			{Name: "updatesAccount", Outcome: sf.TestOutcomeFail, Message: "System.AssertException: Assertion Failed"},
+		}
+	})
+	opts := testOptions(t)
+	opts.TestClasses = []string{"AccountTest"}
+	h := newHarnessWithOptions(t, srv, opts).start(160, 30)
+
+	v := h.view()
+	for _, want := range []string{"Tests: AccountTest", "AccountTest.createsAccount", "AccountTest.updatesAccount", "1 passed, 1 failed"} {
+		if !strings.Contains(v, want) {
+			t.Errorf("expected the view to contain %q:\n%s", want, v)
+		}
+	}
+
+	h.press("enter")
+	if v := h.view(); !strings.Contains(v, "creating account") {
+		t.Errorf("expected the log of the test to be opened:\n%s", v)
+	}
+	if m := h.model.(model); len(m.logs) == 0 || m.logs[0].ID != m.tabs[0].id {
+		t.Errorf("expected the list of logs to include the log of the test")
+	}
+
+	h.press("tab", "down")
+	if v := h.view(); !strings.Contains(v, "System.AssertException: Assertion Failed") {
+		t.Errorf("expected the failure of the selected test to be shown:\n%s", v)
+	}
+	h.press("enter")
+	if v := h.view(); !strings.Contains(v, "the selected test did not generate a log") {
+		t.Errorf("expected the missing log to be reported:\n%s", v)
+	}
+
+	h.press("esc")
+	if m := h.model.(model); m.left == testsPanel || !m.table.Focused() {
+		t.Errorf("expected the table to be focused after closing the test results")
+	}
+	if h.press("T"); h.model.(model).left != testsPanel {
+		t.Errorf("expected the test results to be shown again")
+	}
+}
+
+func TestPanelsOverTestResults(t *testing.T) {
+	srv := sftest.New(sftest.FixturesDir())
+	opts := testOptions(t)
+	opts.TestClasses = []string{"AccountTest"}
+	h := newHarnessWithOptions(t, srv, opts).start(160, 30)
+
+	h.press("enter", "L")
+	if m := h.model.(model); m.left != bookmarksPanel || !m.bookmarks.Focused() || m.testrun.Focused() {
+		t.Fatalf("expected only the bookmarks to be focused")
+	}
+	if v := h.view(); strings.Contains(v, "Tests: AccountTest") {
+		t.Errorf("expected the bookmarks to replace the test results:\n%s", v)
+	}
+
+	h.press("esc")
+	if m := h.model.(model); m.left != testsPanel || !m.viewport.Focused() || m.testrun.Focused() {
+		t.Errorf("expected the test results to be restored with the viewport focused")
+	}
+	if v := h.view(); !strings.Contains(v, "Tests: AccountTest") {
+		t.Errorf("expected the test results to be shown:\n%s", v)
+	}
+	if h.press("tab"); !h.model.(model).testrun.Focused() {
+		t.Errorf("expected the test results to be focused")
+	}
+}
@@ -0,0 +1,119 @@
+package app
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// A leftPanel is what the left side of the screen shows.
+type leftPanel int
+
+const (
+	tablePanel leftPanel = iota
+	resultsPanel
+	anonymousPanel
+	testsPanel
+	// The event types and bookmarks describe the open log. They are shown
+	// over one of the panels above, which is restored when they close.
+	eventsPanel
+	bookmarksPanel
+)
+
+// overlay reports whether p is shown over another panel instead of replacing it.
+func (p leftPanel) overlay() bool {
+	return p == eventsPanel || p == bookmarksPanel
+}
+
+// openLeft shows p on the left and focuses it.
+func (m *model) openLeft(p leftPanel) tea.Cmd {
+	if p.overlay() && !m.left.overlay() {
+		m.under = m.left
+	}
+	m.left = p
+	cmd := m.focusLeft()
+	m.resize()
+	return cmd
+}
+
+// closeLeft closes the panel shown on the left. The panel an overlay was
+// shown over is restored and the viewport focused, the table is shown and
+// focused otherwise. The panels keep their content, so the test results can be
+// shown again.
+func (m *model) closeLeft() {
+	if m.left.overlay() {
+		m.left = m.under
+		m.focusViewport()
+	} else {
+		m.left = tablePanel
+		m.focusLeft()
+	}
+	m.resize()
+}
+
+// switchFocus moves the focus between the left panel and the right one.
+// The right panel shows the comparison of two logs while it is open, or the
+// viewport otherwise.
+func (m *model) switchFocus() tea.Cmd {
+	if m.viewport.Focused() || m.diff.Focused() {
+		return m.focusLeft()
+	}
+	m.focusViewport()
+	return nil
+}
+
+// focusLeft focuses the panel shown on the left.
+func (m *model) focusLeft() tea.Cmd {
+	m.blurAll()
+	switch m.left {
+	case resultsPanel:
+		m.keys.showResults = true
+		return m.results.Focus()
+	case anonymousPanel:
+		m.keys.showAnonymous = true
+		return m.anonymous.Focus()
+	case testsPanel:
+		m.keys.showTests = true
+		m.testrun.Focus()
+	case eventsPanel:
+		m.keys.showEvents = true
+		m.events.Focus()
+	case bookmarksPanel:
+		m.keys.showBookmarks = true
+		m.bookmarks.Focus()
+	default:
+		m.keys.showTable = true
+		m.table.Focus()
+	}
+	return nil
+}
+
+// focusViewport focuses the panel shown on the right.
+func (m *model) focusViewport() {
+	m.blurAll()
+	if m.showDiff {
+		m.keys.showDiff = true
+		m.diff.Focus()
+		return
+	}
+	m.keys.showViewport = true
+	m.viewport.Focus()
+}
+
+// blurAll removes the focus from every panel.
+func (m *model) blurAll() {
+	m.table.Blur()
+	m.results.Blur()
+	m.anonymous.Blur()
+	m.testrun.Blur()
+	m.events.Blur()
+	m.bookmarks.Blur()
+	m.viewport.Blur()
+	m.diff.Blur()
+	m.keys.showTable = false
+	m.keys.showResults = false
+	m.keys.showAnonymous = false
+	m.keys.showTests = false
+	m.keys.showEvents = false
+	m.keys.showBookmarks = false
+	m.keys.showViewport = false
+	m.keys.showDiff = false
+}
@@ -0,0 +1,20 @@
+package results
+
+import (
+	"github.com/charmbracelet/bubbles/key"
+)
+
+type KeyMap struct {
+	// Search runs the query typed in the input. It is handled by the
+	// application, and only shown in the prompt.
+	Search key.Binding
+}
+
+func DefaultKeyMap() KeyMap {
+	return KeyMap{
+		Search: key.NewBinding(
+			key.WithKeys("enter"),
+			key.WithHelp("enter", "search"),
+		),
+	}
+}
@@ -0,0 +1,247 @@
+package results
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	sf "github.com/cdelmoral/apexlogs/internal/salesforce"
+	"github.com/cdelmoral/apexlogs/internal/search"
+	"github.com/cdelmoral/apexlogs/internal/theme"
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/table"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	emptyMsg       = "No matching lines found"
+	promptMsg      = "Type a text or /regex/"
+	searchingMsg   = "Searching logs..."
+	datetimeLayout = "02 Jan 15:04"
+	// headerHeight is the height of the header row and its bottom border.
+	headerHeight = 2
+	// inputHeight is the height of the search input and its bottom border.
+	inputHeight = 2
+)
+
+// Model displays the lines matching a search across all the indexed logs.
+//
+// It contains the following elements:
+//   - A text input for the query
+//   - A table with a row per matching line
+//   - Loading spinner and status messages
+type Model struct {
+	KeyMap    KeyMap
+	style     lipgloss.Style
+	input     textinput.Model
+	table     table.Model
+	spinner   spinner.Model
+	results   []search.Result
+	err       error
+	height    int
+	width     int
+	focused   bool
+	searching bool
+	searched  bool
+}
+
+// New creates a new [Model].
+func New() Model {
+	input := textinput.New()
+	input.Placeholder = "Search all logs..."
+	input.Prompt = "search: "
+
+	t := table.New(table.WithColumns(columns(0)))
+	s := table.DefaultStyles()
+	s.Header = s.Header.BorderStyle(lipgloss.NormalBorder()).BorderBottom(true)
+	th := theme.Current()
+	s.Selected = s.Selected.Foreground(th.SelectedForeground).Background(th.SelectedBackground)
+	t.SetStyles(s)
+
+	return Model{
+		KeyMap: DefaultKeyMap(),
+		input:  input,
+		table:  t,
+		style: lipgloss.NewStyle().
+			Border(lipgloss.NormalBorder()).
+			BorderForeground(theme.Current().Focused).
+			MarginRight(1),
+	}
+}
+
+func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case spinner.TickMsg:
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
+	case tea.KeyMsg:
+		if !m.focused {
+			return m, nil
+		}
+		var cmd tea.Cmd
+		if m.input.Focused() {
+			m.input, cmd = m.input.Update(msg)
+		} else {
+			m.table, cmd = m.table.Update(msg)
+		}
+		return m, cmd
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
+func (m Model) View() string {
+	input := lipgloss.NewStyle().
+		BorderStyle(lipgloss.NormalBorder()).
+		BorderBottom(true).
+		Render(m.input.View())
+
+	var status string
+	switch {
+	case m.searching:
+		status = fmt.Sprintf("%s %s", m.spinner.View(), searchingMsg)
+	case m.err != nil:
+		status = m.err.Error()
+	case !m.searched:
+		status = m.promptMsg()
+	case len(m.results) == 0:
+		status = emptyMsg
+	default:
+		status = fmt.Sprintf("%d matching lines", len(m.results))
+	}
+
+	v := lipgloss.JoinVertical(lipgloss.Left, input, m.table.View(), status)
+	return m.style.Render(v)
+}
+
+// promptMsg tells how to search with the keys in use.
+func (m Model) promptMsg() string {
+	if !m.KeyMap.Search.Enabled() {
+		return promptMsg
+	}
+	return fmt.Sprintf("%s and press %s", promptMsg, m.KeyMap.Search.Help().Key)
+}
+
+// StartSearch shows the loading spinner until the results are set.
+func (m *Model) StartSearch() tea.Cmd {
+	m.searching = true
+	m.err = nil
+	m.input.Blur()
+	m.spinner = spinner.New()
+	return m.spinner.Tick
+}
+
+// SetResults displays the given results.
+// logs is used to display the start time of the log of every result.
+func (m *Model) SetResults(rs []search.Result, logs []sf.ApexLog, err error) {
+	m.searching = false
+	m.searched = true
+	m.results = rs
+	m.err = err
+
+	byId := make(map[string]sf.ApexLog, len(logs))
+	for _, l := range logs {
+		byId[l.ID] = l
+	}
+
+	rows := make([]table.Row, 0, len(rs))
+	for _, r := range rs {
+		start := r.LogId
+		if l, ok := byId[r.LogId]; ok {
+			if st, err := time.Parse(sf.DateTimeLayout, l.StartTime); err == nil {
+				start = st.Format(datetimeLayout)
+			}
+		}
+		rows = append(rows, table.Row{start, strconv.Itoa(r.Line + 1), strings.TrimSpace(r.Text)})
+	}
+	m.table.SetRows(rows)
+	m.table.SetCursor(0)
+}
+
+// Selected returns the result under the cursor.
+func (m Model) Selected() (search.Result, bool) {
+	i := m.table.Cursor()
+	if i < 0 || i >= len(m.results) {
+		return search.Result{}, false
+	}
+	return m.results[i], true
+}
+
+// SetTableKeyMap sets the key bindings moving the cursor through the table.
+func (m *Model) SetTableKeyMap(km table.KeyMap) {
+	m.table.KeyMap = km
+}
+
+// Query returns the text typed in the search input.
+func (m Model) Query() string {
+	return m.input.Value()
+}
+
+// Typing reports whether the key presses are sent to the search input.
+func (m Model) Typing() bool {
+	return m.focused && m.input.Focused()
+}
+
+// EditQuery moves the focus to the search input.
+func (m *Model) EditQuery() tea.Cmd {
+	m.table.Blur()
+	return m.input.Focus()
+}
+
+// Focus focuses the search input if there are no results yet, or the results otherwise.
+func (m *Model) Focus() tea.Cmd {
+	m.focused = true
+	m.style = m.style.BorderForeground(theme.Current().Focused)
+	if len(m.results) == 0 {
+		return m.EditQuery()
+	}
+	m.table.Focus()
+	return nil
+}
+
+func (m *Model) Blur() {
+	m.focused = false
+	m.style = m.style.BorderForeground(theme.Current().Base)
+	m.input.Blur()
+	m.table.Blur()
+}
+
+func (m Model) Focused() bool {
+	return m.focused
+}
+
+// SetHeight sets the total height of the model, including its border.
+// One line is kept below the table for the status message.
+func (m *Model) SetHeight(h int) {
+	m.height = h
+	hc := h - m.style.GetVerticalFrameSize()
+	m.table.SetHeight(hc - inputHeight - headerHeight - 1)
+	m.style = m.style.Height(hc).MaxHeight(h)
+}
+
+// SetWidth sets the total width of the model, including its border and margin.
+func (m *Model) SetWidth(w int) {
+	m.width = w
+	wc := w - m.style.GetHorizontalFrameSize()
+	m.input.Width = wc - lipgloss.Width(m.input.Prompt) - 1
+	m.table.SetColumns(columns(wc))
+	m.table.SetWidth(wc)
+	m.style = m.style.Width(wc).MaxWidth(w)
+}
+
+// columns returns the table columns filling the given width.
+func columns(w int) []table.Column {
+	// Every column has a padding of one character on each side.
+	text := max(w-12-5-3*2, 10)
+	return []table.Column{
+		{Title: "Start time", Width: 12},
+		{Title: "Line", Width: 5},
+		{Title: "Text", Width: text},
+	}
+}
@@ -0,0 +1,355 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cdelmoral/apexlogs/internal/apexlog"
+	"github.com/cdelmoral/apexlogs/internal/cache"
+	sf "github.com/cdelmoral/apexlogs/internal/salesforce"
+	"github.com/cdelmoral/apexlogs/internal/search"
+)
+
+// A logSource provides the apex logs displayed by the application.
+type logSource interface {
+	// Logs returns the metadata of the available logs, most recent first.
+	Logs(ctx context.Context) ([]sf.ApexLog, error)
+	// Body returns the content of the log with the given id.
+	Body(ctx context.Context, id string) (string, error)
+	// OpenBody opens the content of the log with the given id, so it can be
+	// displayed while it is downloaded. The size is the length of the
+	// content, or -1 if it is unknown.
+	OpenBody(ctx context.Context, id string) (io.ReadCloser, int64, error)
+}
+
+// An orgSource fetches the logs from a Salesforce org.
+// When a cache is available, downloaded bodies are stored in it and logs that
+// are no longer in the org are still listed if their body is cached.
+// limit is the number of logs of the org listed, zero lists the default number.
+type orgSource struct {
+	client *sf.Client
+	cache  *cache.Cache
+	limit  int
+}
+
+func (s orgSource) Logs(ctx context.Context) ([]sf.ApexLog, error) {
+	apexLogs, err := sf.DoQuery[sf.ApexLog](ctx, s.client, sf.SelectApexLogsWhere(sf.ApexLogFilter{Limit: s.limit}))
+	if err != nil {
+		return nil, err
+	}
+	if s.cache == nil {
+		return apexLogs.Records, nil
+	}
+
+	if err := s.cache.SaveLogs(apexLogs.Records); err != nil {
+		log.Printf("error caching apex logs: %s", err)
+		return apexLogs.Records, nil
+	}
+	cached, err := s.cache.Logs()
+	if err != nil {
+		log.Printf("error reading cached apex logs: %s", err)
+		return apexLogs.Records, nil
+	}
+
+	inOrg := make(map[string]bool, len(apexLogs.Records))
+	for _, l := range apexLogs.Records {
+		inOrg[l.ID] = true
+	}
+	logs := make([]sf.ApexLog, 0, len(cached))
+	for _, l := range cached {
+		if inOrg[l.ID] || s.cache.HasBody(l.ID) {
+			logs = append(logs, l)
+		}
+	}
+	return logs, nil
+}
+
+func (s orgSource) Body(ctx context.Context, id string) (string, error) {
+	if s.cache != nil {
+		body, err := s.cache.Body(id)
+		if err == nil {
+			return body, nil
+		}
+		if !errors.Is(err, cache.ErrNotCached) {
+			log.Printf("error reading cached apex log: %s", err)
+		}
+	}
+
+	body, err := sf.GetSObjectBody(ctx, s.client, "ApexLog", id)
+	if err != nil {
+		return "", err
+	}
+
+	if s.cache != nil {
+		if err := s.cache.SaveBody(id, body); err != nil {
+			log.Printf("error caching apex log: %s", err)
+		}
+	}
+	return body, nil
+}
+
+func (s orgSource) OpenBody(ctx context.Context, id string) (io.ReadCloser, int64, error) {
+	if s.cache != nil {
+		r, size, err := s.cache.OpenBody(id)
+		if err == nil {
+			return r, size, nil
+		}
+		if !errors.Is(err, cache.ErrNotCached) {
+			log.Printf("error reading cached apex log: %s", err)
+		}
+	}
+
+	r, size, err := sf.OpenSObjectBody(ctx, s.client, "ApexLog", id)
+	if err != nil {
+		return nil, 0, err
+	}
+	if s.cache == nil {
+		return r, size, nil
+	}
+	w, err := s.cache.CreateBody(id)
+	if err != nil {
+		log.Printf("error caching apex log: %s", err)
+		return r, size, nil
+	}
+	return &cachingReader{ReadCloser: r, w: w}, size, nil
+}
+
+// A cachingReader writes the body of a log to the cache while it is read,
+// keeping it once it is read completely.
+type cachingReader struct {
+	io.ReadCloser
+	// w is nil once the body is kept or discarded.
+	w *cache.BodyWriter
+}
+
+func (r *cachingReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	if r.w == nil {
+		return n, err
+	}
+	if _, werr := r.w.Write(p[:n]); werr != nil {
+		log.Printf("error caching apex log: %s", werr)
+		r.w.Discard()
+		r.w = nil
+		return n, err
+	}
+	if err == io.EOF {
+		if err := r.w.Commit(); err != nil {
+			log.Printf("error caching apex log: %s", err)
+		}
+		r.w = nil
+	}
+	return n, err
+}
+
+// Close discards the body if it was not read completely.
+func (r *cachingReader) Close() error {
+	if r.w != nil {
+		r.w.Discard()
+		r.w = nil
+	}
+	return r.ReadCloser.Close()
+}
+
+// A cacheSource browses the logs stored in the local cache without connecting to the org.
+type cacheSource struct {
+	cache *cache.Cache
+}
+
+func (s cacheSource) Logs(ctx context.Context) ([]sf.ApexLog, error) {
+	cached, err := s.cache.Logs()
+	if err != nil {
+		return nil, err
+	}
+
+	// Only logs with a cached body can be opened offline.
+	logs := make([]sf.ApexLog, 0, len(cached))
+	for _, l := range cached {
+		if s.cache.HasBody(l.ID) {
+			logs = append(logs, l)
+		}
+	}
+	return logs, nil
+}
+
+func (s cacheSource) Body(ctx context.Context, id string) (string, error) {
+	body, err := s.cache.Body(id)
+	if errors.Is(err, cache.ErrNotCached) {
+		return "", fmt.Errorf("apex log %s is not available offline", id)
+	}
+	return body, err
+}
+
+func (s cacheSource) OpenBody(ctx context.Context, id string) (io.ReadCloser, int64, error) {
+	r, size, err := s.cache.OpenBody(id)
+	if errors.Is(err, cache.ErrNotCached) {
+		return nil, 0, fmt.Errorf("apex log %s is not available offline", id)
+	}
+	return r, size, err
+}
+
+// stdinPath is the path that reads a log from the standard input.
+const stdinPath = "-"
+
+// A fileSource reads logs from local files, like the ones saved by
+// `sf apex get log` or the Apex Replay Debugger.
+// The id of every log is the path of its file.
+type fileSource struct {
+	paths []string
+	// stdin is the log read from the standard input, if any.
+	stdin    string
+	stdinLog sf.ApexLog
+}
+
+// newFileSource creates a source of the log files in paths.
+// The standard input is read right away when one of the paths is "-".
+func newFileSource(paths []string, stdin io.Reader) (*fileSource, error) {
+	s := &fileSource{paths: paths}
+	for _, p := range paths {
+		if p != stdinPath {
+			continue
+		}
+		b, err := io.ReadAll(stdin)
+		if err != nil {
+			return nil, fmt.Errorf("error reading standard input: %w", err)
+		}
+		s.stdin = string(b)
+		s.stdinLog = sf.ApexLog{
+			ID:        stdinPath,
+			Operation: "stdin",
+			Status:    "Local",
+			StartTime: time.Now().UTC().Format(sf.DateTimeLayout),
+			LogLength: len(b),
+		}
+	}
+	return s, nil
+}
+
+// Logs lists the log files in the paths of the source.
+// Directories are searched recursively for files with the .log extension.
+func (s *fileSource) Logs(ctx context.Context) ([]sf.ApexLog, error) {
+	var logs []sf.ApexLog
+	for _, p := range s.paths {
+		if p == stdinPath {
+			logs = append(logs, s.stdinLog)
+			continue
+		}
+
+		err := filepath.WalkDir(p, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() || (path != p && !strings.EqualFold(filepath.Ext(path), ".log")) {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			logs = append(logs, sf.ApexLog{
+				ID:        path,
+				Operation: filepath.Base(path),
+				Status:    "Local",
+				StartTime: info.ModTime().UTC().Format(sf.DateTimeLayout),
+				LogLength: int(info.Size()),
+			})
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("error listing log files: %w", err)
+		}
+	}
+
+	// Files have no start time, the most recently modified ones are listed first.
+	sort.SliceStable(logs, func(i, j int) bool {
+		return logs[i].StartTime > logs[j].StartTime
+	})
+	return logs, nil
+}
+
+func (s *fileSource) Body(ctx context.Context, id string) (string, error) {
+	if id == stdinPath {
+		return s.stdin, nil
+	}
+	b, err := os.ReadFile(id)
+	if err != nil {
+		return "", fmt.Errorf("error reading log file: %w", err)
+	}
+	return string(b), nil
+}
+
+func (s *fileSource) OpenBody(ctx context.Context, id string) (io.ReadCloser, int64, error) {
+	if id == stdinPath {
+		return io.NopCloser(strings.NewReader(s.stdin)), int64(len(s.stdin)), nil
+	}
+	f, err := os.Open(id)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error reading log file: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, 0, fmt.Errorf("error reading log file: %w", err)
+	}
+	return f, info.Size(), nil
+}
+
+// openCache opens the cache of the org of the given user.
+// A nil cache is returned when the cache is disabled or cannot be opened,
+// since the application still works without it.
+func openCache(dir, username string) *cache.Cache {
+	if dir == "" {
+		return nil
+	}
+	c, err := cache.Open(dir, username)
+	if err != nil {
+		log.Printf("error opening local cache: %s", err)
+		return nil
+	}
+	return c
+}
+
+// newSearchIndex creates an index of the log bodies stored in c.
+// Searching requires the cache, so a nil index is returned when there is none.
+func newSearchIndex(c *cache.Cache) *search.Index {
+	if c == nil {
+		return nil
+	}
+	return search.NewIndex(c.Body)
+}
+
+// indexCachedLogs adds the cached log bodies to the index, most recent first.
+// It returns the ids of the logs truncated by Salesforce.
+func indexCachedLogs(ix *search.Index, c *cache.Cache) []string {
+	logs, err := c.Logs()
+	if err != nil {
+		log.Printf("error indexing cached apex logs: %s", err)
+		return nil
+	}
+
+	var truncated []string
+	for _, l := range logs {
+		body, err := c.Body(l.ID)
+		if errors.Is(err, cache.ErrNotCached) {
+			continue
+		}
+		if err != nil {
+			log.Printf("error indexing cached apex log: %s", err)
+			continue
+		}
+		ix.Add(l.ID, body)
+		if apexlog.IsTruncated(body) {
+			truncated = append(truncated, l.ID)
+		}
+	}
+	return truncated
+}
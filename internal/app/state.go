@@ -0,0 +1,60 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+const stateFile = "state.json"
+
+// state is the user interface state remembered between runs.
+type state struct {
+	// HiddenEvents are the event types hidden from the viewport.
+	HiddenEvents []string `json:"hiddenEvents"`
+}
+
+// DefaultStateDir returns the directory the application state is saved to.
+func DefaultStateDir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "apexlogs"), nil
+}
+
+// loadState reads the state saved in dir.
+// A missing state file returns the zero state.
+func loadState(dir string) (state, error) {
+	var s state
+	if dir == "" {
+		return s, nil
+	}
+	b, err := os.ReadFile(filepath.Join(dir, stateFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return s, err
+	}
+	err = json.Unmarshal(b, &s)
+	return s, err
+}
+
+// saveState writes the state to dir, creating it if needed.
+// Nothing is saved when dir is empty.
+func saveState(dir string, s state) error {
+	if dir == "" {
+		return nil
+	}
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, stateFile), b, 0o644)
+}
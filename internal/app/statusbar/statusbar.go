@@ -0,0 +1,240 @@
+package statusbar
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cdelmoral/apexlogs/internal/download"
+	sf "github.com/cdelmoral/apexlogs/internal/salesforce"
+	"github.com/cdelmoral/apexlogs/internal/theme"
+	"github.com/cdelmoral/apexlogs/internal/traceflag"
+	"github.com/charmbracelet/bubbles/progress"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	warningPercent  = 80
+	criticalPercent = 95
+	// warningRemaining is the remaining time below which the trace flag is highlighted.
+	warningRemaining = 5 * time.Minute
+	separator        = " • "
+	progressWidth    = 20
+)
+
+// Model is a single line bar displayed below the main panels.
+//
+// It displays the following information:
+//   - Whether the logs are browsed offline or read from local files
+//   - Remaining time of the trace flag and renewal errors
+//   - Daily API requests used by the org
+//   - Progress of the apex log being opened
+//   - Progress of the last bulk download
+//   - The last error or message
+type Model struct {
+	style        lipgloss.Style
+	apiUsage     sf.ApiUsage
+	traceFlag    traceflag.Status
+	download     download.Progress
+	downloadDir  string
+	loadRead     int64
+	loadSize     int64
+	progress     progress.Model
+	now          time.Time
+	err          error
+	message      string
+	hasApiUsage  bool
+	hasTraceFlag bool
+	hasDownload  bool
+	downloading  bool
+	loading      bool
+	offline      bool
+	local        bool
+	width        int
+}
+
+// New creates a new [Model].
+func New() Model {
+	return Model{
+		style: lipgloss.NewStyle().Foreground(theme.Current().Base).Padding(0, 1),
+		progress: progress.New(
+			progress.WithSolidFill(string(theme.Current().Base)),
+			progress.WithWidth(progressWidth),
+			progress.WithoutPercentage(),
+		),
+	}
+}
+
+func (m Model) View() string {
+	var items []string
+	if m.offline {
+		items = append(items, "Offline")
+	}
+	if m.local {
+		items = append(items, "Local files")
+	}
+	if m.hasTraceFlag {
+		items = append(items, m.traceFlagView())
+	}
+	if m.hasApiUsage {
+		items = append(items, m.apiUsageView())
+	}
+	if m.loading {
+		items = append(items, m.loadingView())
+	}
+	if m.hasDownload {
+		items = append(items, m.downloadView())
+	}
+	if m.err != nil {
+		items = append(items, lipgloss.NewStyle().Foreground(theme.Current().Error).Render(m.err.Error()))
+	} else if m.message != "" {
+		items = append(items, m.message)
+	}
+	s := strings.Join(items, separator)
+	return m.style.Width(m.width).MaxWidth(m.width).Render(s)
+}
+
+// SetApiUsage updates the API usage displayed in the bar.
+func (m *Model) SetApiUsage(u sf.ApiUsage, ok bool) {
+	m.apiUsage = u
+	m.hasApiUsage = ok
+}
+
+// SetTraceFlag updates the trace flag status displayed in the bar.
+// The remaining time is computed relative to now.
+func (m *Model) SetTraceFlag(s traceflag.Status, now time.Time) {
+	m.traceFlag = s
+	m.hasTraceFlag = true
+	m.now = now
+}
+
+// SetDownload updates the progress of the bulk download into dir.
+// running is false once the download has finished.
+func (m *Model) SetDownload(p download.Progress, dir string, running bool) {
+	m.download = p
+	m.downloadDir = dir
+	m.downloading = running
+	m.hasDownload = true
+}
+
+// SetLoading updates the progress of the apex log being opened, read out of
+// size bytes, or an unknown size if it is negative.
+// loading is false once the log has been read completely.
+func (m *Model) SetLoading(read, size int64, loading bool) {
+	m.loadRead = read
+	m.loadSize = size
+	m.loading = loading
+}
+
+// SetOffline sets whether the logs are browsed offline.
+func (m *Model) SetOffline(offline bool) {
+	m.offline = offline
+}
+
+// SetLocal sets whether the logs are read from local files.
+func (m *Model) SetLocal(local bool) {
+	m.local = local
+}
+
+// SetError displays err in the bar, a nil error clears the previous one.
+func (m *Model) SetError(err error) {
+	m.err = err
+	m.message = ""
+}
+
+// Err returns the error displayed in the bar, or nil if there is none.
+func (m Model) Err() error {
+	return m.err
+}
+
+// SetMessage displays the outcome of an action in the bar, in place of the last error.
+func (m *Model) SetMessage(s string) {
+	m.err = nil
+	m.message = s
+}
+
+// SetTime updates the time used to compute the remaining time of the trace flag.
+func (m *Model) SetTime(now time.Time) {
+	m.now = now
+}
+
+func (m *Model) SetWidth(w int) {
+	m.width = w
+}
+
+// Height returns the number of lines taken by the bar.
+func (m Model) Height() int {
+	return 1
+}
+
+func (m Model) apiUsageView() string {
+	p := m.apiUsage.Percent()
+	s := fmt.Sprintf("API requests: %d/%d (%d%%)", m.apiUsage.Used, m.apiUsage.Max, p)
+
+	style := lipgloss.NewStyle()
+	switch {
+	case p >= criticalPercent:
+		style = style.Foreground(theme.Current().Error)
+	case p >= warningPercent:
+		style = style.Foreground(theme.Current().Warning)
+	}
+	return style.Render(s)
+}
+
+func (m Model) downloadView() string {
+	p := m.download
+	if m.downloading {
+		percent := 0.0
+		if p.Total > 0 {
+			percent = float64(p.Finished()) / float64(p.Total)
+		}
+		return fmt.Sprintf("Downloading logs %s %d/%d", m.progress.ViewAs(percent), p.Finished(), p.Total)
+	}
+
+	s := fmt.Sprintf("%d logs saved to %s", p.Done+p.Skipped, m.downloadDir)
+	if p.Failed > 0 {
+		return lipgloss.NewStyle().Foreground(theme.Current().Error).Render(fmt.Sprintf("%s, %d failed", s, p.Failed))
+	}
+	return s
+}
+
+func (m Model) loadingView() string {
+	if m.loadSize <= 0 {
+		return fmt.Sprintf("Loading log %s", formatMegabytes(m.loadRead))
+	}
+	percent := min(float64(m.loadRead)/float64(m.loadSize), 1)
+	return fmt.Sprintf("Loading log %s %s/%s", m.progress.ViewAs(percent), formatMegabytes(m.loadRead), formatMegabytes(m.loadSize))
+}
+
+func (m Model) traceFlagView() string {
+	r := m.traceFlag.Remaining(m.now)
+	s := fmt.Sprintf("Trace flag: %s left", formatRemaining(r))
+	if r == 0 {
+		s = "Trace flag: expired"
+	}
+
+	style := lipgloss.NewStyle()
+	if r < warningRemaining {
+		style = style.Foreground(theme.Current().Warning)
+	}
+	if m.traceFlag.Err != nil {
+		style = style.Foreground(theme.Current().Error)
+		s = fmt.Sprintf("%s (renewal failed: %s)", s, m.traceFlag.Err)
+	}
+	return style.Render(s)
+}
+
+// formatRemaining formats d rounded to the minute, e.g. "1h05m" or "30m".
+func formatRemaining(d time.Duration) string {
+	d = d.Round(time.Minute)
+	h, m := int(d.Hours()), int(d.Minutes())%60
+	if h > 0 {
+		return fmt.Sprintf("%dh%02dm", h, m)
+	}
+	return fmt.Sprintf("%dm", m)
+}
+
+// formatMegabytes formats a number of bytes in megabytes, e.g. "20.1 MB".
+func formatMegabytes(n int64) string {
+	return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+}
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	sf "github.com/cdelmoral/apexlogs/internal/salesforce"
+	"github.com/cdelmoral/apexlogs/internal/theme"
 	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/table"
 	tea "github.com/charmbracelet/bubbletea"
@@ -14,21 +15,15 @@ import (
 const (
 	emptyMsg       = "No logs found to display"
 	loadingMsg     = "Loading apex logs..."
-	focusedColor   = lipgloss.Color("12")
-	baseColor      = lipgloss.Color("7")
 	datetimeLayout = "02 Jan 15:04"
+	// headerHeight is the height of the header row and its bottom border.
+	headerHeight = 2
+	// truncatedMark precedes the status of the logs truncated by Salesforce.
+	truncatedMark = "! "
 )
 
-var headerStyle = lipgloss.NewStyle().
-	BorderStyle(lipgloss.NormalBorder()).
-	BorderForeground(lipgloss.Color("240")).
-	BorderBottom(true).
-	Bold(false)
-
-var selectedStyle = lipgloss.NewStyle().
-	Foreground(lipgloss.Color("229")).
-	Background(lipgloss.Color("57")).
-	Bold(false)
+// compareMarks precede the operation of the logs marked to be compared.
+var compareMarks = [2]string{"A ", "B "}
 
 type Table = table.Model
 
@@ -37,11 +32,16 @@ type Table = table.Model
 // It adds the following functionality:
 //   - Loading spinner
 //   - Empty state message
+//   - Marks on the logs truncated by Salesforce
+//   - Marks on the logs to compare
 type Model struct {
-	style   lipgloss.Style
-	cols    []table.Column
-	ids     []string
-	spinner spinner.Model
+	style     lipgloss.Style
+	cols      []table.Column
+	ids       []string
+	logs      []sf.ApexLog
+	truncated map[string]bool
+	marked    []string
+	spinner   spinner.Model
 	Table
 	height      int
 	width       int
@@ -63,14 +63,15 @@ func New(opts ...table.Option) Model {
 	t := table.New(opts...)
 	s := table.DefaultStyles()
 	s.Header = s.Header.BorderStyle(lipgloss.NormalBorder()).BorderBottom(true)
-	s.Selected = s.Selected.Foreground(lipgloss.Color("229")).Background(lipgloss.Color("57"))
+	th := theme.Current()
+	s.Selected = s.Selected.Foreground(th.SelectedForeground).Background(th.SelectedBackground)
 	t.SetStyles(s)
 
 	return Model{
 		Table: t,
 		style: lipgloss.NewStyle().
-			BorderStyle(lipgloss.NormalBorder()).
-			BorderForeground(focusedColor).
+			Border(lipgloss.NormalBorder()).
+			BorderForeground(theme.Current().Focused).
 			MarginRight(1),
 	}
 }
@@ -117,7 +118,8 @@ func (a *Model) StopSpinner() {
 }
 
 func (a *Model) SetLogs(logs []sf.ApexLog) {
-	ids, rows := marshalLogs(logs)
+	a.logs = logs
+	ids, rows := marshalLogs(logs, a.truncated, a.marked)
 	a.ids = ids
 	if len(rows) == 0 {
 		a.Table.SetHeight(5)
@@ -128,25 +130,50 @@ func (a *Model) SetLogs(logs []sf.ApexLog) {
 	a.SetRows(rows)
 }
 
+// SetTruncated marks the log with the given id as truncated by Salesforce.
+func (a *Model) SetTruncated(id string) {
+	if a.truncated[id] {
+		return
+	}
+	if a.truncated == nil {
+		a.truncated = map[string]bool{}
+	}
+	a.truncated[id] = true
+	_, rows := marshalLogs(a.logs, a.truncated, a.marked)
+	a.SetRows(rows)
+}
+
+// SetMarked marks the logs with the given ids as the first and second log to compare.
+func (a *Model) SetMarked(ids []string) {
+	a.marked = ids
+	_, rows := marshalLogs(a.logs, a.truncated, a.marked)
+	a.SetRows(rows)
+}
+
+// SetHeight sets the total height of the model, including its border.
+// One line is kept below the table for the loading and empty state messages.
 func (m *Model) SetHeight(h int) {
 	m.height = h
-	m.Table.SetHeight(h - 5)
-	m.style = m.style.Height(h - 3).MaxHeight(h)
+	hc := h - m.style.GetVerticalFrameSize()
+	m.Table.SetHeight(hc - headerHeight - 1)
+	m.style = m.style.Height(hc).MaxHeight(h)
 }
 
+// SetWidth sets the total width of the model, including its border and margin.
 func (a *Model) SetWidth(w int) {
 	a.width = w
-	a.Table.SetWidth(w - 3)
-	a.style = a.style.Width(w - 3).MaxWidth(w)
+	wc := w - a.style.GetHorizontalFrameSize()
+	a.Table.SetWidth(wc)
+	a.style = a.style.Width(wc).MaxWidth(w)
 }
 
 func (a *Model) Blur() {
-	a.style = a.style.BorderForeground(baseColor)
+	a.style = a.style.BorderForeground(theme.Current().Base)
 	a.Table.Blur()
 }
 
 func (a *Model) Focus() {
-	a.style = a.style.BorderForeground(focusedColor)
+	a.style = a.style.BorderForeground(theme.Current().Focused)
 	a.Table.Focus()
 }
 
@@ -158,7 +185,7 @@ func (m Model) SelectedLogId() string {
 	return ""
 }
 
-func marshalLogs(logs []sf.ApexLog) ([]string, []table.Row) {
+func marshalLogs(logs []sf.ApexLog, truncated map[string]bool, marked []string) ([]string, []table.Row) {
 	rows := make([]table.Row, 0, len(logs))
 	ids := make([]string, 0, len(logs))
 	for _, log := range logs {
@@ -166,13 +193,23 @@ func marshalLogs(logs []sf.ApexLog) ([]string, []table.Row) {
 		if err != nil {
 			st = time.Now()
 		}
+		status := log.Status
+		if truncated[log.ID] {
+			status = truncatedMark + status
+		}
+		operation := log.Operation
+		for i, id := range marked {
+			if id == log.ID {
+				operation = compareMarks[i] + operation
+			}
+		}
 
 		rows = append(
 			rows,
 			table.Row{
 				st.Format(datetimeLayout),
-				log.Operation,
-				log.Status,
+				operation,
+				status,
 				printSize(log.LogLength),
 			},
 		)
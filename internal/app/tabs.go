@@ -0,0 +1,198 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cdelmoral/apexlogs/internal/app/viewport"
+	"github.com/cdelmoral/apexlogs/internal/cache"
+	sf "github.com/cdelmoral/apexlogs/internal/salesforce"
+	"github.com/cdelmoral/apexlogs/internal/theme"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	// tabTimeLayout is the start time of the logs displayed in the tabs.
+	tabTimeLayout = "15:04:05"
+)
+
+// A logTab is an open log with its own viewport, so its scroll position and
+// filter are kept while other logs are displayed.
+//
+// The viewport of the current tab is the viewport of the model, so the
+// viewport of a tab is only up to date while the tab is not displayed.
+type logTab struct {
+	id string
+	// line is the zero based line scrolled to once the log is loaded.
+	line     int
+	viewport viewport.Model
+	cancel   context.CancelFunc
+	loading  bool
+	// bookmarks are the bookmarked lines of the log, sorted by line.
+	bookmarks []cache.Bookmark
+}
+
+// tabIndex returns the index of the tab of the log with the given id, or -1
+// if the log is not open.
+func (m model) tabIndex(id string) int {
+	for i, t := range m.tabs {
+		if t.id == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// tabViewport returns the viewport of the tab with the given index.
+func (m *model) tabViewport(i int) *viewport.Model {
+	if i == m.tab {
+		return &m.viewport
+	}
+	return &m.tabs[i].viewport
+}
+
+// addTab displays the given tab after the open ones, with an empty viewport.
+func (m *model) addTab(t logTab) {
+	if len(m.tabs) > 0 {
+		m.tabs[m.tab].viewport = m.viewport
+	}
+	m.tabs = append(m.tabs, t)
+	m.tab = len(m.tabs) - 1
+	m.viewport = m.newViewport()
+	m.resize()
+}
+
+// replaceTab displays the given tab in place of the current one, or adds it
+// if no log is open. The viewport is kept with its filter, but its content is
+// cleared, so the previous log is not displayed under the new one.
+func (m *model) replaceTab(t logTab) {
+	if len(m.tabs) == 0 {
+		m.addTab(t)
+		return
+	}
+	if c := m.tabs[m.tab].cancel; c != nil {
+		c()
+	}
+	m.tabs[m.tab] = t
+	m.viewport.SetContent("")
+	m.events.SetCounts(m.viewport.EventCounts())
+}
+
+// logReady reports whether the viewport is focused on an open log that has
+// finished loading, so the actions on its lines can be used.
+func (m model) logReady() bool {
+	return m.viewport.Focused() && len(m.tabs) > 0 && !m.tabs[m.tab].loading
+}
+
+// switchTab displays the tab with the given index, keeping the focus on the
+// viewport or on the left panel.
+func (m *model) switchTab(i int) {
+	if i == m.tab || i < 0 || i >= len(m.tabs) {
+		return
+	}
+	m.tabs[m.tab].viewport = m.viewport
+	m.tab = i
+	m.setViewport(m.tabs[i].viewport)
+}
+
+// closeTab closes the current tab, abandoning its download, and displays the
+// next one. The left panel is focused after closing the last tab.
+func (m *model) closeTab() {
+	if len(m.tabs) == 0 {
+		return
+	}
+	if c := m.tabs[m.tab].cancel; c != nil {
+		c()
+	}
+	m.tabs = append(m.tabs[:m.tab], m.tabs[m.tab+1:]...)
+	if len(m.tabs) == 0 {
+		focused := m.viewport.Focused()
+		m.tab = 0
+		m.viewport = m.newViewport()
+		m.events.SetCounts(nil)
+		m.statusbar.SetLoading(0, 0, false)
+		if m.left.overlay() {
+			m.left = m.under
+		}
+		m.showTabBookmarks()
+		if focused {
+			m.focusLeft()
+		}
+		m.resize()
+		return
+	}
+	m.tab = min(m.tab, len(m.tabs)-1)
+	m.setViewport(m.tabs[m.tab].viewport)
+}
+
+// setViewport displays vp, with the focus where the previous viewport had it.
+func (m *model) setViewport(vp viewport.Model) {
+	focused := m.viewport.Focused()
+	m.viewport = vp
+	if focused {
+		m.viewport.Focus()
+	} else {
+		m.viewport.Blur()
+	}
+	m.events.SetCounts(m.viewport.EventCounts())
+	m.showTabBookmarks()
+	// The progress of a log loading in the background is not displayed.
+	m.statusbar.SetLoading(0, 0, false)
+	m.resize()
+}
+
+// newViewport creates an empty viewport hiding the hidden event types, sized
+// by the next resize.
+func (m model) newViewport() viewport.Model {
+	vp := viewport.New(m.terminalWidth, m.terminalHeight)
+	vp.KeyMap = m.keys.viewportKeys
+	vp.SetHiddenEvents(m.events.Hidden())
+	return vp
+}
+
+// tabsHeight returns the height of the tab bar, which is only displayed
+// while several logs are open.
+func (m model) tabsHeight() int {
+	if len(m.tabs) < 2 {
+		return 0
+	}
+	return 1
+}
+
+// tabsView renders a tab per open log with its start time and operation,
+// like "1: 22:50:17 /aura", the current one between brackets.
+func (m model) tabsView(width int) string {
+	if m.tabsHeight() == 0 {
+		return ""
+	}
+	active := lipgloss.NewStyle().Bold(true).Foreground(theme.Current().Focused)
+	var tabs []string
+	for i, t := range m.tabs {
+		label := fmt.Sprintf("%d: %s", i+1, m.tabLabel(t.id))
+		if i == m.tab {
+			tabs = append(tabs, active.Render("["+label+"]"))
+		} else {
+			tabs = append(tabs, " "+label+" ")
+		}
+	}
+	return lipgloss.NewStyle().
+		Width(width).
+		MaxWidth(width).
+		Render(strings.Join(tabs, " "))
+}
+
+// tabLabel describes the log with the given id by its start time and operation.
+func (m model) tabLabel(id string) string {
+	for _, l := range m.logs {
+		if l.ID != id {
+			continue
+		}
+		if st, err := time.Parse(sf.DateTimeLayout, l.StartTime); err == nil {
+			return st.Format(tabTimeLayout) + " " + l.Operation
+		}
+		return l.Operation
+	}
+	return id
+}
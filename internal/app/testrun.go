@@ -0,0 +1,86 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/cdelmoral/apexlogs/internal/apextest"
+	sf "github.com/cdelmoral/apexlogs/internal/salesforce"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// errTestsOffline is reported when running tests without an org.
+var errTestsOffline = errors.New("running apex tests requires a connection to the org")
+
+// errNoTestLog is reported when opening the log of a test that was not traced.
+var errNoTestLog = errors.New("the selected test did not generate a log")
+
+// A testProgressMsg reports the progress of a test run.
+// logs is the refreshed list of logs, set once the run is done, which
+// includes the logs of the tests. updates is closed after the message with
+// done set.
+type testProgressMsg struct {
+	progress apextest.Progress
+	logs     []sf.ApexLog
+	err      error
+	done     bool
+	updates  <-chan testProgressMsg
+}
+
+// runTestsCmd runs the tests of the given classes in the background.
+func runTestsCmd(ctx context.Context, client *sf.Client, source logSource, classes []string) tea.Cmd {
+	// The progress is buffered so the run never waits for the model.
+	updates := make(chan testProgressMsg, 1)
+	go func() {
+		defer close(updates)
+		send := func(msg testProgressMsg) bool {
+			msg.updates = updates
+			select {
+			case updates <- msg:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		p, err := apextest.New(client).Run(ctx, classes, func(p apextest.Progress) {
+			if !p.Done() {
+				send(testProgressMsg{progress: p})
+			}
+		})
+		if errors.Is(err, context.Canceled) {
+			return
+		}
+		msg := testProgressMsg{progress: p, done: true}
+		if err != nil {
+			msg.err = fmt.Errorf("error running apex tests: %w", err)
+		} else if msg.logs, err = source.Logs(ctx); err != nil {
+			msg.err = fmt.Errorf("error getting apex logs: %w", err)
+		}
+		send(msg)
+	}()
+	return waitForTestProgress(updates)
+}
+
+func waitForTestProgress(updates <-chan testProgressMsg) tea.Cmd {
+	return func() tea.Msg {
+		msg, ok := <-updates
+		if !ok {
+			return nil
+		}
+		return msg
+	}
+}
+
+// startTests runs the tests of the given classes, showing their results in
+// place of the left panel.
+func (m *model) startTests(classes []string) tea.Cmd {
+	if m.salesforceClient == nil {
+		m.statusbar.SetError(errTestsOffline)
+		return nil
+	}
+	m.keys.tests.SetEnabled(true)
+	m.openLeft(testsPanel)
+	return tea.Batch(m.testrun.Start(classes), runTestsCmd(m.ctx, m.salesforceClient, m.source, classes))
+}
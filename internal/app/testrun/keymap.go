@@ -0,0 +1,18 @@
+package testrun
+
+import (
+	"github.com/charmbracelet/bubbles/key"
+)
+
+type KeyMap struct {
+	Open key.Binding
+}
+
+func DefaultKeyMap() KeyMap {
+	return KeyMap{
+		Open: key.NewBinding(
+			key.WithKeys("enter"),
+			key.WithHelp("enter", "open log of selected test"),
+		),
+	}
+}
@@ -0,0 +1,272 @@
+package testrun
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cdelmoral/apexlogs/internal/apextest"
+	"github.com/cdelmoral/apexlogs/internal/app/text"
+	sf "github.com/cdelmoral/apexlogs/internal/salesforce"
+	"github.com/cdelmoral/apexlogs/internal/theme"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	runningMsg = "Running tests..."
+	noLogMsg   = "The test did not generate a log"
+	// titleHeight is the height of the title and its bottom border.
+	titleHeight = 2
+	// headerHeight is the height of the header row of the table and its bottom border.
+	headerHeight = 2
+	// detailHeight is the height of the status line, the details of the
+	// selected test and their top border.
+	detailHeight = 5
+)
+
+// An OpenMsg is sent to open the log of a test result.
+type OpenMsg struct {
+	Result apextest.Result
+}
+
+// Model displays the results of a test run as they are reported, with the
+// failure message of the selected test below them.
+type Model struct {
+	KeyMap   KeyMap
+	style    lipgloss.Style
+	table    table.Model
+	spinner  spinner.Model
+	classes  []string
+	progress apextest.Progress
+	err      error
+	running  bool
+	focused  bool
+	height   int
+	width    int
+}
+
+// New creates a new [Model].
+func New() Model {
+	t := table.New(table.WithColumns(columns(0)))
+	s := table.DefaultStyles()
+	s.Header = s.Header.BorderStyle(lipgloss.NormalBorder()).BorderBottom(true)
+	th := theme.Current()
+	s.Selected = s.Selected.Foreground(th.SelectedForeground).Background(th.SelectedBackground)
+	t.SetStyles(s)
+
+	return Model{
+		KeyMap: DefaultKeyMap(),
+		table:  t,
+		style: lipgloss.NewStyle().
+			Border(lipgloss.NormalBorder()).
+			BorderForeground(theme.Current().Base).
+			MarginRight(1),
+	}
+}
+
+func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case spinner.TickMsg:
+		if !m.running {
+			return m, nil
+		}
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
+	case tea.KeyMsg:
+		if !m.focused {
+			return m, nil
+		}
+		if key.Matches(msg, m.KeyMap.Open) {
+			if r, ok := m.Selected(); ok {
+				return m, func() tea.Msg { return OpenMsg{Result: r} }
+			}
+			return m, nil
+		}
+		var cmd tea.Cmd
+		m.table, cmd = m.table.Update(msg)
+		return m, cmd
+	}
+	return m, nil
+}
+
+func (m Model) View() string {
+	wc := max(m.width-m.style.GetHorizontalFrameSize(), 1)
+	title := lipgloss.NewStyle().
+		BorderStyle(lipgloss.NormalBorder()).
+		BorderBottom(true).
+		Render(text.Truncate("Tests: "+strings.Join(m.classes, ", "), wc))
+
+	detail := lipgloss.NewStyle().
+		BorderStyle(lipgloss.NormalBorder()).
+		BorderTop(true).
+		Width(wc).
+		Height(detailHeight - 1).
+		MaxHeight(detailHeight).
+		Render(lipgloss.JoinVertical(lipgloss.Left, m.status(), m.detail()))
+
+	return m.style.Render(lipgloss.JoinVertical(lipgloss.Left, title, m.table.View(), detail))
+}
+
+// status summarizes the run, like "3 passed, 1 failed".
+func (m Model) status() string {
+	p := m.progress
+	switch {
+	case m.err != nil:
+		return lipgloss.NewStyle().Foreground(theme.Current().Error).Render(m.err.Error())
+	case m.running:
+		s := fmt.Sprintf("%s %s", m.spinner.View(), runningMsg)
+		if p.Classes > 0 {
+			s += fmt.Sprintf(" %d/%d classes", p.Finished, p.Classes)
+		}
+		return s
+	}
+
+	s := fmt.Sprintf("%d passed, %d failed", p.Passed(), p.Failed())
+	if len(p.Errors) > 0 {
+		s += fmt.Sprintf(", %d classes not run", len(p.Errors))
+	}
+	color := theme.Current().Success
+	if p.Failed() > 0 || len(p.Errors) > 0 {
+		color = theme.Current().Error
+	}
+	return lipgloss.NewStyle().Foreground(color).Render(s)
+}
+
+// detail describes the failure of the selected test, or the classes that
+// could not run when no test failed.
+func (m Model) detail() string {
+	r, ok := m.Selected()
+	switch {
+	case ok && !r.Passed():
+		return strings.TrimSpace(r.Message + "\n" + r.StackTrace)
+	case len(m.progress.Errors) > 0:
+		return strings.Join(m.progress.Errors, "\n")
+	case ok && r.LogId == "":
+		return noLogMsg
+	}
+	return ""
+}
+
+// Start clears the results and shows the loading spinner until the tests of
+// the given classes finish.
+func (m *Model) Start(classes []string) tea.Cmd {
+	m.classes = classes
+	m.progress = apextest.Progress{}
+	m.err = nil
+	m.running = true
+	m.table.SetRows(nil)
+	m.spinner = spinner.New()
+	return m.spinner.Tick
+}
+
+// SetProgress displays the results reported so far, stopping the spinner
+// once the run is done.
+func (m *Model) SetProgress(p apextest.Progress) {
+	m.progress = p
+	m.running = !p.Done()
+
+	rows := make([]table.Row, 0, len(p.Results))
+	for _, r := range p.Results {
+		rows = append(rows, table.Row{outcomeLabel(r.Outcome), r.Name(), formatRunTime(r.RunTime)})
+	}
+	m.table.SetRows(rows)
+	if m.table.Cursor() >= len(rows) {
+		m.table.SetCursor(max(len(rows)-1, 0))
+	}
+}
+
+// SetError stops the run with the given error.
+func (m *Model) SetError(err error) {
+	m.running = false
+	m.err = err
+}
+
+// SetTableKeyMap sets the key bindings moving the cursor through the table.
+func (m *Model) SetTableKeyMap(km table.KeyMap) {
+	m.table.KeyMap = km
+}
+
+// Running reports whether the tests are still running.
+func (m Model) Running() bool {
+	return m.running
+}
+
+// Selected returns the result under the cursor.
+func (m Model) Selected() (apextest.Result, bool) {
+	i := m.table.Cursor()
+	if i < 0 || i >= len(m.progress.Results) {
+		return apextest.Result{}, false
+	}
+	return m.progress.Results[i], true
+}
+
+func (m *Model) Focus() {
+	m.focused = true
+	m.style = m.style.BorderForeground(theme.Current().Focused)
+	m.table.Focus()
+}
+
+func (m *Model) Blur() {
+	m.focused = false
+	m.style = m.style.BorderForeground(theme.Current().Base)
+	m.table.Blur()
+}
+
+func (m Model) Focused() bool {
+	return m.focused
+}
+
+// SetHeight sets the total height of the model, including its border.
+func (m *Model) SetHeight(h int) {
+	m.height = h
+	hc := h - m.style.GetVerticalFrameSize()
+	m.table.SetHeight(max(hc-titleHeight-headerHeight-detailHeight, 1))
+	m.style = m.style.Height(hc).MaxHeight(h)
+}
+
+// SetWidth sets the total width of the model, including its border and margin.
+func (m *Model) SetWidth(w int) {
+	m.width = w
+	wc := w - m.style.GetHorizontalFrameSize()
+	m.table.SetColumns(columns(wc))
+	m.table.SetWidth(wc)
+	m.style = m.style.Width(wc).MaxWidth(w)
+}
+
+// columns returns the table columns filling the given width.
+func columns(w int) []table.Column {
+	// Every column has a padding of one character on each side.
+	test := max(w-5-7-3*2, 10)
+	return []table.Column{
+		{Title: "", Width: 5},
+		{Title: "Test", Width: test},
+		{Title: "Time", Width: 7},
+	}
+}
+
+// outcomeLabel returns a short label of the outcome of a test, in upper case
+// when it did not pass so failures stand out.
+func outcomeLabel(outcome string) string {
+	switch outcome {
+	case sf.TestOutcomePass:
+		return "pass"
+	case sf.TestOutcomeSkip:
+		return "skip"
+	case sf.TestOutcomeCompileFail:
+		return "ERROR"
+	}
+	return strings.ToUpper(outcome)
+}
+
+// formatRunTime formats the duration of a test like 250ms or 1.25s.
+func formatRunTime(d time.Duration) string {
+	if d < time.Second {
+		return fmt.Sprintf("%dms", d.Milliseconds())
+	}
+	return fmt.Sprintf("%.2fs", d.Seconds())
+}
@@ -0,0 +1,15 @@
+// Package text has the helpers shared by the panels to fit text in their width.
+package text
+
+// Truncate cuts s to at most w runes, ending it with an ellipsis when it is
+// cut and there is room for one. It returns an empty string when w < 1.
+func Truncate(s string, w int) string {
+	r := []rune(s)
+	if len(r) <= w {
+		return s
+	}
+	if w <= 1 {
+		return string(r[:max(w, 0)])
+	}
+	return string(r[:w-1]) + "…"
+}
@@ -0,0 +1,25 @@
+package text
+
+import "testing"
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		s    string
+		w    int
+		want string
+	}{
+		{"USER_DEBUG", 20, "USER_DEBUG"},
+		{"USER_DEBUG", 10, "USER_DEBUG"},
+		{"USER_DEBUG", 5, "USER…"},
+		{"USER_DEBUG", 1, "U"},
+		{"USER_DEBUG", 0, ""},
+		{"USER_DEBUG", -1, ""},
+		{"héllo wörld", 5, "héll…"},
+		{"日本語のログ", 4, "日本語…"},
+	}
+	for _, tt := range tests {
+		if got := Truncate(tt.s, tt.w); got != tt.want {
+			t.Errorf("Truncate(%q, %d) = %q, want %q", tt.s, tt.w, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package app
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/cdelmoral/apexlogs/internal/salesforce/sftest"
+)
+
+func TestViewGolden(t *testing.T) {
+	tests := []struct {
+		name   string
+		width  int
+		height int
+		script func(h *harness)
+	}{
+		{"logs", 120, 30, func(h *harness) {}},
+		{"logs_help", 120, 30, func(h *harness) { h.press("?") }},
+		{"open_log", 120, 30, func(h *harness) { h.press("end", "enter") }},
+		{"open_log_help", 120, 30, func(h *harness) { h.press("end", "enter", "?") }},
+		{"filter_box", 120, 30, func(h *harness) { h.press("end", "enter", "/").typeText("DUPLICATE") }},
+		{"filter_applied", 120, 30, func(h *harness) { h.press("end", "enter", "/").typeText("DUPLICATE").press("enter") }},
+		{"filter_context", 120, 30, func(h *harness) { h.press("end", "enter", "/").typeText("detection_end").press("enter", "+", "+") }},
+		{"filter_highlight", 120, 30, func(h *harness) { h.press("end", "enter", "/").typeText("-duplicate").press("enter", "m", "n") }},
+		{"filter_invalid", 120, 30, func(h *harness) { h.press("end", "enter", "/").typeText(`DUPLICATE "unclosed`) }},
+		{"filter_closed", 120, 30, func(h *harness) { h.press("end", "enter", "/").typeText("DUPLICATE").press("enter", "esc") }},
+		{"events", 120, 30, func(h *harness) { h.press("end", "enter", "e") }},
+		{"events_hidden", 120, 30, func(h *harness) { h.press("end", "enter", "e", " ", "down", " ") }},
+		{"events_closed", 120, 30, func(h *harness) { h.press("end", "enter", "e", " ", "esc") }},
+		{"focus_table", 120, 30, func(h *harness) { h.press("end", "enter", "tab") }},
+		{"small", 80, 16, func(h *harness) { h.press("end", "enter") }},
+		{"resized", 120, 30, func(h *harness) { h.press("end", "enter").resize(100, 24) }},
+		{"search_box", 120, 30, func(h *harness) { h.press("end", "enter", "tab", "ctrl+f").typeText("duplicate") }},
+		{"search_results", 120, 30, func(h *harness) { h.press("end", "enter", "tab", "ctrl+f").typeText("duplicate").press("enter") }},
+		{"search_closed", 120, 30, func(h *harness) {
+			h.press("end", "enter", "tab", "ctrl+f").typeText("duplicate").press("enter", "esc")
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := sftest.New(sftest.FixturesDir())
+			h := newHarness(t, srv).start(tt.width, tt.height)
+			tt.script(h)
+			h.assertGolden(tt.name)
+		})
+	}
+}
+
+func TestViewFitsTerminal(t *testing.T) {
+	sizes := []struct{ width, height int }{
+		{80, 16}, {80, 24}, {100, 40}, {160, 50},
+	}
+	scripts := map[string]func(h *harness){
+		"logs":          func(h *harness) {},
+		"help":          func(h *harness) { h.press("?") },
+		"open_log":      func(h *harness) { h.press("end", "enter") },
+		"filter_box":    func(h *harness) { h.press("end", "enter", "/") },
+		"viewport_help": func(h *harness) { h.press("end", "enter", "?") },
+		"search":        func(h *harness) { h.press("end", "enter", "tab", "ctrl+f").typeText("duplicate").press("enter") },
+	}
+
+	for name, script := range scripts {
+		for _, s := range sizes {
+			t.Run(fmt.Sprintf("%s_%dx%d", name, s.width, s.height), func(t *testing.T) {
+				srv := sftest.New(sftest.FixturesDir())
+				h := newHarness(t, srv).start(s.width, s.height)
+				script(h)
+				h.assertFits(s.width, s.height)
+			})
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package viewport
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/cdelmoral/apexlogs/internal/app/text"
+	"github.com/cdelmoral/apexlogs/internal/filter"
+	"github.com/cdelmoral/apexlogs/internal/theme"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// A filterMode is how the lines matching the filter are displayed.
+type filterMode int
+
+const (
+	// modeFilter displays only the matching lines and their context lines.
+	modeFilter filterMode = iota
+	// modeHighlight displays every line, highlighting the matches.
+	modeHighlight
+)
+
+const separatorLine = "--"
+
+func (m filterMode) toggle() filterMode {
+	if m == modeHighlight {
+		return modeFilter
+	}
+	return modeHighlight
+}
+
+func (m filterMode) String() string {
+	if m == modeHighlight {
+		return "highlight"
+	}
+	return "filter"
+}
+
+// setFilter parses the expression and applies it to the content.
+// An invalid expression keeps the previous filter applied.
+func (m *Model) setFilter(s string) {
+	expr, err := filter.Parse(s)
+	m.filterErr = err
+	if err != nil {
+		return
+	}
+	m.expr = expr
+	m.current = 0
+	m.applyFilter()
+	m.gotoMatch(0)
+}
+
+func (m *Model) closeFilter() {
+	m.showFilter = false
+	m.textInput.Blur()
+	m.textInput.SetValue("")
+	m.expr = nil
+	m.filterErr = nil
+	m.applyFilter()
+	m.SetHeight(m.containerHeight)
+}
+
+// applyFilter updates the displayed content with the matches of the filter.
+// Lines of hidden event types are never displayed nor matched.
+func (m *Model) applyFilter() {
+	m.visible = m.visible[:0]
+	m.positions = m.positions[:0]
+	m.matches = m.matches[:0]
+	m.isMatch = map[int]bool{}
+	m.displayed = nil
+	m.filterFrom(0)
+}
+
+// filterFrom applies the filter to the lines from start, which are the only
+// ones changed when content is appended, keeping the results of the previous
+// lines.
+func (m *Model) filterFrom(start int) {
+	v := sort.SearchInts(m.visible, start)
+	m.visible = m.visible[:v]
+	for i := start; i < len(m.lines); i++ {
+		if !m.hiddenEvents[m.lineEvents[i]] {
+			m.visible = append(m.visible, i)
+		}
+	}
+
+	k := sort.SearchInts(m.matches, start)
+	for _, i := range m.matches[k:] {
+		delete(m.isMatch, i)
+	}
+	m.matches = m.matches[:k]
+	m.positions = m.positions[:k]
+	if m.expr == nil || m.mode == modeHighlight {
+		m.displayed = m.visible
+	}
+	if m.expr == nil {
+		m.clampYOffset()
+		return
+	}
+
+	for p := v; p < len(m.visible); p++ {
+		if i := m.visible[p]; m.expr.Match(m.lines[i]) {
+			m.matches = append(m.matches, i)
+			m.isMatch[i] = true
+			m.positions = append(m.positions, p)
+		}
+	}
+	m.current = min(m.current, max(len(m.matches)-1, 0))
+
+	if m.mode == modeFilter {
+		// Only the context of the matches close to the changed lines can
+		// change, so the lines displayed for the previous ones are kept.
+		k = sort.SearchInts(m.positions, v-m.contextLines)
+		next := 0
+		if k > 0 {
+			next = m.positions[k-1] + m.contextLines + 1
+		}
+		n := 0
+		if k > 0 {
+			n = len(m.displayed)
+			last := m.visible[next-1]
+			for m.displayed[n-1] < 0 || m.displayed[n-1] > last {
+				n--
+			}
+		}
+		m.displayed = contextLines(m.displayed[:n], m.positions[k:], next, len(m.visible), m.contextLines)
+		for j := n; j < len(m.displayed); j++ {
+			if p := m.displayed[j]; p >= 0 {
+				m.displayed[j] = m.visible[p]
+			}
+		}
+	}
+	m.clampYOffset()
+}
+
+// clampYOffset scrolls to the last lines when the displayed lines get shorter
+// than the current scroll position, keeping the cursor on them.
+func (m *Model) clampYOffset() {
+	if m.yOffset > len(m.displayed)-1 {
+		m.SetYOffset(m.maxYOffset())
+	}
+	m.cursor = max(min(m.cursor, len(m.displayed)-1), 0)
+}
+
+// gotoMatch makes the match with the given index the current one, wrapping
+// around the first and last matches, and moves the cursor to it, scrolling
+// to it if it is not visible.
+func (m *Model) gotoMatch(i int) {
+	if len(m.matches) == 0 {
+		return
+	}
+	m.current = (i%len(m.matches) + len(m.matches)) % len(m.matches)
+
+	target := m.matches[m.current]
+	for n, l := range m.displayed {
+		if l != target {
+			continue
+		}
+		if n < m.yOffset || n >= m.yOffset+m.height {
+			m.SetYOffset(max(n-m.height/3, 0))
+		}
+		m.setCursor(n)
+		return
+	}
+}
+
+// filterInfo describes the filter mode and the matches, or the filter error.
+func (m Model) filterInfo() string {
+	style := lipgloss.NewStyle().Width(m.infoWidth).MaxWidth(m.infoWidth).Align(lipgloss.Right)
+	if m.filterErr != nil {
+		return style.Foreground(theme.Current().Error).Render(text.Truncate(m.filterErr.Error(), m.infoWidth))
+	}
+	if m.expr == nil {
+		return style.Render(m.mode.String())
+	}
+
+	info := fmt.Sprintf("%s %d/%d", m.mode, min(m.current+1, len(m.matches)), len(m.matches))
+	if m.mode == modeFilter && m.contextLines > 0 {
+		info = fmt.Sprintf("%s ±%d %d/%d", m.mode, m.contextLines, min(m.current+1, len(m.matches)), len(m.matches))
+	}
+	return style.Render(info)
+}
+
+// displayIndex returns the index of the first displayed line at or after the given line.
+func (m Model) displayIndex(line int) int {
+	for n, i := range m.displayed {
+		if i >= line {
+			return n
+		}
+	}
+	return max(len(m.displayed)-1, 0)
+}
+
+// contextLines appends to lines the lines to display for the given matches,
+// including n lines around every match, from the line next on. Non contiguous
+// groups are separated by -1.
+func contextLines(lines, matches []int, next, total, n int) []int {
+	for _, i := range matches {
+		start, end := max(i-n, next), min(i+n, total-1)
+		if n > 0 && len(lines) > 0 && start > next {
+			lines = append(lines, -1)
+		}
+		for l := start; l <= end; l++ {
+			lines = append(lines, l)
+		}
+		next = max(next, end+1)
+	}
+	return lines
+}
@@ -0,0 +1,104 @@
+package viewport
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestContextLines(t *testing.T) {
+	tests := []struct {
+		matches []int
+		n       int
+		want    []int
+	}{
+		{[]int{1, 5}, 0, []int{1, 5}},
+		{[]int{1, 5}, 1, []int{0, 1, 2, -1, 4, 5, 6}},
+		{[]int{1, 3}, 1, []int{0, 1, 2, 3, 4}},
+		{[]int{0, 9}, 2, []int{0, 1, 2, -1, 7, 8, 9}},
+	}
+	for _, tt := range tests {
+		if got := contextLines(nil, tt.matches, 0, 10, tt.n); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("contextLines(%v, 10, %d) = %v, want %v", tt.matches, tt.n, got, tt.want)
+		}
+	}
+}
+
+func typeKeys(m Model, s string) Model {
+	for _, r := range s {
+		m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	return m
+}
+
+func TestFilterNavigation(t *testing.T) {
+	lines := make([]string, 100)
+	for i := range lines {
+		lines[i] = "STATEMENT_EXECUTE"
+	}
+	lines[10] = "USER_DEBUG|first"
+	lines[50] = "USER_DEBUG|second"
+	lines[90] = "USER_DEBUG|third"
+
+	m := New(80, 20)
+	m.SetContent(strings.Join(lines, "\n"))
+	m.Focus()
+
+	m = typeKeys(m, "/user_debug")
+	if m.TotalLineCount() != 3 {
+		t.Fatalf("expected the filter to be applied while typing, got %d lines", m.TotalLineCount())
+	}
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = typeKeys(m, "m")
+	if m.TotalLineCount() != 100 {
+		t.Fatalf("expected every line in highlight mode, got %d lines", m.TotalLineCount())
+	}
+
+	m = typeKeys(m, "n")
+	if m.matches[m.current] != 50 || m.yOffset > 50 || m.yOffset+m.height <= 50 {
+		t.Errorf("expected the second match to be visible, current %d offset %d", m.matches[m.current], m.yOffset)
+	}
+	m = typeKeys(m, "nn")
+	if m.matches[m.current] != 10 {
+		t.Errorf("expected next to wrap around to the first match, got line %d", m.matches[m.current])
+	}
+	m = typeKeys(m, "N")
+	if m.matches[m.current] != 90 {
+		t.Errorf("expected previous to wrap around to the last match, got line %d", m.matches[m.current])
+	}
+}
+
+func TestHiddenEvents(t *testing.T) {
+	lines := []string{
+		"61.0 APEX_CODE,FINEST",
+		"12:00:00.0 (1)|HEAP_ALLOCATE|[1]|Bytes:3",
+		"12:00:00.0 (2)|USER_DEBUG|[1]|DEBUG|first",
+		"continued",
+		"12:00:00.0 (3)|STATEMENT_EXECUTE|[2]",
+		"12:00:00.0 (4)|USER_DEBUG|[3]|DEBUG|second",
+	}
+	m := New(80, 20)
+	m.SetContent(strings.Join(lines, "\n"))
+
+	want := map[string]int{"HEAP_ALLOCATE": 1, "USER_DEBUG": 2, "STATEMENT_EXECUTE": 1}
+	if got := m.EventCounts(); !reflect.DeepEqual(got, want) {
+		t.Errorf("EventCounts() = %v, want %v", got, want)
+	}
+
+	m.SetHiddenEvents([]string{"HEAP_ALLOCATE", "USER_DEBUG"})
+	if want := []int{0, 4}; !reflect.DeepEqual(m.displayed, want) {
+		t.Errorf("expected the header and the visible events with their continuation lines, got %v, want %v", m.displayed, want)
+	}
+
+	m.SetHiddenEvents([]string{"HEAP_ALLOCATE"})
+	m.setFilter("debug")
+	if want := []int{2, 5}; !reflect.DeepEqual(m.matches, want) {
+		t.Errorf("matches = %v, want %v", m.matches, want)
+	}
+	m.setFilter("bytes")
+	if len(m.matches) != 0 {
+		t.Errorf("expected hidden lines not to match, got %v", m.matches)
+	}
+}
@@ -8,9 +8,16 @@ import (
 type viewportKeyMap = viewport.KeyMap
 
 type KeyMap struct {
-	Esc   key.Binding
-	Enter key.Binding
-	Slash key.Binding
+	Esc         key.Binding
+	Enter       key.Binding
+	Slash       key.Binding
+	NextMatch   key.Binding
+	PrevMatch   key.Binding
+	ToggleMode  key.Binding
+	MoreContext key.Binding
+	LessContext key.Binding
+	Syntax      key.Binding
+	Select      key.Binding
 	viewportKeyMap
 }
 
@@ -22,14 +29,40 @@ func DefaultKeyMap() KeyMap {
 		),
 		Enter: key.NewBinding(
 			key.WithKeys("enter"),
-			key.WithHelp("enter", "filter apex log"),
+			key.WithHelp("enter", "confirm filter"),
 		),
 		Slash: key.NewBinding(
 			key.WithKeys("/"),
 			key.WithHelp("/", "open filter box"),
 		),
+		NextMatch: key.NewBinding(
+			key.WithKeys("n"),
+			key.WithHelp("n", "next match"),
+		),
+		PrevMatch: key.NewBinding(
+			key.WithKeys("N"),
+			key.WithHelp("N", "previous match"),
+		),
+		ToggleMode: key.NewBinding(
+			key.WithKeys("m"),
+			key.WithHelp("m", "filter/highlight matches"),
+		),
+		MoreContext: key.NewBinding(
+			key.WithKeys("+"),
+			key.WithHelp("+", "more context lines"),
+		),
+		LessContext: key.NewBinding(
+			key.WithKeys("-"),
+			key.WithHelp("-", "fewer context lines"),
+		),
+		Syntax: key.NewBinding(
+			key.WithKeys("s"),
+			key.WithHelp("s", "toggle syntax highlighting"),
+		),
+		Select: key.NewBinding(
+			key.WithKeys("S"),
+			key.WithHelp("S", "select lines"),
+		),
 		viewportKeyMap: viewport.DefaultKeyMap(),
 	}
 }
-
-var keys = DefaultKeyMap()
@@ -0,0 +1,176 @@
+package viewport
+
+import (
+	"strings"
+
+	"github.com/cdelmoral/apexlogs/internal/apexlog"
+	"github.com/cdelmoral/apexlogs/internal/theme"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+// fieldSeparator replaces the pipes between the fields of an event.
+const fieldSeparator = " │ "
+
+// highlightedCategories are the categories of the event types with a color.
+var highlightedCategories = []apexlog.Category{
+	apexlog.CategorySOQL,
+	apexlog.CategoryDML,
+	apexlog.CategoryDebug,
+	apexlog.CategoryException,
+	apexlog.CategoryLimit,
+	apexlog.CategoryCodeUnit,
+	apexlog.CategoryMethod,
+	apexlog.CategoryValidation,
+	apexlog.CategoryFlow,
+}
+
+// styles renders the content of the viewport.
+// They are termenv styles rather than lipgloss ones, which are too slow to
+// render every part of every line of a large log.
+type styles struct {
+	dim          termenv.Style
+	match        termenv.Style
+	currentMatch termenv.Style
+	bookmark     termenv.Style
+	cursor       termenv.Style
+	selection    termenv.Style
+	categories   map[apexlog.Category]termenv.Style
+}
+
+func newStyles(p termenv.Profile, th theme.Theme) styles {
+	color := func(c lipgloss.Color) termenv.Color {
+		return p.Color(string(c))
+	}
+	s := styles{
+		dim:          p.String().Foreground(color(th.Dim)),
+		match:        p.String().Background(color(th.Match)).Foreground(color(th.MatchForeground)),
+		currentMatch: p.String().Background(color(th.CurrentMatch)).Foreground(color(th.MatchForeground)),
+		bookmark:     p.String().Foreground(color(th.Bookmark)).Bold(),
+		cursor:       p.String().Background(color(th.SelectedBackground)).Foreground(color(th.SelectedForeground)),
+		selection:    p.String().Reverse(),
+		categories:   make(map[apexlog.Category]termenv.Style, len(highlightedCategories)),
+	}
+	for _, c := range highlightedCategories {
+		s.categories[c] = p.String().Foreground(color(th.CategoryColor(c)))
+	}
+	s.categories[apexlog.CategoryException] = s.categories[apexlog.CategoryException].Bold()
+	return s
+}
+
+// A segment is a part of a line rendered with the same style.
+type segment struct {
+	start int
+	end   int
+	style *termenv.Style
+	// text replaces the text of the line in the segment if it is not empty.
+	text string
+}
+
+// segments splits the line in the parts colorized by the syntax highlighting.
+// Lines that are not events are a single segment.
+func (s styles) segments(line string) []segment {
+	l, ok := apexlog.ParseLine(line)
+	if !ok {
+		return []segment{{start: 0, end: len(line)}}
+	}
+
+	event := s.categories[apexlog.CategoryOf(l.EventType(line))]
+	segs := []segment{
+		{start: l.Time.Start, end: l.Time.End, style: &s.dim},
+		{start: l.Time.End, end: l.Nanos.Start},
+		{start: l.Nanos.Start, end: l.Nanos.End, style: &s.dim},
+		{start: l.Nanos.End, end: l.Event.Start, style: &s.dim, text: fieldSeparator},
+		{start: l.Event.Start, end: l.Event.End, style: &event},
+	}
+	for _, f := range l.Fields {
+		segs = append(segs,
+			segment{start: f.Start - 1, end: f.Start, style: &s.dim, text: fieldSeparator},
+			segment{start: f.Start, end: f.End},
+		)
+	}
+	return segs
+}
+
+// renderLine renders the line with the given matches highlighted with match.
+// Without syntax highlighting, only the matches are styled.
+func (s styles) renderLine(line string, syntax bool, matches [][2]int, match termenv.Style) string {
+	if !syntax && len(matches) == 0 {
+		return line
+	}
+
+	segs := []segment{{start: 0, end: len(line)}}
+	if syntax {
+		segs = s.segments(line)
+	}
+	return render(line, segs, matches, match)
+}
+
+// renderCursorLine renders the line under the cursor like [styles.renderLine],
+// with the cursor style in place of the syntax highlighting.
+func (s styles) renderCursorLine(line string, syntax bool, matches [][2]int, match termenv.Style) string {
+	segs := []segment{{start: 0, end: len(line)}}
+	if syntax {
+		segs = s.segments(line)
+	}
+	for i := range segs {
+		segs[i].style = &s.cursor
+	}
+	return render(line, segs, matches, match)
+}
+
+// render writes the segments of the line in their styles, with the parts
+// overlapping the matches in the match style.
+func render(line string, segs []segment, matches [][2]int, match termenv.Style) string {
+	var b strings.Builder
+	for _, seg := range segs {
+		if seg.text != "" {
+			style := seg.style
+			if overlaps(matches, seg.start, seg.end) {
+				style = &match
+			}
+			write(&b, seg.text, style)
+			continue
+		}
+
+		// Split the segment at the boundaries of the matches.
+		pos := seg.start
+		for _, m := range matches {
+			if m[1] <= pos || m[0] >= seg.end {
+				continue
+			}
+			start, end := max(m[0], pos), min(m[1], seg.end)
+			write(&b, line[pos:start], seg.style)
+			write(&b, line[start:end], &match)
+			pos = end
+		}
+		write(&b, line[pos:seg.end], seg.style)
+	}
+	return b.String()
+}
+
+func write(b *strings.Builder, s string, style *termenv.Style) {
+	if s == "" {
+		return
+	}
+	if style == nil {
+		b.WriteString(s)
+		return
+	}
+	b.WriteString(style.Styled(s))
+}
+
+func overlaps(ranges [][2]int, start, end int) bool {
+	for _, r := range ranges {
+		if r[0] < end && r[1] > start {
+			return true
+		}
+	}
+	return false
+}
+
+// currentStyles returns the styles of the current theme for the color
+// profile used by lipgloss.
+func currentStyles() styles {
+	return newStyles(lipgloss.ColorProfile(), theme.Current())
+}
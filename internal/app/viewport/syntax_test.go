@@ -0,0 +1,46 @@
+package viewport
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cdelmoral/apexlogs/internal/apexlog"
+	"github.com/cdelmoral/apexlogs/internal/theme"
+	"github.com/muesli/termenv"
+)
+
+func TestRenderLine(t *testing.T) {
+	line := "15:50:17.5 (5559211)|USER_DEBUG|[3]|DEBUG|hello"
+	s := newStyles(termenv.Ascii, theme.Current())
+
+	if got := s.renderLine(line, true, nil, s.match); got != "15:50:17.5 (5559211) │ USER_DEBUG │ [3] │ DEBUG │ hello" {
+		t.Errorf("unexpected pretty printed line %q", got)
+	}
+	if got := s.renderLine(line, false, nil, s.match); got != line {
+		t.Errorf("expected the raw line without syntax highlighting, got %q", got)
+	}
+	if got := s.renderLine("not an event", true, nil, s.match); got != "not an event" {
+		t.Errorf("expected lines that are not events to be unchanged, got %q", got)
+	}
+}
+
+func TestRenderLineColors(t *testing.T) {
+	line := "15:50:17.5 (5559211)|USER_DEBUG|[3]|DEBUG|hello"
+	s := newStyles(termenv.ANSI256, theme.Current())
+
+	got := s.renderLine(line, true, [][2]int{{42, 45}}, s.match)
+	if want := s.categories[apexlog.CategoryDebug].Styled("USER_DEBUG"); !strings.Contains(got, want) {
+		t.Errorf("expected the debug color for the event type, got %q", got)
+	}
+	if want := s.dim.Styled("15:50:17.5"); !strings.Contains(got, want) {
+		t.Errorf("expected the time to be dimmed, got %q", got)
+	}
+	if !strings.Contains(got, s.match.Styled("hel")+"lo") {
+		t.Errorf("expected the match to be highlighted inside the field, got %q", got)
+	}
+
+	light, _ := theme.Builtin(theme.Light)
+	if l := newStyles(termenv.ANSI256, light).renderLine(line, true, nil, s.match); strings.Contains(l, s.categories[apexlog.CategoryDebug].Styled("USER_DEBUG")) {
+		t.Errorf("expected the light theme to change the debug color, got %q", l)
+	}
+}
@@ -4,37 +4,90 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/cdelmoral/apexlogs/internal/apexlog"
+	"github.com/cdelmoral/apexlogs/internal/app/text"
+	"github.com/cdelmoral/apexlogs/internal/filter"
+	"github.com/cdelmoral/apexlogs/internal/theme"
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/textinput"
-	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
 )
 
 const (
-	emptyMsg     = "Select an apex log to see the content"
-	loadingMsg   = "Loading selected apex log..."
-	focusedColor = lipgloss.Color("12")
-	baseColor    = lipgloss.Color("7")
+	emptyMsg   = "Select an apex log to see the content"
+	loadingMsg = "Loading selected apex log..."
+	// maxInfoWidth is the maximum width of the filter mode and match count inside the filter box.
+	maxInfoWidth = 24
+	// truncationHint suggests how to keep logs below the maximum size.
+	truncationHint = "lower the debug levels, e.g. Apex Code to DEBUG"
+	// bookmarkMark precedes the bookmarked lines.
+	bookmarkMark = "» "
 )
 
-type Viewport = viewport.Model
-
-// Model wraps the [viewport.Model] type.
+// Model is a scrollable view of the lines of an apex log.
 //
-// It adds the following functionality:
-//   - A text input for filtering the content
+// Only the visible lines are rendered, so logs of several megabytes scroll as
+// fast as small ones. It has the following functionality:
+//   - Content appended as it is downloaded, see [Model.AppendContent]
+//   - A text input for filtering the content, see [filter.Parse]
+//   - Highlighting and navigation of the filter matches
+//   - Syntax highlighting of the log events
+//   - A banner warning about logs truncated by Salesforce
+//   - Marks on the bookmarked lines
+//   - A cursor on the current line, see [Model.CurrentLine]
+//   - Selection of a range of lines, see [Model.Selection]
 //   - Focus/blur functionality
 //   - Loading spinner
 //   - Empty state message
 type Model struct {
+	KeyMap         KeyMap
 	viewportStyle  lipgloss.Style
 	textInputStyle lipgloss.Style
-	content        string
-	Viewport
-	textInput       textinput.Model
-	spinner         spinner.Model
+	// lines are the lines of the content. The last one is incomplete until
+	// the rest of it is appended.
+	lines []string
+	// lineEvents are the event types of the lines. Continuation lines have the
+	// event type of the event they belong to.
+	lineEvents   []string
+	hiddenEvents map[string]bool
+	// truncation maps the lines marking that Salesforce truncated the log to
+	// the number of bytes they skipped.
+	truncation map[int]int
+	bookmarks  map[int]bool
+	// selectStart is the line the selection started at, which ends at the
+	// current line.
+	selectStart int
+	selecting   bool
+	width       int
+	height      int
+	// yOffset is the index in displayed of the first visible line.
+	yOffset int
+	// cursor is the index in displayed of the current line, which is always
+	// visible.
+	cursor    int
+	textInput textinput.Model
+	infoWidth int
+	spinner   spinner.Model
+	expr      *filter.Expr
+	filterErr error
+	mode      filterMode
+	// contextLines is the number of lines displayed around every match in filter mode.
+	contextLines int
+	// visible are the indexes of the lines not hidden by their event type.
+	visible []int
+	// matches are the indexes of the lines matching the filter, and positions
+	// their indexes in visible.
+	matches   []int
+	positions []int
+	isMatch   map[int]bool
+	// current is the index in matches of the match navigated to with next/previous.
+	current int
+	// displayed are the indexes of the lines displayed, -1 for separators.
+	displayed       []int
+	syntax          bool
 	isFocused       bool
 	isEmpty         bool
 	showSpinner     bool
@@ -45,17 +98,18 @@ type Model struct {
 // New creates a new [Model] with the given width and height.
 func New(width, height int) Model {
 	m := Model{
-		Viewport: viewport.New(width, height),
+		KeyMap: DefaultKeyMap(),
 		viewportStyle: lipgloss.NewStyle().
-			BorderStyle(lipgloss.NormalBorder()).
-			BorderForeground(baseColor),
+			Border(lipgloss.NormalBorder()).
+			BorderForeground(theme.Current().Base),
 		showFilter: false,
+		syntax:     true,
 	}
 	m.textInput = textinput.New()
-	m.textInput.Placeholder = "Search apex log..."
+	m.textInput.Placeholder = "Filter apex log, e.g. debug -heap OR /exception.*null/"
 	m.textInputStyle = lipgloss.NewStyle().
-		BorderStyle(lipgloss.NormalBorder()).
-		BorderForeground(baseColor).
+		Border(lipgloss.NormalBorder()).
+		BorderForeground(theme.Current().Base).
 		Height(1).
 		MaxHeight(4)
 	m.SetWidth(width)
@@ -66,23 +120,55 @@ func New(width, height int) Model {
 func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		if !m.isFocused {
+			return m, nil
+		}
+		if m.Typing() {
+			return m.updateTextInput(msg)
+		}
+
 		switch {
-		case key.Matches(msg, keys.Slash):
+		case key.Matches(msg, m.KeyMap.Slash):
 			m.showFilter = true
-			m.textInput.Focus()
 			m.SetHeight(m.containerHeight)
-			return m, textinput.Blink
+			return m, m.textInput.Focus()
+		case key.Matches(msg, m.KeyMap.Syntax):
+			m.syntax = !m.syntax
+			return m, nil
+		case key.Matches(msg, m.KeyMap.Select):
+			m.selectStart = m.CurrentLine()
+			m.selecting = !m.selecting && m.selectStart >= 0
+			return m, nil
+		}
 
-		case key.Matches(msg, keys.Enter):
-			if m.showFilter {
-				m.Viewport.SetContent(m.filterContent(m.textInput.Value()))
-				m.textInput.Blur()
-				return m, nil
-			}
-		case key.Matches(msg, keys.Esc):
-			m.showFilter = false
-			m.textInput.Blur()
-			m.SetHeight(m.containerHeight)
+		// The keys below only act on the open filter.
+		if !m.showFilter {
+			break
+		}
+		switch {
+		case key.Matches(msg, m.KeyMap.Esc):
+			m.closeFilter()
+			return m, nil
+		case key.Matches(msg, m.KeyMap.NextMatch):
+			m.gotoMatch(m.current + 1)
+			return m, nil
+		case key.Matches(msg, m.KeyMap.PrevMatch):
+			m.gotoMatch(m.current - 1)
+			return m, nil
+		case key.Matches(msg, m.KeyMap.ToggleMode):
+			m.mode = m.mode.toggle()
+			m.applyFilter()
+			m.gotoMatch(m.current)
+			return m, nil
+		case key.Matches(msg, m.KeyMap.MoreContext):
+			m.contextLines++
+			m.applyFilter()
+			m.gotoMatch(m.current)
+			return m, nil
+		case key.Matches(msg, m.KeyMap.LessContext):
+			m.contextLines = max(m.contextLines-1, 0)
+			m.applyFilter()
+			m.gotoMatch(m.current)
 			return m, nil
 		}
 	case spinner.TickMsg:
@@ -91,23 +177,71 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 		return m, cmd
 	}
 
+	if !m.isFocused {
+		return m, nil
+	}
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		m.scroll(msg)
+	}
 	var cmd tea.Cmd
-	var cmds []tea.Cmd
+	m.textInput, cmd = m.textInput.Update(msg)
+	return m, cmd
+}
 
-	if m.isFocused {
-		m.Viewport, cmd = m.Viewport.Update(msg)
-		cmds = append(cmds, cmd)
-		m.textInput, cmd = m.textInput.Update(msg)
-		cmds = append(cmds, cmd)
+// scroll moves the cursor with the scrolling keys. The pages scroll the
+// visible lines along with the cursor, so it can still reach the last lines
+// once the content cannot scroll further.
+func (m *Model) scroll(msg tea.KeyMsg) {
+	switch {
+	case key.Matches(msg, m.KeyMap.PageDown):
+		m.scrollBy(m.height)
+	case key.Matches(msg, m.KeyMap.PageUp):
+		m.scrollBy(-m.height)
+	case key.Matches(msg, m.KeyMap.HalfPageDown):
+		m.scrollBy(m.height / 2)
+	case key.Matches(msg, m.KeyMap.HalfPageUp):
+		m.scrollBy(-m.height / 2)
+	case key.Matches(msg, m.KeyMap.Down):
+		m.setCursor(m.cursor + 1)
+	case key.Matches(msg, m.KeyMap.Up):
+		m.setCursor(m.cursor - 1)
 	}
+}
 
-	return m, tea.Batch(cmds...)
+func (m *Model) scrollBy(n int) {
+	cursor := m.cursor + n
+	m.SetYOffset(m.yOffset + n)
+	m.setCursor(cursor)
+}
+
+// updateTextInput sends the key to the filter box, applying the filter as it is typed.
+func (m Model) updateTextInput(msg tea.KeyMsg) (Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, m.KeyMap.Enter):
+		m.textInput.Blur()
+		return m, nil
+	case key.Matches(msg, m.KeyMap.Esc):
+		m.closeFilter()
+		return m, nil
+	}
+
+	prev := m.textInput.Value()
+	var cmd tea.Cmd
+	m.textInput, cmd = m.textInput.Update(msg)
+	if m.textInput.Value() != prev {
+		m.setFilter(m.textInput.Value())
+	}
+	return m, cmd
 }
 
 func (m Model) View() string {
 	if !m.isEmpty && !m.showSpinner {
-		v := m.viewportStyle.Render(m.Viewport.View())
-		ti := m.textInputStyle.Render(m.textInput.View())
+		content := m.contentView()
+		if len(m.truncation) > 0 {
+			content = lipgloss.JoinVertical(lipgloss.Left, m.truncationBanner(), content)
+		}
+		v := m.viewportStyle.Render(content)
+		ti := m.textInputStyle.Render(lipgloss.JoinHorizontal(lipgloss.Top, m.textInput.View(), m.filterInfo()))
 		if m.showFilter {
 			return lipgloss.JoinVertical(lipgloss.Left, v, ti)
 		} else {
@@ -125,10 +259,325 @@ func (m Model) View() string {
 	return m.viewportStyle.Render(style.Render(emptyMsg))
 }
 
+// contentView renders the visible lines, padded or truncated to the size of the model.
+func (m Model) contentView() string {
+	st := currentStyles()
+	current := -1
+	if len(m.matches) > 0 {
+		current = m.matches[m.current]
+	}
+	selStart, selEnd, selected := m.Selection()
+	cursor := -1
+	if m.isFocused && m.cursor < len(m.displayed) {
+		cursor = m.displayed[m.cursor]
+	}
+
+	end := min(m.yOffset+m.height, len(m.displayed))
+	lines := make([]string, 0, max(end-m.yOffset, 0))
+	for _, i := range m.displayed[min(m.yOffset, end):end] {
+		if i < 0 {
+			lines = append(lines, separatorLine)
+			continue
+		}
+
+		line := m.lines[i]
+		var highlights [][2]int
+		if m.isMatch[i] {
+			highlights = m.expr.Highlights(line)
+		}
+		style := st.match
+		if i == current {
+			style = st.currentMatch
+		}
+		var l string
+		switch {
+		case selected && i >= selStart && i <= selEnd:
+			l = st.selection.Styled(line)
+		case i == cursor:
+			l = st.renderCursorLine(line, m.syntax, highlights, style)
+		default:
+			l = st.renderLine(line, m.syntax, highlights, style)
+		}
+		if m.bookmarks[i] {
+			l = st.bookmark.Styled(bookmarkMark) + l
+		}
+		// Long lines are cut instead of wrapped, so every line takes a
+		// single row and the cursor stays visible.
+		lines = append(lines, ansi.Truncate(l, m.width, ""))
+	}
+
+	return lipgloss.NewStyle().
+		Width(m.width).
+		Height(m.height).
+		MaxHeight(m.height).
+		MaxWidth(m.width).
+		Render(strings.Join(lines, "\n"))
+}
+
+// truncationBanner warns that the log is incomplete and how to avoid it.
+func (m Model) truncationBanner() string {
+	var skipped int
+	for _, n := range m.truncation {
+		skipped += n
+	}
+	s := "Truncated log: " + truncationHint
+	if skipped > 0 {
+		s = fmt.Sprintf("Truncated log, %.1f MB skipped: %s", float64(skipped)/(1<<20), truncationHint)
+	}
+	return lipgloss.NewStyle().
+		Foreground(theme.Current().Warning).
+		Width(m.width).
+		Render(text.Truncate(s, m.width))
+}
+
+// Truncated reports whether Salesforce truncated the log because it exceeded the maximum size.
+func (m Model) Truncated() bool {
+	return len(m.truncation) > 0
+}
+
+// SetContent replaces the content, keeping the filter applied.
 func (m *Model) SetContent(s string) {
-	m.content = s
-	m.isEmpty = s == ""
-	m.Viewport.SetContent(s)
+	m.lines = nil
+	m.lineEvents = nil
+	m.truncation = nil
+	m.selecting = false
+	m.current = 0
+	m.yOffset = 0
+	m.cursor = 0
+	m.isEmpty = true
+	m.SetHeight(m.containerHeight)
+	m.applyFilter()
+	m.AppendContent(s)
+}
+
+// AppendContent adds s to the end of the content, keeping the filter applied.
+// The content can be appended in parts of any size as it is downloaded, even
+// if they split lines.
+func (m *Model) AppendContent(s string) {
+	if s == "" {
+		return
+	}
+	parts := strings.Split(s, "\n")
+
+	// The first part completes the last line.
+	start := max(len(m.lines)-1, 0)
+	if len(m.lines) > 0 {
+		m.lines[start] += parts[0]
+		parts = parts[1:]
+	}
+	m.lines = append(m.lines, parts...)
+	// The carriage return of a Windows line ending is only removed once its
+	// line is complete, since it may be appended apart from its line feed.
+	for i := start; i < len(m.lines)-1; i++ {
+		m.lines[i] = strings.TrimSuffix(m.lines[i], "\r")
+	}
+
+	var prev string
+	if start > 0 {
+		prev = m.lineEvents[start-1]
+	}
+	m.lineEvents = append(m.lineEvents[:start], lineEvents(prev, m.lines[start:])...)
+	m.isEmpty = false
+	m.findTruncation(start)
+	m.filterFrom(start)
+}
+
+// findTruncation looks for the truncation markers from the given line on.
+// The banner is made room for when the first one is found.
+func (m *Model) findTruncation(start int) {
+	wasTruncated := m.Truncated()
+	delete(m.truncation, start)
+	for i := start; i < len(m.lines); i++ {
+		if skipped, ok := apexlog.ParseTruncation(m.lines[i]); ok {
+			if m.truncation == nil {
+				m.truncation = map[int]int{}
+			}
+			m.truncation[i] = skipped
+		}
+	}
+	if m.Truncated() != wasTruncated {
+		m.SetHeight(m.containerHeight)
+	}
+}
+
+// GotoLine scrolls the content so the given zero based line is at the top,
+// moving the cursor to it.
+// An applied filter is cleared, since line numbers refer to the whole content.
+func (m *Model) GotoLine(n int) {
+	if m.showFilter {
+		m.closeFilter()
+	}
+	m.scrollTo(m.displayIndex(n))
+}
+
+// ScrollToLine scrolls the content so the given zero based line is at the top,
+// moving the cursor to it and keeping the filter applied if the line is
+// displayed with it.
+func (m *Model) ScrollToLine(n int) {
+	for pos, i := range m.displayed {
+		if i == n {
+			m.scrollTo(pos)
+			return
+		}
+	}
+	m.GotoLine(n)
+}
+
+// scrollTo scrolls the content so the displayed line n is at the top, or
+// visible if the content cannot scroll that far, and moves the cursor to it.
+func (m *Model) scrollTo(n int) {
+	m.SetYOffset(n)
+	m.setCursor(n)
+}
+
+// setCursor moves the cursor to the displayed line n, scrolling the content
+// as little as possible to keep it visible.
+func (m *Model) setCursor(n int) {
+	m.cursor = max(min(n, len(m.displayed)-1), 0)
+	if m.cursor < m.yOffset {
+		m.SetYOffset(m.cursor)
+	} else if m.cursor >= m.yOffset+m.height {
+		m.SetYOffset(m.cursor - m.height + 1)
+	}
+}
+
+// CurrentLine returns the zero based line under the cursor, or the line after
+// it when the cursor is on a separator between matches. It returns -1 when no
+// line is displayed.
+func (m Model) CurrentLine() int {
+	for _, i := range m.displayed[min(m.cursor, len(m.displayed)):] {
+		if i >= 0 {
+			return i
+		}
+	}
+	return -1
+}
+
+// TopLine returns the zero based line at the top of the viewport, or -1 when
+// no line is displayed.
+func (m Model) TopLine() int {
+	for _, i := range m.displayed[min(m.yOffset, len(m.displayed)):] {
+		if i >= 0 {
+			return i
+		}
+	}
+	return -1
+}
+
+// Line returns the text of the given zero based line.
+func (m Model) Line(n int) string {
+	if n < 0 || n >= len(m.lines) {
+		return ""
+	}
+	return m.lines[n]
+}
+
+// Selection returns the zero based range [start, end] of the selected lines,
+// which goes from the line the selection started at to the current line.
+func (m Model) Selection() (start, end int, ok bool) {
+	if !m.selecting {
+		return 0, 0, false
+	}
+	cur := max(m.CurrentLine(), 0)
+	return min(m.selectStart, cur), max(m.selectStart, cur), true
+}
+
+// SelectedContent returns the text of the selected lines and their number.
+// Only the displayed lines are included, not the ones hidden by the filter or
+// by their event type.
+func (m Model) SelectedContent() (string, int) {
+	start, end, ok := m.Selection()
+	if !ok {
+		return "", 0
+	}
+	var lines []string
+	for _, i := range m.displayed {
+		if i >= start && i <= end {
+			lines = append(lines, m.lines[i])
+		}
+	}
+	return strings.Join(lines, "\n"), len(lines)
+}
+
+// ClearSelection cancels the selection.
+func (m *Model) ClearSelection() {
+	m.selecting = false
+}
+
+// DisplayedContent returns the text of the displayed lines without syntax
+// highlighting, like the matching lines and their context while filtering.
+func (m Model) DisplayedContent() string {
+	var b strings.Builder
+	for _, i := range m.displayed {
+		if i < 0 {
+			b.WriteString(separatorLine)
+		} else {
+			b.WriteString(m.lines[i])
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// Lines returns the lines of the content, which must not be modified.
+func (m Model) Lines() []string {
+	return m.lines
+}
+
+// SetBookmarks marks the given zero based lines as bookmarked.
+func (m *Model) SetBookmarks(lines []int) {
+	m.bookmarks = make(map[int]bool, len(lines))
+	for _, l := range lines {
+		m.bookmarks[l] = true
+	}
+}
+
+// SetYOffset scrolls the content so the displayed line n is at the top.
+// The content never scrolls past its last line, and the cursor is moved
+// along if it is no longer visible.
+func (m *Model) SetYOffset(n int) {
+	m.yOffset = max(min(n, m.maxYOffset()), 0)
+	m.cursor = max(min(m.cursor, m.yOffset+m.height-1, len(m.displayed)-1), m.yOffset, 0)
+}
+
+// YOffset returns the index of the first visible line among the displayed ones.
+func (m Model) YOffset() int {
+	return m.yOffset
+}
+
+// TotalLineCount returns the number of lines displayed with the current filter.
+func (m Model) TotalLineCount() int {
+	return len(m.displayed)
+}
+
+func (m Model) maxYOffset() int {
+	return max(len(m.displayed)-m.height, 0)
+}
+
+// SetHiddenEvents hides the lines of the given event types.
+func (m *Model) SetHiddenEvents(types []string) {
+	m.hiddenEvents = make(map[string]bool, len(types))
+	for _, t := range types {
+		m.hiddenEvents[t] = true
+	}
+	m.applyFilter()
+	m.gotoMatch(m.current)
+}
+
+// EventCounts returns the number of events of every event type in the content.
+func (m Model) EventCounts() map[string]int {
+	counts := map[string]int{}
+	for i, l := range m.lines {
+		if _, ok := apexlog.ParseLine(l); ok {
+			counts[m.lineEvents[i]]++
+		}
+	}
+	return counts
+}
+
+// Typing reports whether the key presses are sent to the filter box.
+func (m Model) Typing() bool {
+	return m.isFocused && m.showFilter && m.textInput.Focused()
 }
 
 func (m *Model) StartSpinner() tea.Cmd {
@@ -144,65 +593,76 @@ func (m *Model) StopSpinner() {
 
 func (m *Model) Focus() {
 	m.isFocused = true
-	m.viewportStyle = m.viewportStyle.BorderForeground(focusedColor)
-	m.textInputStyle = m.textInputStyle.BorderForeground(focusedColor)
+	m.viewportStyle = m.viewportStyle.BorderForeground(theme.Current().Focused)
+	m.textInputStyle = m.textInputStyle.BorderForeground(theme.Current().Focused)
 	if m.showFilter {
 		m.textInput.Focus()
 	}
 }
 
+func (m Model) Focused() bool {
+	return m.isFocused
+}
+
 func (m *Model) Blur() {
 	m.isFocused = false
-	m.viewportStyle = m.viewportStyle.BorderForeground(baseColor)
-	m.textInputStyle = m.textInputStyle.BorderForeground(baseColor)
+	m.viewportStyle = m.viewportStyle.BorderForeground(theme.Current().Base)
+	m.textInputStyle = m.textInputStyle.BorderForeground(theme.Current().Base)
 	m.textInput.Blur()
 }
 
+// SetWidth sets the total width of the model, including its border.
 func (m *Model) SetWidth(w int) {
-	m.Width = w - 2
-	m.viewportStyle = m.viewportStyle.Width(w - 2).MaxWidth(w)
-	m.textInput.Width = w - 5
-	m.textInputStyle = m.textInputStyle.Width(w - 2).MaxWidth(w)
+	wc := w - m.viewportStyle.GetHorizontalFrameSize()
+	m.width = wc
+	m.viewportStyle = m.viewportStyle.Width(wc).MaxWidth(w)
+	// The text input prompt, its cursor and the filter info take the remaining columns.
+	wti := w - m.textInputStyle.GetHorizontalFrameSize()
+	m.infoWidth = min(maxInfoWidth, wti/3)
+	m.textInput.Width = max(wti-3-m.infoWidth, 1)
+	m.textInputStyle = m.textInputStyle.Width(wc).MaxWidth(w)
 }
 
+// SetHeight sets the total height of the model, including its border and the filter box.
 func (m *Model) SetHeight(h int) {
 	m.containerHeight = h
-	hm := h - 3
-	hti := m.getTextInputWidth()
-	m.Height = hm - hti
+	hm := h - m.viewportStyle.GetVerticalFrameSize()
+	hti := m.getTextInputHeight()
+	m.height = hm - hti
+	if m.Truncated() {
+		m.height--
+	}
 	m.viewportStyle = m.viewportStyle.Height(hm - hti).MaxHeight(h - hti)
+	if m.height > 0 {
+		m.setCursor(m.cursor)
+	}
 }
 
-func (m Model) getTextInputWidth() int {
+func (m Model) getTextInputHeight() int {
 	if m.showFilter {
-		return 3
+		return 1 + m.textInputStyle.GetVerticalFrameSize()
 	}
 	return 0
 }
 
 func (m Model) centerMsgStyle() lipgloss.Style {
-	w, h := m.Width, m.Height
-	if sw := m.Style.GetWidth(); sw != 0 {
-		w = min(w, sw)
-	}
-	if sh := m.Style.GetHeight(); sh != 0 {
-		h = min(h, sh)
-	}
-
 	return lipgloss.NewStyle().
-		Width(w).
-		Height(h).
+		Width(m.width).
+		Height(m.height).
 		Align(lipgloss.Center, lipgloss.Center)
 }
 
-func (m Model) filterContent(s string) string {
-	var b strings.Builder
-	lines := strings.Split(m.content, "\n")
-	for _, line := range lines {
-		if strings.Contains(line, s) {
-			b.WriteString(line)
-			b.WriteString("\n")
+// lineEvents returns the event type of every line, prev being the event
+// type of the line before them. The header lines before the first event have
+// no event type.
+func lineEvents(prev string, lines []string) []string {
+	events := make([]string, len(lines))
+	current := prev
+	for i, line := range lines {
+		if l, ok := apexlog.ParseLine(line); ok {
+			current = l.EventType(line)
 		}
+		events[i] = current
 	}
-	return b.String()
+	return events
 }
@@ -0,0 +1,139 @@
+package viewport
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAppendContent(t *testing.T) {
+	content := "61.0 APEX_CODE,FINEST\n12:00:00.0 (1)|USER_DEBUG|[1]|DEBUG|multi\nline\n12:00:00.0 (2)|HEAP_ALLOCATE|[2]"
+
+	want := New(80, 20)
+	want.SetContent(content)
+
+	// Every split must give the same lines, even in the middle of an event or
+	// of a Windows line ending.
+	for _, c := range []string{content, strings.ReplaceAll(content, "\n", "\r\n")} {
+		for i := range len(c) {
+			m := New(80, 20)
+			m.SetContent(c[:i])
+			m.AppendContent(c[i:])
+			if !reflect.DeepEqual(m.lines, want.lines) || !reflect.DeepEqual(m.lineEvents, want.lineEvents) {
+				t.Fatalf("split at %d: got lines %q events %q, want %q %q", i, m.lines, m.lineEvents, want.lines, want.lineEvents)
+			}
+		}
+	}
+}
+
+func TestAppendContentFiltered(t *testing.T) {
+	lines := []string{
+		"61.0 APEX_CODE,FINEST",
+		"12:00:00.0 (1)|USER_DEBUG|[1]|DEBUG|first",
+		"12:00:00.0 (2)|HEAP_ALLOCATE|[2]|Bytes:3",
+		"12:00:00.0 (3)|STATEMENT_EXECUTE|[3]",
+		"12:00:00.0 (4)|STATEMENT_EXECUTE|[4]",
+		"12:00:00.0 (5)|USER_DEBUG|[5]|DEBUG|second",
+		"continued",
+		"12:00:00.0 (6)|STATEMENT_EXECUTE|[6]",
+		"12:00:00.0 (7)|HEAP_ALLOCATE|[7]|Bytes:4",
+		"12:00:00.0 (8)|USER_DEBUG|[8]|DEBUG|third",
+	}
+	content := strings.Join(lines, "\n")
+
+	for _, mode := range []filterMode{modeFilter, modeHighlight} {
+		for _, n := range []int{0, 1, 2} {
+			setup := func() Model {
+				m := New(80, 20)
+				m.mode = mode
+				m.contextLines = n
+				m.SetHiddenEvents([]string{"HEAP_ALLOCATE"})
+				m.setFilter("debug")
+				return m
+			}
+			want := setup()
+			want.SetContent(content)
+
+			// Appending in chunks must display the same lines as the whole content.
+			for size := 1; size < len(content); size += 7 {
+				m := setup()
+				m.SetContent("")
+				for i := 0; i < len(content); i += size {
+					m.AppendContent(content[i:min(i+size, len(content))])
+				}
+				if !reflect.DeepEqual(m.displayed, want.displayed) || !reflect.DeepEqual(m.matches, want.matches) {
+					t.Fatalf("%v mode, %d context lines, chunks of %d: got displayed %v matches %v, want %v %v",
+						mode, n, size, m.displayed, m.matches, want.displayed, want.matches)
+				}
+			}
+		}
+	}
+}
+
+func TestRendersVisibleLines(t *testing.T) {
+	lines := make([]string, 1000)
+	for i := range lines {
+		lines[i] = "line"
+	}
+	lines[500] = "middle"
+
+	m := New(40, 12)
+	m.SetContent(strings.Join(lines, "\n"))
+	m.GotoLine(500)
+	if !strings.HasPrefix(strings.Split(m.View(), "\n")[1], "│middle") {
+		t.Errorf("expected line 500 at the top:\n%s", m.View())
+	}
+
+	m.SetYOffset(5000)
+	if m.YOffset() != 1000-m.height {
+		t.Errorf("expected the scroll to stop at the last line, got offset %d", m.YOffset())
+	}
+}
+
+func TestCursor(t *testing.T) {
+	lines := make([]string, 100)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("line %d", i)
+	}
+	m := New(40, 12)
+	m.SetContent(strings.Join(lines, "\n"))
+
+	// The cursor moves past the top of the viewport to reach the last lines.
+	m.GotoLine(95)
+	if m.YOffset() != 100-m.height || m.CurrentLine() != 95 {
+		t.Errorf("expected line 95 under the cursor on the last page, got offset %d line %d", m.YOffset(), m.CurrentLine())
+	}
+	m.scrollBy(m.height)
+	if m.CurrentLine() != 99 {
+		t.Errorf("expected the cursor on the last line, got line %d", m.CurrentLine())
+	}
+
+	// Scrolling keeps the cursor visible.
+	m.SetYOffset(10)
+	if cur := m.CurrentLine(); cur != 10+m.height-1 {
+		t.Errorf("expected the cursor on the last visible line, got line %d", cur)
+	}
+	m.setCursor(5)
+	if m.YOffset() != 5 || m.CurrentLine() != 5 {
+		t.Errorf("expected the content to scroll up to the cursor, got offset %d line %d", m.YOffset(), m.CurrentLine())
+	}
+}
+
+func TestLongLinesAreNotWrapped(t *testing.T) {
+	lines := make([]string, 30)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("12:00:00.0 (%d)|VARIABLE_ASSIGNMENT|[%d]|value|%s", i, i, strings.Repeat("x", 100))
+	}
+	m := New(40, 12)
+	m.SetContent(strings.Join(lines, "\n"))
+	m.GotoLine(29)
+
+	v := m.View()
+	if n := strings.Count(v, "\n") + 1; n != 12 {
+		t.Errorf("expected 12 rows, got %d:\n%s", n, v)
+	}
+	if !strings.Contains(v, "(29)") {
+		t.Errorf("expected the line under the cursor to be visible:\n%s", v)
+	}
+}
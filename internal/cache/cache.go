@@ -0,0 +1,282 @@
+// Package cache stores apex logs on disk, so they can be opened without
+// downloading them again and browsed after Salesforce deletes them.
+//
+// Every org has its own directory with the following layout:
+//
+//	logs.json          metadata of the cached ApexLog records
+//	logs/<id>          body of the ApexLog record with the given id
+//	bookmarks/<id>     bookmarks of the log with the given id, as JSON
+//
+// Files are written atomically, so a crash never leaves a partial file behind,
+// but the temporary .tmp-* files of a process killed while writing are not
+// cleaned up.
+package cache
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"sync"
+
+	"github.com/cdelmoral/apexlogs/internal/fsutil"
+	sf "github.com/cdelmoral/apexlogs/internal/salesforce"
+)
+
+const (
+	logsFile     = "logs.json"
+	bodyDir      = "logs"
+	bookmarksDir = "bookmarks"
+)
+
+var unsafeChars = regexp.MustCompile(`[^A-Za-z0-9@._-]+`)
+
+// ErrNotCached is returned when the body of a log is not in the cache.
+var ErrNotCached = errors.New("log not found in the local cache")
+
+// A Bookmark marks a line of a log to come back to it.
+type Bookmark struct {
+	// Line is the zero based index of the line in the log.
+	Line int `json:"line"`
+	// Text is the text of the line, to list the bookmarks without the log.
+	Text string `json:"text"`
+	Note string `json:"note,omitempty"`
+}
+
+// A Cache is the local cache of the logs of a single org.
+// It is safe for concurrent use.
+type Cache struct {
+	mu  sync.Mutex
+	dir string
+}
+
+// DefaultDir returns the default root directory of the cache.
+func DefaultDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "apexlogs"), nil
+}
+
+// Open opens the cache of the given org inside root, creating it if it does not exist.
+// The org is usually identified by the username used to connect to it.
+func Open(root, org string) (*Cache, error) {
+	if org == "" {
+		return nil, fmt.Errorf("cannot open the cache of an unknown org")
+	}
+
+	dir := filepath.Join(root, unsafeChars.ReplaceAllString(org, "_"))
+	if err := os.MkdirAll(filepath.Join(dir, bodyDir), 0o755); err != nil {
+		return nil, fmt.Errorf("error creating cache directory: %w", err)
+	}
+
+	return &Cache{dir: dir}, nil
+}
+
+// Dir returns the directory of the cache.
+func (c *Cache) Dir() string {
+	return c.dir
+}
+
+// Logs returns the metadata of the cached logs, most recent first.
+func (c *Cache) Logs() ([]sf.ApexLog, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.readLogs()
+}
+
+// SaveLogs adds the metadata of the given logs to the cache.
+// Logs that are already cached are updated.
+func (c *Cache) SaveLogs(logs []sf.ApexLog) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cached, err := c.readLogs()
+	if err != nil {
+		return err
+	}
+
+	byId := make(map[string]int, len(cached))
+	for i, l := range cached {
+		byId[l.ID] = i
+	}
+	for _, l := range logs {
+		if i, ok := byId[l.ID]; ok {
+			cached[i] = l
+			continue
+		}
+		byId[l.ID] = len(cached)
+		cached = append(cached, l)
+	}
+	sortLogs(cached)
+
+	b, err := json.Marshal(cached)
+	if err != nil {
+		return fmt.Errorf("error serializing cached logs: %w", err)
+	}
+	return writeFile(filepath.Join(c.dir, logsFile), b)
+}
+
+// HasBody reports whether the body of the log with the given id is cached.
+func (c *Cache) HasBody(id string) bool {
+	_, err := os.Stat(c.bodyPath(id))
+	return err == nil
+}
+
+// Body returns the cached body of the log with the given id.
+// [ErrNotCached] is returned if the body is not cached.
+func (c *Cache) Body(id string) (string, error) {
+	b, err := os.ReadFile(c.bodyPath(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return "", ErrNotCached
+	}
+	if err != nil {
+		return "", fmt.Errorf("error reading cached log: %w", err)
+	}
+	return string(b), nil
+}
+
+// OpenBody opens the cached body of the log with the given id for reading,
+// returning its size. [ErrNotCached] is returned if the body is not cached.
+func (c *Cache) OpenBody(id string) (io.ReadCloser, int64, error) {
+	f, err := os.Open(c.bodyPath(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, 0, ErrNotCached
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("error opening cached log: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, 0, fmt.Errorf("error opening cached log: %w", err)
+	}
+	return f, info.Size(), nil
+}
+
+// SaveBody stores the body of the log with the given id.
+func (c *Cache) SaveBody(id, body string) error {
+	return writeFile(c.bodyPath(id), []byte(body))
+}
+
+// CreateBody starts storing the body of the log with the given id while it is
+// written, without holding it in memory. The body is only cached once
+// [BodyWriter.Commit] is called.
+func (c *Cache) CreateBody(id string) (*BodyWriter, error) {
+	f, err := fsutil.Create(c.bodyPath(id))
+	if err != nil {
+		return nil, fmt.Errorf("error creating cache file: %w", err)
+	}
+	return &BodyWriter{f: f}, nil
+}
+
+// A BodyWriter writes the body of a log to the cache, see [Cache.CreateBody].
+type BodyWriter struct {
+	f *fsutil.File
+}
+
+func (w *BodyWriter) Write(p []byte) (int, error) {
+	n, err := w.f.Write(p)
+	if err != nil {
+		return n, fmt.Errorf("error writing cache file: %w", err)
+	}
+	return n, nil
+}
+
+// Commit stores the body written so far in the cache.
+func (w *BodyWriter) Commit() error {
+	if err := w.f.Commit(); err != nil {
+		return fmt.Errorf("error writing cache file: %w", err)
+	}
+	return nil
+}
+
+// Discard drops the body written so far, leaving the cache unchanged.
+func (w *BodyWriter) Discard() {
+	w.f.Discard()
+}
+
+// Bookmarks returns the bookmarks of the log with the given id, sorted by line.
+// Logs without bookmarks return none.
+func (c *Cache) Bookmarks(id string) ([]Bookmark, error) {
+	b, err := os.ReadFile(c.bookmarksPath(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading bookmarks: %w", err)
+	}
+
+	var bs []Bookmark
+	if err := json.Unmarshal(b, &bs); err != nil {
+		return nil, fmt.Errorf("error parsing bookmarks: %w", err)
+	}
+	sort.SliceStable(bs, func(i, j int) bool {
+		return bs[i].Line < bs[j].Line
+	})
+	return bs, nil
+}
+
+// SaveBookmarks replaces the bookmarks of the log with the given id.
+func (c *Cache) SaveBookmarks(id string, bs []Bookmark) error {
+	if len(bs) == 0 {
+		err := os.Remove(c.bookmarksPath(id))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("error removing bookmarks: %w", err)
+		}
+		return nil
+	}
+
+	b, err := json.MarshalIndent(bs, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error serializing bookmarks: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Join(c.dir, bookmarksDir), 0o755); err != nil {
+		return fmt.Errorf("error creating bookmarks directory: %w", err)
+	}
+	return writeFile(c.bookmarksPath(id), b)
+}
+
+func (c *Cache) bookmarksPath(id string) string {
+	return filepath.Join(c.dir, bookmarksDir, unsafeChars.ReplaceAllString(id, "_"))
+}
+
+func (c *Cache) bodyPath(id string) string {
+	return filepath.Join(c.dir, bodyDir, unsafeChars.ReplaceAllString(id, "_"))
+}
+
+func (c *Cache) readLogs() ([]sf.ApexLog, error) {
+	b, err := os.ReadFile(filepath.Join(c.dir, logsFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading cached logs: %w", err)
+	}
+
+	var logs []sf.ApexLog
+	if err := json.Unmarshal(b, &logs); err != nil {
+		return nil, fmt.Errorf("error parsing cached logs: %w", err)
+	}
+	sortLogs(logs)
+	return logs, nil
+}
+
+func sortLogs(logs []sf.ApexLog) {
+	sort.SliceStable(logs, func(i, j int) bool {
+		return logs[i].StartTime > logs[j].StartTime
+	})
+}
+
+// writeFile writes a file of the cache atomically.
+func writeFile(path string, b []byte) error {
+	if err := fsutil.WriteFile(path, b); err != nil {
+		return fmt.Errorf("error writing cache file: %w", err)
+	}
+	return nil
+}
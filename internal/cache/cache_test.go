@@ -0,0 +1,183 @@
+package cache
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	sf "github.com/cdelmoral/apexlogs/internal/salesforce"
+)
+
+func TestSaveLogsMergesAndSorts(t *testing.T) {
+	c, err := Open(t.TempDir(), "user@example.com")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	err = c.SaveLogs([]sf.ApexLog{
+		{ID: "a", StartTime: "2024-06-15T10:00:00.000+0000", Status: "Success"},
+		{ID: "b", StartTime: "2024-06-15T12:00:00.000+0000"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	err = c.SaveLogs([]sf.ApexLog{
+		{ID: "a", StartTime: "2024-06-15T10:00:00.000+0000", Status: "Failed"},
+		{ID: "c", StartTime: "2024-06-15T11:00:00.000+0000"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	logs, err := c.Logs()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var ids []string
+	for _, l := range logs {
+		ids = append(ids, l.ID)
+	}
+	if len(ids) != 3 || ids[0] != "b" || ids[1] != "c" || ids[2] != "a" {
+		t.Errorf("unexpected cached logs order: %v", ids)
+	}
+	if logs[2].Status != "Failed" {
+		t.Errorf("expected cached log to be updated, got status %q", logs[2].Status)
+	}
+}
+
+func TestBody(t *testing.T) {
+	c, err := Open(t.TempDir(), "user@example.com")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if _, err := c.Body("07L1"); !errors.Is(err, ErrNotCached) {
+		t.Errorf("expected ErrNotCached, got %v", err)
+	}
+	if c.HasBody("07L1") {
+		t.Errorf("expected body not to be cached")
+	}
+
+	if err := c.SaveBody("07L1", "61.0 APEX_CODE,FINEST"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	body, err := c.Body("07L1")
+	if err != nil || body != "61.0 APEX_CODE,FINEST" {
+		t.Errorf("unexpected body %q, error %v", body, err)
+	}
+	if !c.HasBody("07L1") {
+		t.Errorf("expected body to be cached")
+	}
+
+	r, size, err := c.OpenBody("07L1")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer r.Close()
+	if b, _ := io.ReadAll(r); string(b) != body || size != int64(len(body)) {
+		t.Errorf("unexpected opened body %q of size %d", b, size)
+	}
+	if _, _, err := c.OpenBody("07L2"); !errors.Is(err, ErrNotCached) {
+		t.Errorf("expected ErrNotCached, got %v", err)
+	}
+}
+
+func TestCreateBody(t *testing.T) {
+	c, err := Open(t.TempDir(), "user@example.com")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	w, err := c.CreateBody("07L1")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	io.WriteString(w, "61.0 APEX_CODE,FINEST\n")
+	if c.HasBody("07L1") {
+		t.Errorf("expected body not to be cached before the commit")
+	}
+	io.WriteString(w, "10:00:00.0 (1)|EXECUTION_STARTED")
+	if err := w.Commit(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if body, err := c.Body("07L1"); err != nil || body != "61.0 APEX_CODE,FINEST\n10:00:00.0 (1)|EXECUTION_STARTED" {
+		t.Errorf("unexpected body %q, error %v", body, err)
+	}
+
+	w, err = c.CreateBody("07L2")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	io.WriteString(w, "61.0 APEX_CODE,FINEST")
+	w.Discard()
+	if c.HasBody("07L2") {
+		t.Errorf("expected a discarded body not to be cached")
+	}
+	entries, err := os.ReadDir(filepath.Join(c.Dir(), bodyDir))
+	if err != nil || len(entries) != 1 {
+		t.Errorf("expected only the committed body to be left, got %v, error %v", entries, err)
+	}
+}
+
+func TestBookmarks(t *testing.T) {
+	c, err := Open(t.TempDir(), "user@example.com")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if bs, err := c.Bookmarks("07L1"); err != nil || len(bs) != 0 {
+		t.Errorf("expected no bookmarks, got %v, error %v", bs, err)
+	}
+
+	saved := []Bookmark{
+		{Line: 12, Text: "USER_DEBUG|[1]|DEBUG|world"},
+		{Line: 3, Text: "USER_DEBUG|[1]|DEBUG|hello", Note: "starts here"},
+	}
+	if err := c.SaveBookmarks("07L1", saved); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	bs, err := c.Bookmarks("07L1")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(bs) != 2 || bs[0] != saved[1] || bs[1] != saved[0] {
+		t.Errorf("expected the bookmarks sorted by line, got %v", bs)
+	}
+
+	if err := c.SaveBookmarks("07L1", nil); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if bs, err := c.Bookmarks("07L1"); err != nil || len(bs) != 0 {
+		t.Errorf("expected the bookmarks to be removed, got %v, error %v", bs, err)
+	}
+}
+
+func TestOpenSeparatesOrgs(t *testing.T) {
+	root := t.TempDir()
+
+	a, err := Open(root, "a@example.com")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	b, err := Open(root, "../b@example.com")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if filepath.Dir(b.Dir()) != root {
+		t.Errorf("expected org directory inside %s, got %s", root, b.Dir())
+	}
+
+	a.SaveBody("07L1", "body")
+	if b.HasBody("07L1") {
+		t.Errorf("expected orgs not to share cached logs")
+	}
+
+	if _, err := Open(root, ""); err == nil {
+		t.Errorf("expected an error for an unknown org")
+	}
+}
@@ -0,0 +1,288 @@
+// Package config reads the settings of apexlogs from JSON files.
+//
+// The settings of the user are read from the config.json file in the apexlogs
+// directory of the user configuration directory, like
+// ~/.config/apexlogs/config.json, and are overridden by the settings of the
+// project in the .apexlogs.json file next to its sfdx-project.json:
+//
+//	{"apiVersion": "62.0", "logLimit": 500, "traceFlagDuration": "2h"}
+//
+// Missing files and settings keep the defaults of the application.
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cdelmoral/apexlogs/internal/project"
+	sf "github.com/cdelmoral/apexlogs/internal/salesforce"
+	"github.com/cdelmoral/apexlogs/internal/theme"
+	"github.com/cdelmoral/apexlogs/internal/traceflag"
+)
+
+const (
+	// FileName is the name of the configuration file of the user.
+	FileName = "config.json"
+	// ProjectFileName is the name of the configuration file of a project.
+	ProjectFileName = ".apexlogs.json"
+
+	// MinListWidth and MaxListWidth bound the width of the list of logs.
+	MinListWidth = 30
+	MaxListWidth = 200
+)
+
+var (
+	apiVersionRe = regexp.MustCompile(`^\d{2,}\.0$`)
+	// debugLevelRe matches the developer names Salesforce accepts.
+	debugLevelRe = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*$`)
+)
+
+// Config is the settings read from the configuration files.
+// Settings that are not set have their zero value.
+type Config struct {
+	// ApiVersion is the version of the Salesforce API, like "61.0".
+	ApiVersion string `json:"apiVersion"`
+	// DebugLevel is the developer name of the debug level of the trace flag,
+	// which is created if it does not exist.
+	DebugLevel string `json:"debugLevel"`
+	// LogLimit is the number of logs listed.
+	LogLimit int `json:"logLimit"`
+	// ListWidth is the width of the list of logs and the other left panels.
+	ListWidth         int      `json:"listWidth"`
+	TraceFlagDuration Duration `json:"traceFlagDuration"`
+	TraceFlagCleanup  string   `json:"traceFlagCleanup"`
+	// Timeout is the maximum duration of a request to the org, and
+	// BodyTimeout of a request downloading the body of a log.
+	Timeout     Duration `json:"timeout"`
+	BodyTimeout Duration `json:"bodyTimeout"`
+	// CacheDir is nil when not set, since an empty directory disables the cache.
+	CacheDir    *string `json:"cacheDir"`
+	DownloadDir string  `json:"downloadDir"`
+	// KeyProfile is the set of key bindings the Keys are applied to, like "vim".
+	KeyProfile string `json:"keyProfile"`
+	// Keys maps the names of actions to the keys bound to them, an empty list
+	// unbinding the action.
+	Keys map[string][]string `json:"keys"`
+	// Theme is the name of a built-in theme or of one of Themes, see [theme.Resolve].
+	Theme  string                 `json:"theme"`
+	Themes map[string]theme.Theme `json:"themes"`
+}
+
+// A Duration is a [time.Duration] written like "30m" or "2h".
+type Duration time.Duration
+
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return fmt.Errorf("expected a duration like \"30m\", got %s", b)
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// DefaultPath returns the path of the configuration file of the user.
+func DefaultPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "apexlogs", FileName), nil
+}
+
+// Load reads the configuration file of the user at path and the one of the
+// project dir is in, which overrides it. Missing files are skipped, and path
+// is skipped when empty.
+// An error is returned if a file cannot be read or its settings are not valid.
+func Load(path, dir string) (Config, error) {
+	var c Config
+	if path != "" {
+		user, err := Read(path)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return c, err
+		}
+		c = user
+	}
+
+	p, err := project.Find(dir)
+	if errors.Is(err, project.ErrNotFound) {
+		return c, nil
+	}
+	if err != nil {
+		return c, err
+	}
+	proj, err := Read(filepath.Join(p.Root, ProjectFileName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return c, err
+	}
+	return c.Merge(proj), nil
+}
+
+// Read reads and validates a single configuration file.
+// Unknown settings are reported as errors, to catch typos. A leading ~ in the
+// directories is replaced with the home directory.
+func Read(path string) (Config, error) {
+	var c Config
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return c, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&c); err != nil {
+		return Config{}, fmt.Errorf("error reading %s: %w", path, err)
+	}
+	if err := c.Validate(); err != nil {
+		return Config{}, fmt.Errorf("invalid configuration in %s: %w", path, err)
+	}
+	if c.CacheDir != nil {
+		dir := expandHome(*c.CacheDir)
+		c.CacheDir = &dir
+	}
+	c.DownloadDir = expandHome(c.DownloadDir)
+	return c, nil
+}
+
+// expandHome replaces a leading ~ in path with the home directory of the user.
+func expandHome(path string) string {
+	rest, ok := strings.CutPrefix(path, "~")
+	if !ok || (rest != "" && rest[0] != '/' && rest[0] != filepath.Separator) {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, rest)
+}
+
+// Merge returns c with the settings set in o replacing its own.
+func (c Config) Merge(o Config) Config {
+	if o.ApiVersion != "" {
+		c.ApiVersion = o.ApiVersion
+	}
+	if o.DebugLevel != "" {
+		c.DebugLevel = o.DebugLevel
+	}
+	if o.LogLimit != 0 {
+		c.LogLimit = o.LogLimit
+	}
+	if o.ListWidth != 0 {
+		c.ListWidth = o.ListWidth
+	}
+	if o.TraceFlagDuration != 0 {
+		c.TraceFlagDuration = o.TraceFlagDuration
+	}
+	if o.TraceFlagCleanup != "" {
+		c.TraceFlagCleanup = o.TraceFlagCleanup
+	}
+	if o.Timeout != 0 {
+		c.Timeout = o.Timeout
+	}
+	if o.BodyTimeout != 0 {
+		c.BodyTimeout = o.BodyTimeout
+	}
+	if o.CacheDir != nil {
+		c.CacheDir = o.CacheDir
+	}
+	if o.DownloadDir != "" {
+		c.DownloadDir = o.DownloadDir
+	}
+	if o.KeyProfile != "" {
+		c.KeyProfile = o.KeyProfile
+	}
+	if len(o.Keys) > 0 {
+		// The actions are merged one by one, so a project can remap a single key.
+		keys := make(map[string][]string, len(c.Keys)+len(o.Keys))
+		for action, ks := range c.Keys {
+			keys[action] = ks
+		}
+		for action, ks := range o.Keys {
+			keys[action] = ks
+		}
+		c.Keys = keys
+	}
+	if o.Theme != "" {
+		c.Theme = o.Theme
+	}
+	if len(o.Themes) > 0 {
+		themes := make(map[string]theme.Theme, len(c.Themes)+len(o.Themes))
+		for name, t := range c.Themes {
+			themes[name] = t
+		}
+		for name, t := range o.Themes {
+			themes[name] = t
+		}
+		c.Themes = themes
+	}
+	return c
+}
+
+// Validate returns an error describing every setting that is not valid.
+func (c Config) Validate() error {
+	var errs []error
+	if c.ApiVersion != "" && !apiVersionRe.MatchString(c.ApiVersion) {
+		errs = append(errs, fmt.Errorf("apiVersion must be like \"61.0\", got %q", c.ApiVersion))
+	}
+	if c.DebugLevel != "" && !debugLevelRe.MatchString(c.DebugLevel) {
+		errs = append(errs, fmt.Errorf("debugLevel must be a developer name like \"SFDC_DevConsole\", got %q", c.DebugLevel))
+	}
+	if c.LogLimit != 0 && (c.LogLimit < 1 || c.LogLimit > sf.MaxApexLogsLimit) {
+		errs = append(errs, fmt.Errorf("logLimit must be between 1 and %d, got %d", sf.MaxApexLogsLimit, c.LogLimit))
+	}
+	if c.ListWidth != 0 && (c.ListWidth < MinListWidth || c.ListWidth > MaxListWidth) {
+		errs = append(errs, fmt.Errorf("listWidth must be between %d and %d, got %d", MinListWidth, MaxListWidth, c.ListWidth))
+	}
+	if c.TraceFlagDuration != 0 {
+		if err := traceflag.ValidateDuration(time.Duration(c.TraceFlagDuration)); err != nil {
+			errs = append(errs, fmt.Errorf("traceFlagDuration: %w", err))
+		}
+	}
+	if c.TraceFlagCleanup != "" {
+		if _, err := traceflag.ParseCleanup(c.TraceFlagCleanup); err != nil {
+			errs = append(errs, fmt.Errorf("traceFlagCleanup: %w", err))
+		}
+	}
+	if c.Timeout < 0 {
+		errs = append(errs, fmt.Errorf("timeout must be positive, got %s", time.Duration(c.Timeout)))
+	}
+	if c.BodyTimeout < 0 {
+		errs = append(errs, fmt.Errorf("bodyTimeout must be positive, got %s", time.Duration(c.BodyTimeout)))
+	}
+	names := make([]string, 0, len(c.Themes))
+	for name := range c.Themes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if _, ok := theme.Builtin(name); ok || name == theme.Auto {
+			errs = append(errs, fmt.Errorf("themes: %q is the name of a built-in theme", name))
+		}
+		// Every invalid color is reported on its own line, naming the theme.
+		if err, ok := c.Themes[name].Validate().(interface{ Unwrap() []error }); ok {
+			for _, err := range err.Unwrap() {
+				errs = append(errs, fmt.Errorf("themes: %s: %w", name, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
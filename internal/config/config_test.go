@@ -0,0 +1,118 @@
+package config
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cdelmoral/apexlogs/internal/project"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	user := filepath.Join(t.TempDir(), FileName)
+	writeFile(t, user, `{"apiVersion": "60.0", "logLimit": 500, "traceFlagDuration": "2h", "cacheDir": "", "keyProfile": "vim", "keys": {"quit": ["Q"], "refresh": ["R"]}, "themes": {"mine": {"extends": "light"}}}`)
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, project.ConfigFile), `{"packageDirectories": [{"path": "force-app"}]}`)
+	writeFile(t, filepath.Join(root, ProjectFileName), `{"apiVersion": "62.0", "listWidth": 60, "keys": {"refresh": ["ctrl+r"]}, "theme": "mine", "themes": {"ours": {"focused": "4"}}}`)
+	dir := filepath.Join(root, "force-app", "main")
+	writeFile(t, filepath.Join(dir, "README.md"), "")
+
+	c, err := Load(user, dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if c.ApiVersion != "62.0" || c.ListWidth != 60 {
+		t.Errorf("expected the project settings to override the user ones, got %+v", c)
+	}
+	if c.LogLimit != 500 || time.Duration(c.TraceFlagDuration) != 2*time.Hour {
+		t.Errorf("expected the user settings to be kept, got %+v", c)
+	}
+	if c.CacheDir == nil || *c.CacheDir != "" {
+		t.Errorf("expected the cache to be disabled, got %v", c.CacheDir)
+	}
+	wantKeys := map[string][]string{"quit": {"Q"}, "refresh": {"ctrl+r"}}
+	if c.KeyProfile != "vim" || !reflect.DeepEqual(c.Keys, wantKeys) {
+		t.Errorf("expected the keys to be merged by action, got %q and %v", c.KeyProfile, c.Keys)
+	}
+	if c.Theme != "mine" || len(c.Themes) != 2 {
+		t.Errorf("expected the themes to be merged by name, got %q and %v", c.Theme, c.Themes)
+	}
+}
+
+func TestReadExpandsHome(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	path := filepath.Join(t.TempDir(), FileName)
+	writeFile(t, path, `{"cacheDir": "~/cache", "downloadDir": "~"}`)
+
+	c, err := Read(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if *c.CacheDir != filepath.Join(home, "cache") || c.DownloadDir != home {
+		t.Errorf("expected the home directory to be expanded, got %q and %q", *c.CacheDir, c.DownloadDir)
+	}
+}
+
+func TestLoadMissingFiles(t *testing.T) {
+	c, err := Load(filepath.Join(t.TempDir(), FileName), t.TempDir())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !reflect.DeepEqual(c, Config{}) {
+		t.Errorf("expected the zero config, got %+v", c)
+	}
+	if _, err := Read(filepath.Join(t.TempDir(), FileName)); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected fs.ErrNotExist, got %v", err)
+	}
+}
+
+func TestReadInvalid(t *testing.T) {
+	for name, tc := range map[string]struct {
+		content string
+		want    []string
+	}{
+		"unknown setting": {`{"logLimt": 10}`, []string{`unknown field "logLimt"`}},
+		"bad duration":    {`{"traceFlagDuration": 30}`, []string{`expected a duration like "30m"`}},
+		"bad values": {
+			`{"apiVersion": "61", "debugLevel": "my level", "logLimit": 5000, "listWidth": 10, "traceFlagDuration": "48h", "traceFlagCleanup": "forget", "timeout": "-1s", "bodyTimeout": "-1m"}`,
+			[]string{"apiVersion", "debugLevel", "logLimit must be between 1 and 2000", "listWidth", "traceFlagDuration", "traceFlagCleanup", "timeout must be positive", "bodyTimeout must be positive"},
+		},
+		"bad themes": {
+			`{"themes": {"dark": {}, "mine": {"extends": "solarized", "focused": "blue"}}}`,
+			[]string{`"dark" is the name of a built-in theme`, "mine: extends must be", "mine: focused must be"},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), FileName)
+			writeFile(t, path, tc.content)
+			_, err := Read(path)
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if !strings.Contains(err.Error(), path) {
+				t.Errorf("expected the error to name the file, got %q", err)
+			}
+			for _, want := range tc.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("expected the error to contain %q, got %q", want, err)
+				}
+			}
+		})
+	}
+}
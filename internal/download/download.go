@@ -0,0 +1,181 @@
+// Package download saves the bodies of many apex logs to a directory.
+//
+// Every log is written to its own file, named after its start time, operation
+// and id, so the files of a directory sort chronologically. Files are written
+// atomically, which makes an existing file a complete download: running a
+// download again into the same directory resumes it. The temporary .tmp-*
+// files of a download that was killed are not cleaned up.
+package download
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/cdelmoral/apexlogs/internal/fsutil"
+	sf "github.com/cdelmoral/apexlogs/internal/salesforce"
+)
+
+// DefaultWorkers is the number of logs downloaded concurrently when no number is given.
+const DefaultWorkers = 4
+
+const (
+	fileTimeLayout  = "20060102T150405Z"
+	maxOperationLen = 40
+)
+
+var unsafeChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// A BodyFunc returns the body of the apex log with the given id.
+type BodyFunc func(ctx context.Context, id string) (string, error)
+
+// Progress reports the state of a download.
+type Progress struct {
+	Total int
+	// Done is the number of logs downloaded by this run.
+	Done int
+	// Skipped is the number of logs found in the directory from a previous run.
+	Skipped int
+	Failed  int
+}
+
+// Finished returns the number of logs that are no longer pending.
+func (p Progress) Finished() int {
+	return p.Done + p.Skipped + p.Failed
+}
+
+// A Downloader downloads apex logs into a directory.
+type Downloader struct {
+	dir     string
+	body    BodyFunc
+	workers int
+}
+
+type Option func(*Downloader)
+
+// WithWorkers sets the maximum number of logs downloaded concurrently.
+func WithWorkers(n int) Option {
+	return func(d *Downloader) {
+		if n > 0 {
+			d.workers = n
+		}
+	}
+}
+
+// New creates a [Downloader] saving the bodies returned by body into dir.
+func New(dir string, body BodyFunc, opts ...Option) *Downloader {
+	d := &Downloader{dir: dir, body: body, workers: DefaultWorkers}
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
+}
+
+type result struct {
+	id      string
+	skipped bool
+	err     error
+}
+
+// Run downloads the given logs, skipping the ones already in the directory.
+// progress, if not nil, is called after every log from the calling goroutine.
+//
+// A failed log does not stop the download of the others, the returned error
+// joins the errors of every failed log. When ctx is cancelled the pending logs
+// are abandoned and the context error is returned.
+func (d *Downloader) Run(ctx context.Context, logs []sf.ApexLog, progress func(Progress)) (Progress, error) {
+	p := Progress{Total: len(logs)}
+	if err := os.MkdirAll(d.dir, 0o755); err != nil {
+		return p, fmt.Errorf("error creating download directory: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	jobs := make(chan sf.ApexLog)
+	// The results are buffered so the workers never block after a cancellation.
+	results := make(chan result, len(logs))
+	for range min(d.workers, len(logs)) {
+		go func() {
+			for l := range jobs {
+				results <- d.download(ctx, l)
+			}
+		}()
+	}
+	go func() {
+		defer close(jobs)
+		for _, l := range logs {
+			select {
+			case jobs <- l:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var errs []error
+	for range logs {
+		var r result
+		select {
+		case r = <-results:
+		case <-ctx.Done():
+			return p, ctx.Err()
+		}
+
+		switch {
+		case r.err != nil:
+			p.Failed++
+			errs = append(errs, fmt.Errorf("error downloading apex log %s: %w", r.id, r.err))
+		case r.skipped:
+			p.Skipped++
+		default:
+			p.Done++
+		}
+		if progress != nil {
+			progress(p)
+		}
+	}
+	return p, errors.Join(errs...)
+}
+
+func (d *Downloader) download(ctx context.Context, l sf.ApexLog) result {
+	path := filepath.Join(d.dir, FileName(l))
+	if _, err := os.Stat(path); err == nil {
+		return result{id: l.ID, skipped: true}
+	}
+
+	body, err := d.body(ctx, l.ID)
+	if err != nil {
+		return result{id: l.ID, err: err}
+	}
+	// The file is written atomically, so an interrupted download never leaves
+	// a partial file that would be skipped when resuming.
+	if err := fsutil.WriteFile(path, []byte(body)); err != nil {
+		return result{id: l.ID, err: fmt.Errorf("error writing file: %w", err)}
+	}
+	return result{id: l.ID}
+}
+
+// FileName returns the name of the file the given log is saved to,
+// like 20240615T225017Z_aura_07L0500000G0f5pEAB.log.
+func FileName(l sf.ApexLog) string {
+	start := "unknown"
+	if st, err := time.Parse(sf.DateTimeLayout, l.StartTime); err == nil {
+		start = st.UTC().Format(fileTimeLayout)
+	}
+
+	op := strings.Trim(unsafeChars.ReplaceAllString(l.Operation, "_"), "_")
+	if len(op) > maxOperationLen {
+		op = op[:maxOperationLen]
+	}
+	if op == "" {
+		op = "log"
+	}
+
+	return fmt.Sprintf("%s_%s_%s.log", start, op, unsafeChars.ReplaceAllString(l.ID, "_"))
+}
@@ -0,0 +1,156 @@
+package download
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	sf "github.com/cdelmoral/apexlogs/internal/salesforce"
+)
+
+func testLogs() []sf.ApexLog {
+	return []sf.ApexLog{
+		{ID: "07L000000000001", Operation: "/aura", StartTime: "2024-06-15T22:50:17.000+0000"},
+		{ID: "07L000000000002", Operation: "Api", StartTime: "2024-06-15T22:51:00.000+0000"},
+		{ID: "07L000000000003", Operation: "/apex/My Page", StartTime: "2024-06-15T22:52:00.000+0000"},
+	}
+}
+
+func TestFileName(t *testing.T) {
+	tests := []struct {
+		log  sf.ApexLog
+		want string
+	}{
+		{sf.ApexLog{ID: "07L1", Operation: "/aura", StartTime: "2024-06-15T22:50:17.000+0000"}, "20240615T225017Z_aura_07L1.log"},
+		{sf.ApexLog{ID: "07L1", Operation: "/apex/My Page", StartTime: "2024-06-15T22:50:17.000+0000"}, "20240615T225017Z_apex_My_Page_07L1.log"},
+		{sf.ApexLog{ID: "07L1", StartTime: "invalid"}, "unknown_log_07L1.log"},
+	}
+	for _, tt := range tests {
+		if got := FileName(tt.log); got != tt.want {
+			t.Errorf("FileName(%+v) = %q, want %q", tt.log, got, tt.want)
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	body := func(ctx context.Context, id string) (string, error) {
+		return "body of " + id, nil
+	}
+
+	var updates []Progress
+	p, err := New(dir, body).Run(context.Background(), testLogs(), func(p Progress) {
+		updates = append(updates, p)
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if p != (Progress{Total: 3, Done: 3}) {
+		t.Errorf("unexpected progress: %+v", p)
+	}
+	if len(updates) != 3 || updates[2] != p {
+		t.Errorf("expected a progress update per log, got %v", updates)
+	}
+
+	for _, l := range testLogs() {
+		b, err := os.ReadFile(filepath.Join(dir, FileName(l)))
+		if err != nil {
+			t.Fatalf("expected log %s to be saved: %s", l.ID, err)
+		}
+		if string(b) != "body of "+l.ID {
+			t.Errorf("unexpected body for log %s: %q", l.ID, b)
+		}
+	}
+}
+
+func TestRunResumes(t *testing.T) {
+	dir := t.TempDir()
+	logs := testLogs()
+	if err := os.WriteFile(filepath.Join(dir, FileName(logs[0])), []byte("previous"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var calls atomic.Int32
+	body := func(ctx context.Context, id string) (string, error) {
+		calls.Add(1)
+		return id, nil
+	}
+	p, err := New(dir, body).Run(context.Background(), logs, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if p != (Progress{Total: 3, Done: 2, Skipped: 1}) {
+		t.Errorf("unexpected progress: %+v", p)
+	}
+	if calls.Load() != 2 {
+		t.Errorf("expected 2 downloads, got %d", calls.Load())
+	}
+}
+
+func TestRunContinuesAfterFailures(t *testing.T) {
+	errBody := errors.New("boom")
+	body := func(ctx context.Context, id string) (string, error) {
+		if id == "07L000000000002" {
+			return "", errBody
+		}
+		return id, nil
+	}
+
+	p, err := New(t.TempDir(), body).Run(context.Background(), testLogs(), nil)
+	if !errors.Is(err, errBody) {
+		t.Errorf("expected the body error, got %v", err)
+	}
+	if p != (Progress{Total: 3, Done: 2, Failed: 1}) {
+		t.Errorf("unexpected progress: %+v", p)
+	}
+}
+
+func TestRunBoundsConcurrency(t *testing.T) {
+	var logs []sf.ApexLog
+	for range 20 {
+		logs = append(logs, testLogs()...)
+	}
+	for i := range logs {
+		logs[i].ID = string(rune('a'+i%26)) + string(rune('a'+i/26))
+	}
+
+	var mu sync.Mutex
+	running, peak := 0, 0
+	body := func(ctx context.Context, id string) (string, error) {
+		mu.Lock()
+		running++
+		peak = max(peak, running)
+		mu.Unlock()
+		defer func() {
+			mu.Lock()
+			running--
+			mu.Unlock()
+		}()
+		return id, nil
+	}
+
+	if _, err := New(t.TempDir(), body, WithWorkers(2)).Run(context.Background(), logs, nil); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if peak > 2 {
+		t.Errorf("expected at most 2 concurrent downloads, got %d", peak)
+	}
+}
+
+func TestRunCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	body := func(ctx context.Context, id string) (string, error) {
+		cancel()
+		<-ctx.Done()
+		return "", ctx.Err()
+	}
+
+	_, err := New(t.TempDir(), body).Run(ctx, testLogs(), nil)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected a cancellation error, got %v", err)
+	}
+}
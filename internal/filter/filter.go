@@ -0,0 +1,379 @@
+// Package filter matches the lines of an apex log against a filter expression.
+//
+// An expression is made of terms separated by spaces, which must all match a
+// line. OR between terms matches lines matching either side, and NOT or a
+// leading - excludes the lines matching the next term. AND binds tighter than
+// OR, so "a b OR c" matches lines containing both a and b, or c.
+//
+// A term is a word, a "quoted text" containing spaces or a /regular expression/.
+// Words starting with a slash, like /apex/MyPage, are only regular expressions
+// when they end with one. Every term ignores case unless it contains upper
+// case letters, not counting the escapes of regular expressions like \S.
+package filter
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/cdelmoral/apexlogs/internal/search"
+)
+
+// An Expr is a parsed filter expression.
+// The zero value and nil match every line.
+type Expr struct {
+	root node
+	// positive are the terms that are not negated, the ones highlighted in matching lines.
+	positive []*regexp.Regexp
+}
+
+type node interface {
+	match(line string) bool
+}
+
+type termNode struct {
+	re *regexp.Regexp
+	// literal is the text of a term that is not a regular expression. It is
+	// matched without the regular expression engine, which is several times
+	// slower on the lines of a large log.
+	literal string
+	// fold ignores the case of the literal, which is lower case.
+	fold bool
+}
+
+type andNode []node
+
+type orNode []node
+
+type notNode struct{ n node }
+
+func (n termNode) match(line string) bool {
+	switch {
+	case n.literal == "":
+		return n.re.MatchString(line)
+	case n.fold:
+		return containsFold(line, n.literal)
+	}
+	return strings.Contains(line, n.literal)
+}
+
+func (n andNode) match(line string) bool {
+	for _, c := range n {
+		if !c.match(line) {
+			return false
+		}
+	}
+	return true
+}
+
+func (n orNode) match(line string) bool {
+	for _, c := range n {
+		if c.match(line) {
+			return true
+		}
+	}
+	return false
+}
+
+func (n notNode) match(line string) bool { return !n.n.match(line) }
+
+// Parse parses a filter expression.
+// An empty expression returns a nil [*Expr], which matches every line.
+func Parse(s string) (*Expr, error) {
+	tokens, err := tokenize(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, nil
+	}
+
+	p := parser{tokens: tokens}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	return &Expr{root: root, positive: p.positive}, nil
+}
+
+// Match reports whether the line matches the expression.
+func (e *Expr) Match(line string) bool {
+	if e == nil || e.root == nil {
+		return true
+	}
+	return e.root.match(line)
+}
+
+// Highlights returns the sorted, non-overlapping byte ranges of the line
+// matched by the terms of the expression that are not negated.
+func (e *Expr) Highlights(line string) [][2]int {
+	if e == nil {
+		return nil
+	}
+
+	var ranges [][2]int
+	for _, re := range e.positive {
+		for _, loc := range re.FindAllStringIndex(line, -1) {
+			if loc[0] < loc[1] {
+				ranges = append(ranges, [2]int{loc[0], loc[1]})
+			}
+		}
+	}
+	if len(ranges) < 2 {
+		return ranges
+	}
+
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i][0] < ranges[j][0] })
+	merged := ranges[:1]
+	for _, r := range ranges[1:] {
+		last := &merged[len(merged)-1]
+		if r[0] <= last[1] {
+			last[1] = max(last[1], r[1])
+			continue
+		}
+		merged = append(merged, r)
+	}
+	return merged
+}
+
+type tokenKind int
+
+const (
+	tokenTerm tokenKind = iota
+	tokenOr
+	tokenNot
+)
+
+type token struct {
+	kind    tokenKind
+	pattern string
+	regex   bool
+}
+
+func tokenize(s string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(s); {
+		if s[i] == ' ' || s[i] == '\t' {
+			i++
+			continue
+		}
+
+		if s[i] == '-' && i+1 < len(s) && s[i+1] != ' ' {
+			tokens = append(tokens, token{kind: tokenNot})
+			i++
+			continue
+		}
+
+		switch s[i] {
+		case '"':
+			end := strings.IndexByte(s[i+1:], '"')
+			if end < 0 {
+				return nil, fmt.Errorf("missing closing quote")
+			}
+			tokens = append(tokens, token{pattern: s[i+1 : i+1+end]})
+			i += end + 2
+		case '/':
+			// Paths like /apex/MyPage are words, a regular expression ends
+			// with a slash followed by a space or the end of the expression.
+			end := closingSlash(s[i+1:])
+			if next := i + end + 2; end >= 0 && (next == len(s) || s[next] == ' ' || s[next] == '\t') {
+				tokens = append(tokens, token{pattern: s[i+1 : i+1+end], regex: true})
+				i = next
+				continue
+			}
+			fallthrough
+		default:
+			end := strings.IndexAny(s[i:], " \t")
+			if end < 0 {
+				end = len(s) - i
+			}
+			word := s[i : i+end]
+			switch word {
+			case "OR", "|":
+				tokens = append(tokens, token{kind: tokenOr})
+			case "NOT":
+				tokens = append(tokens, token{kind: tokenNot})
+			default:
+				tokens = append(tokens, token{pattern: word})
+			}
+			i += end
+		}
+	}
+	return tokens, nil
+}
+
+// closingSlash returns the index of the first slash not escaped by a backslash.
+func closingSlash(s string) int {
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '/':
+			return i
+		}
+	}
+	return -1
+}
+
+type parser struct {
+	tokens   []token
+	pos      int
+	negated  bool
+	positive []*regexp.Regexp
+}
+
+func (p *parser) peek() (token, bool) {
+	if p.pos >= len(p.tokens) {
+		return token{}, false
+	}
+	return p.tokens[p.pos], true
+}
+
+func (p *parser) parseOr() (node, error) {
+	var or orNode
+	for {
+		n, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		or = append(or, n)
+
+		t, ok := p.peek()
+		if !ok {
+			break
+		}
+		if t.kind != tokenOr {
+			return nil, fmt.Errorf("unexpected token")
+		}
+		p.pos++
+	}
+	if len(or) == 1 {
+		return or[0], nil
+	}
+	return or, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	var and andNode
+	for {
+		t, ok := p.peek()
+		if !ok || t.kind == tokenOr {
+			break
+		}
+		n, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		and = append(and, n)
+	}
+	switch len(and) {
+	case 0:
+		return nil, fmt.Errorf("missing term around OR")
+	case 1:
+		return and[0], nil
+	}
+	return and, nil
+}
+
+func (p *parser) parseNot() (node, error) {
+	t, _ := p.peek()
+	p.pos++
+	switch t.kind {
+	case tokenNot:
+		if next, ok := p.peek(); !ok || next.kind == tokenOr {
+			return nil, fmt.Errorf("missing term after NOT")
+		}
+		p.negated = !p.negated
+		n, err := p.parseNot()
+		p.negated = !p.negated
+		if err != nil {
+			return nil, err
+		}
+		return notNode{n}, nil
+	case tokenTerm:
+		re, err := compile(t)
+		if err != nil {
+			return nil, err
+		}
+		if !p.negated {
+			p.positive = append(p.positive, re)
+		}
+		return newTermNode(t, re), nil
+	}
+	return nil, fmt.Errorf("unexpected token")
+}
+
+// newTermNode creates the node of a term compiled to re.
+// Words and quoted texts are matched as literals when they are ASCII, since
+// containsFold only folds the case of ASCII letters.
+func newTermNode(t token, re *regexp.Regexp) termNode {
+	n := termNode{re: re}
+	if t.regex || !isASCII(t.pattern) {
+		return n
+	}
+	n.literal = t.pattern
+	n.fold = search.IgnoresCase(t.pattern, false)
+	if n.fold {
+		n.literal = strings.ToLower(t.pattern)
+	}
+	return n
+}
+
+func compile(t token) (*regexp.Regexp, error) {
+	pattern := t.pattern
+	if !t.regex {
+		pattern = regexp.QuoteMeta(pattern)
+	}
+	if search.IgnoresCase(t.pattern, t.regex) {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regular expression: %w", err)
+	}
+	return re, nil
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+// containsFold reports whether s contains substr ignoring the case of ASCII
+// letters. substr must be lower case and not empty.
+func containsFold(s, substr string) bool {
+	first, upper := substr[0], toUpper(substr[0])
+	for i := 0; i <= len(s)-len(substr); i++ {
+		if s[i] != first && s[i] != upper {
+			continue
+		}
+		j := 1
+		for j < len(substr) && toLower(s[i+j]) == substr[j] {
+			j++
+		}
+		if j == len(substr) {
+			return true
+		}
+	}
+	return false
+}
+
+func toLower(b byte) byte {
+	if 'A' <= b && b <= 'Z' {
+		return b + 'a' - 'A'
+	}
+	return b
+}
+
+func toUpper(b byte) byte {
+	if 'a' <= b && b <= 'z' {
+		return b - ('a' - 'A')
+	}
+	return b
+}
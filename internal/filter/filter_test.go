@@ -0,0 +1,111 @@
+package filter
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	lines := []string{
+		"12:00:00.0 (1)|USER_DEBUG|[3]|DEBUG|hello world",
+		"12:00:00.0 (2)|SOQL_EXECUTE_BEGIN|[5]|Aggregations:0|SELECT Id FROM Account",
+		"12:00:00.0 (3)|HEAP_ALLOCATE|[72]|Bytes:3",
+		"12:00:00.0 (4)|EXCEPTION_THROWN|[9]|System.NullPointerException: Attempt to de-reference a null object",
+		"12:00:00.0 (5)|CALLOUT_REQUEST|[12]|System.HttpRequest[Endpoint=https://example.com/services/data/v61.0/apex, Method=GET]",
+	}
+
+	tests := []struct {
+		expr string
+		want []int
+	}{
+		{"", []int{0, 1, 2, 3, 4}},
+		{"user_debug", []int{0}},
+		{"User_Debug", nil},
+		{"debug hello", []int{0}},
+		{"debug goodbye", nil},
+		{"user_debug OR soql", []int{0, 1}},
+		{"user_debug | heap", []int{0, 2}},
+		{"-heap_allocate", []int{0, 1, 3, 4}},
+		{"NOT heap_allocate NOT soql", []int{0, 3, 4}},
+		{`"hello world"`, []int{0}},
+		{`/\[\d\]/`, []int{0, 1, 3}},
+		{`/exception:.*null/`, []int{3}},
+		{`/\w+\s\S+ a null/`, []int{3}},
+		{"/apex", []int{4}},
+		{"/services/data/v61.0", []int{4}},
+		{"/services/data/v61.0/ OR heap", []int{2, 4}},
+		{"debug hello OR select", []int{0, 1}},
+		{"-(1)", []int{1, 2, 3, 4}},
+	}
+
+	for _, tt := range tests {
+		e, err := Parse(tt.expr)
+		if err != nil {
+			t.Errorf("Parse(%q) returned error: %s", tt.expr, err)
+			continue
+		}
+		var got []int
+		for i, l := range lines {
+			if e.Match(l) {
+				got = append(got, i)
+			}
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Parse(%q) matched lines %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, expr := range []string{`"unclosed`, "/[/", "OR debug", "debug OR", "debug NOT"} {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("expected Parse(%q) to fail", expr)
+		}
+	}
+}
+
+func TestHighlights(t *testing.T) {
+	e, err := Parse("debug -heap OR /d.b/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := e.Highlights("USER_DEBUG|DEBUG|dub")
+	want := [][2]int{{5, 10}, {11, 16}, {17, 20}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got highlights %v, want %v", got, want)
+	}
+}
+
+func TestContainsFold(t *testing.T) {
+	tests := []struct {
+		s, substr string
+		want      bool
+	}{
+		{"USER_DEBUG", "user_debug", true},
+		{"12|User_Debug|", "user_debug", true},
+		{"USER_DEBU", "user_debug", false},
+		{"uuser", "user", true},
+		{"[1]", "[1]", true},
+		{"héllo WORLD", "world", true},
+	}
+	for _, tt := range tests {
+		if got := containsFold(tt.s, tt.substr); got != tt.want {
+			t.Errorf("containsFold(%q, %q) = %t, want %t", tt.s, tt.substr, got, tt.want)
+		}
+	}
+}
+
+func BenchmarkMatch(b *testing.B) {
+	line := "12:00:00.0 (4)|EXCEPTION_THROWN|[9]|System.NullPointerException: Attempt to de-reference a null object"
+	for _, expr := range []string{"nullpointer", "NullPointer", "/null.*object/"} {
+		e, err := Parse(expr)
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.Run(expr, func(b *testing.B) {
+			for range b.N {
+				e.Match(line)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+// Package fsutil writes files atomically, so a crash or an interrupted write
+// never leaves a partial file behind.
+//
+// The content is written to a temporary file named .tmp-* in the directory
+// of the destination, which is renamed to the destination once complete.
+// The temporary files of a process killed while writing are not cleaned up.
+package fsutil
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// A File is written to a temporary file and only replaces its destination
+// once [File.Commit] is called.
+type File struct {
+	f    *os.File
+	path string
+}
+
+// Create starts writing the file at path. The directory of path must exist.
+func Create(path string) (*File, error) {
+	f, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return nil, err
+	}
+	return &File{f: f, path: path}, nil
+}
+
+func (f *File) Write(p []byte) (int, error) {
+	return f.f.Write(p)
+}
+
+// Commit replaces the destination with the content written so far.
+func (f *File) Commit() error {
+	defer os.Remove(f.f.Name())
+	if err := f.f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.f.Name(), f.path)
+}
+
+// Discard drops the content written so far, leaving the destination unchanged.
+func (f *File) Discard() {
+	f.f.Close()
+	os.Remove(f.f.Name())
+}
+
+// WriteFile writes b to the file at path atomically.
+func WriteFile(path string, b []byte) error {
+	f, err := Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(b); err != nil {
+		f.Discard()
+		return err
+	}
+	return f.Commit()
+}
@@ -0,0 +1,55 @@
+package fsutil
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "log")
+	if err := os.WriteFile(path, []byte("old"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := WriteFile(path, []byte("new")); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if b, err := os.ReadFile(path); err != nil || string(b) != "new" {
+		t.Errorf("expected the file to be replaced, got %q, %v", b, err)
+	}
+	assertNoTempFiles(t, dir)
+
+	if err := WriteFile(filepath.Join(dir, "missing", "log"), []byte("new")); err == nil {
+		t.Errorf("expected an error writing to a missing directory")
+	}
+}
+
+func TestDiscard(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "log")
+	f, err := Create(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, err := f.Write([]byte("partial")); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("expected the file not to exist before committing, got %v", err)
+	}
+
+	f.Discard()
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("expected the file not to exist after discarding, got %v", err)
+	}
+	assertNoTempFiles(t, dir)
+}
+
+func assertNoTempFiles(t *testing.T, dir string) {
+	t.Helper()
+	if tmp, _ := filepath.Glob(filepath.Join(dir, ".tmp-*")); len(tmp) > 0 {
+		t.Errorf("expected no temporary files, got %q", tmp)
+	}
+}
@@ -0,0 +1,216 @@
+// Package logdiff compares two apex logs, like the logs of a transaction
+// before and after a change.
+//
+// The lines of both logs are aligned ignoring what changes on every run, like
+// the time of the events and the record Ids, so only the differences in the
+// executed code, the queries, the limits and the debug output remain.
+package logdiff
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/cdelmoral/apexlogs/internal/apexlog"
+)
+
+// maxEdits is the maximum number of differing lines aligned one by one.
+// Aligning is quadratic in the number of differences, so the rest of very
+// different logs is reported as a single change.
+const maxEdits = 1000
+
+var (
+	// idPattern matches 15 and 18 character record Ids, which have a digit
+	// after the 3 character prefix of their object type.
+	idPattern = regexp.MustCompile(`\b[0-9a-zA-Z]{3}[0-9][0-9a-zA-Z]{11}(?:[0-9a-zA-Z]{3})?\b`)
+	// addressPattern matches the memory addresses of variables and objects.
+	addressPattern = regexp.MustCompile(`\b0x[0-9a-fA-F]+\b`)
+)
+
+// An Op is how a line differs between the logs.
+type Op int
+
+const (
+	// Equal lines are in both logs.
+	Equal Op = iota
+	// Delete lines are only in the first log.
+	Delete
+	// Insert lines are only in the second log.
+	Insert
+)
+
+// A Line is a line of the aligned logs.
+type Line struct {
+	Op Op
+	// Text is the line of the first log for Equal and Delete lines, or of the
+	// second log for Insert lines.
+	Text string
+}
+
+// A Result is the comparison of two logs.
+type Result struct {
+	Lines []Line
+	// Stats are the statistics of the first and the second log.
+	Stats [2]Stats
+	// Partial reports that the logs were too different to be aligned
+	// completely, so part of them is a single change.
+	Partial bool
+}
+
+// Changes returns the number of lines only in one of the logs.
+func (r Result) Changes() int {
+	n := 0
+	for _, l := range r.Lines {
+		if l.Op != Equal {
+			n++
+		}
+	}
+	return n
+}
+
+// Compare aligns the lines of the logs a and b.
+func Compare(a, b string) Result {
+	la, lb := strings.Split(a, "\n"), strings.Split(b, "\n")
+	na, nb := normalizeAll(la), normalizeAll(lb)
+
+	r := Result{Stats: [2]Stats{NewStats(la), NewStats(lb)}}
+
+	// The beginning and the end of similar logs are usually the same, and
+	// skipping them keeps the lines to align few.
+	prefix := 0
+	for prefix < len(na) && prefix < len(nb) && na[prefix] == nb[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(na)-prefix && suffix < len(nb)-prefix && na[len(na)-1-suffix] == nb[len(nb)-1-suffix] {
+		suffix++
+	}
+
+	for _, l := range la[:prefix] {
+		r.Lines = append(r.Lines, Line{Equal, l})
+	}
+	edits, ok := align(na[prefix:len(na)-suffix], nb[prefix:len(nb)-suffix])
+	r.Partial = !ok
+	for _, e := range edits {
+		switch e.op {
+		case Insert:
+			r.Lines = append(r.Lines, Line{Insert, lb[prefix+e.b]})
+		default:
+			r.Lines = append(r.Lines, Line{e.op, la[prefix+e.a]})
+		}
+	}
+	for _, l := range la[len(la)-suffix:] {
+		r.Lines = append(r.Lines, Line{Equal, l})
+	}
+	return r
+}
+
+// Normalize removes from the line what changes on every run of the same code:
+// the time of the event, record Ids and memory addresses.
+func Normalize(line string) string {
+	if l, ok := apexlog.ParseLine(line); ok {
+		line = line[l.Event.Start:]
+	}
+	line = idPattern.ReplaceAllString(line, "<id>")
+	return addressPattern.ReplaceAllString(line, "<address>")
+}
+
+func normalizeAll(lines []string) []string {
+	n := make([]string, len(lines))
+	for i, l := range lines {
+		n[i] = Normalize(l)
+	}
+	return n
+}
+
+// An edit is a line of the alignment of a and b, with its index in a for
+// Equal and Delete edits, or in b for Insert edits.
+type edit struct {
+	op Op
+	a  int
+	b  int
+}
+
+// align returns the shortest edit script from a to b, using the algorithm
+// described in "An O(ND) Difference Algorithm and Its Variations" by Myers.
+// When more than maxEdits differences are needed it returns false, with every
+// line deleted from a and inserted from b.
+func align(a, b []string) ([]edit, bool) {
+	n, m := len(a), len(b)
+	limit := min(n+m, maxEdits)
+	// v[k+offset] is the furthest index in a reached on diagonal k.
+	offset := limit + 1
+	v := make([]int, 2*offset+1)
+	// trace[d] keeps the diagonals -d to d of v before looking for the paths
+	// with d differences, to walk back the shortest one.
+	var trace [][]int
+
+	for d := 0; d <= limit; d++ {
+		trace = append(trace, append([]int(nil), v[offset-d:offset+d+1]...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[k-1+offset] < v[k+1+offset]) {
+				x = v[k+1+offset]
+			} else {
+				x = v[k-1+offset] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[k+offset] = x
+			if x >= n && y >= m {
+				return backtrack(trace, n, m), true
+			}
+		}
+	}
+
+	edits := make([]edit, 0, n+m)
+	for i := range a {
+		edits = append(edits, edit{op: Delete, a: i})
+	}
+	for i := range b {
+		edits = append(edits, edit{op: Insert, b: i})
+	}
+	return edits, false
+}
+
+// backtrack walks the path found by align from the end to the start.
+func backtrack(trace [][]int, x, y int) []edit {
+	var edits []edit
+	for d := len(trace) - 1; d >= 0; d-- {
+		// v returns the furthest index in a reached on diagonal k with d-1 differences.
+		v := func(k int) int { return trace[d][k+d] }
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && v(k-1) < v(k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := 0
+		if d > 0 {
+			prevX = v(prevK)
+		}
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			x--
+			y--
+			edits = append(edits, edit{op: Equal, a: x, b: y})
+		}
+		if d > 0 {
+			if x == prevX {
+				edits = append(edits, edit{op: Insert, b: prevY})
+			} else {
+				edits = append(edits, edit{op: Delete, a: prevX})
+			}
+		}
+		x, y = prevX, prevY
+	}
+
+	for i, j := 0, len(edits)-1; i < j; i, j = i+1, j-1 {
+		edits[i], edits[j] = edits[j], edits[i]
+	}
+	return edits
+}
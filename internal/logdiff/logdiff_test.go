@@ -0,0 +1,142 @@
+package logdiff
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		line string
+		want string
+	}{
+		{
+			"15:50:17.5 (5559211)|CODE_UNIT_STARTED|[EXTERNAL]|01q5g000000ABCD|AccountTrigger on Account trigger event BeforeInsert",
+			"CODE_UNIT_STARTED|[EXTERNAL]|<id>|AccountTrigger on Account trigger event BeforeInsert",
+		},
+		{
+			"16:02:03.25 (125)|VARIABLE_ASSIGNMENT|[4]|this|{}|0x6e2b8c39",
+			"VARIABLE_ASSIGNMENT|[4]|this|{}|<address>",
+		},
+		{
+			"Id: 0015g00000XyZ12AAB",
+			"Id: <id>",
+		},
+		{
+			"Execute Anonymous: insert acc;",
+			"Execute Anonymous: insert acc;",
+		},
+	}
+	for _, tt := range tests {
+		if got := Normalize(tt.line); got != tt.want {
+			t.Errorf("Normalize(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	a := strings.Join([]string{
+		"10:00:00.1 (100)|EXECUTION_STARTED",
+		"10:00:00.1 (200)|CODE_UNIT_STARTED|[EXTERNAL]|0015g00000XyZ12|AccountTrigger",
+		"10:00:00.1 (300)|SOQL_EXECUTE_BEGIN|[5]|Aggregations:0|SELECT Id FROM Contact",
+		"10:00:00.1 (400)|USER_DEBUG|[7]|DEBUG|old",
+		"10:00:00.1 (500)|CODE_UNIT_FINISHED|AccountTrigger",
+		"10:00:00.1 (600)|EXECUTION_FINISHED",
+	}, "\n")
+	b := strings.Join([]string{
+		"11:30:00.2 (150)|EXECUTION_STARTED",
+		"11:30:00.2 (250)|CODE_UNIT_STARTED|[EXTERNAL]|0015g00000AbC34|AccountTrigger",
+		"11:30:00.2 (350)|SOQL_EXECUTE_BEGIN|[5]|Aggregations:0|SELECT Id FROM Contact",
+		"11:30:00.2 (360)|SOQL_EXECUTE_BEGIN|[6]|Aggregations:0|SELECT Id FROM Case",
+		"11:30:00.2 (450)|USER_DEBUG|[7]|DEBUG|new",
+		"11:30:00.2 (550)|CODE_UNIT_FINISHED|AccountTrigger",
+		"11:30:00.2 (650)|EXECUTION_FINISHED",
+	}, "\n")
+
+	r := Compare(a, b)
+	var got []string
+	for _, l := range r.Lines {
+		got = append(got, fmt.Sprint(l.Op, " ", Normalize(l.Text)))
+	}
+	want := []string{
+		"0 EXECUTION_STARTED",
+		"0 CODE_UNIT_STARTED|[EXTERNAL]|<id>|AccountTrigger",
+		"0 SOQL_EXECUTE_BEGIN|[5]|Aggregations:0|SELECT Id FROM Contact",
+		"1 USER_DEBUG|[7]|DEBUG|old",
+		"2 SOQL_EXECUTE_BEGIN|[6]|Aggregations:0|SELECT Id FROM Case",
+		"2 USER_DEBUG|[7]|DEBUG|new",
+		"0 CODE_UNIT_FINISHED|AccountTrigger",
+		"0 EXECUTION_FINISHED",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected lines\ngot:  %q\nwant: %q", got, want)
+	}
+	if r.Changes() != 3 || r.Partial {
+		t.Errorf("unexpected changes %d, partial %v", r.Changes(), r.Partial)
+	}
+	if r.Stats[0].SOQL != 1 || r.Stats[1].SOQL != 2 {
+		t.Errorf("unexpected SOQL counts %d and %d", r.Stats[0].SOQL, r.Stats[1].SOQL)
+	}
+}
+
+func TestCompareEqual(t *testing.T) {
+	a := "10:00:00.1 (100)|EXECUTION_STARTED\n10:00:00.1 (600)|EXECUTION_FINISHED"
+	b := "12:00:00.1 (900)|EXECUTION_STARTED\n12:00:00.1 (999)|EXECUTION_FINISHED"
+	if r := Compare(a, b); r.Changes() != 0 || len(r.Lines) != 2 {
+		t.Errorf("expected no changes, got %+v", r.Lines)
+	}
+}
+
+func TestAlignTooDifferent(t *testing.T) {
+	var a, b []string
+	for i := range maxEdits {
+		a = append(a, fmt.Sprint("a", i))
+		b = append(b, fmt.Sprint("b", i))
+	}
+	edits, ok := align(a, b)
+	if ok {
+		t.Error("expected the alignment to be partial")
+	}
+	if len(edits) != 2*maxEdits || edits[0].op != Delete || edits[len(edits)-1].op != Insert {
+		t.Errorf("expected every line to be replaced, got %d edits", len(edits))
+	}
+}
+
+func TestNewStats(t *testing.T) {
+	lines := []string{
+		"60.0 APEX_CODE,DEBUG",
+		"10:00:00.1 (1000000)|EXECUTION_STARTED",
+		"10:00:00.1 (2000000)|CODE_UNIT_STARTED|[EXTERNAL]|01q5g000000ABCD|AccountTrigger",
+		"10:00:00.1 (3000000)|SOQL_EXECUTE_BEGIN|[5]|Aggregations:0|SELECT Id FROM Contact",
+		"10:00:00.1 (4000000)|DML_BEGIN|[9]|Op:Insert|Type:Contact|Rows:1",
+		"10:00:00.1 (5000000)|USER_DEBUG|[7]|DEBUG|hello",
+		"10:00:00.1 (6000000)|EXCEPTION_THROWN|[8]|System.NullPointerException: oops",
+		"10:00:00.1 (7000000)|LIMIT_USAGE_FOR_NS|(default)|",
+		"  Number of SOQL queries: 1 out of 100",
+		"  Number of DML statements: 1 out of 150",
+		"10:00:00.1 (8000000)|LIMIT_USAGE_FOR_NS|(default)|",
+		"  Number of SOQL queries: 3 out of 100",
+		"10:00:00.1 (9000000)|EXECUTION_FINISHED",
+	}
+	s := NewStats(lines)
+
+	want := Stats{
+		Duration:   8 * time.Millisecond,
+		Events:     9,
+		SOQL:       1,
+		DML:        1,
+		CodeUnits:  []string{"AccountTrigger"},
+		Exceptions: []string{"System.NullPointerException: oops"},
+		Debug:      []string{"hello"},
+		Limits: []Limit{
+			{Name: "Number of SOQL queries", Used: 3, Max: 100},
+			{Name: "Number of DML statements", Used: 1, Max: 150},
+		},
+	}
+	if !reflect.DeepEqual(s, want) {
+		t.Errorf("unexpected stats\ngot:  %+v\nwant: %+v", s, want)
+	}
+}
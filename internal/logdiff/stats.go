@@ -0,0 +1,132 @@
+package logdiff
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cdelmoral/apexlogs/internal/apexlog"
+)
+
+// A Limit is the usage of a governor limit, reported in the log like
+//
+//	Number of SOQL queries: 2 out of 100
+type Limit struct {
+	Name string
+	Used int
+	Max  int
+}
+
+// Stats are the figures of a log compared between logs.
+type Stats struct {
+	// Duration is the time elapsed between the first and the last event.
+	Duration time.Duration
+	Events   int
+	SOQL     int
+	DML      int
+	// CodeUnits are the names of the executed code units, like triggers and
+	// classes, in the order they started.
+	CodeUnits []string
+	// Exceptions are the messages of the thrown exceptions and fatal errors.
+	Exceptions []string
+	// Debug are the messages of the debug statements.
+	Debug []string
+	// Limits are the last reported usages of the governor limits, in the order
+	// they are first reported.
+	Limits []Limit
+}
+
+// Limit returns the usage of the governor limit with the given name.
+func (s Stats) Limit(name string) (Limit, bool) {
+	for _, l := range s.Limits {
+		if l.Name == name {
+			return l, true
+		}
+	}
+	return Limit{}, false
+}
+
+// NewStats computes the statistics of the lines of a log.
+func NewStats(lines []string) Stats {
+	var s Stats
+	var first, last int64 = -1, -1
+	limits := map[string]int{}
+	inLimits := false
+
+	for _, line := range lines {
+		l, ok := apexlog.ParseLine(line)
+		if !ok {
+			if inLimits {
+				if lim, ok := parseLimit(line); ok {
+					if i, ok := limits[lim.Name]; ok {
+						s.Limits[i] = lim
+					} else {
+						limits[lim.Name] = len(s.Limits)
+						s.Limits = append(s.Limits, lim)
+					}
+				}
+			}
+			continue
+		}
+
+		s.Events++
+		if n, err := strconv.ParseInt(strings.Trim(l.Nanos.Text(line), "()"), 10, 64); err == nil {
+			if first < 0 {
+				first = n
+			}
+			last = n
+		}
+
+		event := l.EventType(line)
+		inLimits = event == "LIMIT_USAGE_FOR_NS"
+		switch event {
+		case "SOQL_EXECUTE_BEGIN":
+			s.SOQL++
+		case "DML_BEGIN":
+			s.DML++
+		case "CODE_UNIT_STARTED":
+			s.CodeUnits = append(s.CodeUnits, lastField(l, line))
+		case "EXCEPTION_THROWN", "FATAL_ERROR":
+			s.Exceptions = append(s.Exceptions, lastField(l, line))
+		case "USER_DEBUG":
+			s.Debug = append(s.Debug, lastField(l, line))
+		}
+	}
+
+	if first >= 0 {
+		s.Duration = time.Duration(last - first)
+	}
+	return s
+}
+
+// lastField returns the last field of the event line, which is the name or
+// message of most events.
+func lastField(l apexlog.Line, line string) string {
+	if len(l.Fields) == 0 {
+		return ""
+	}
+	return l.Fields[len(l.Fields)-1].Text(line)
+}
+
+// parseLimit parses a line of the limit usage of a namespace, like
+//
+//	Number of SOQL queries: 2 out of 100
+func parseLimit(line string) (Limit, bool) {
+	name, usage, ok := strings.Cut(strings.TrimSpace(line), ": ")
+	if !ok {
+		return Limit{}, false
+	}
+	used, maximum, ok := strings.Cut(usage, " out of ")
+	if !ok {
+		return Limit{}, false
+	}
+	u, err := strconv.Atoi(used)
+	if err != nil {
+		return Limit{}, false
+	}
+	m, err := strconv.Atoi(strings.TrimSpace(maximum))
+	if err != nil {
+		return Limit{}, false
+	}
+	return Limit{Name: name, Used: u, Max: m}, true
+}
@@ -0,0 +1,117 @@
+// Package project locates the Apex sources of a local Salesforce DX project,
+// so the lines of a log can be opened in the code that produced them.
+//
+// A project is a directory with an sfdx-project.json file listing the package
+// directories the sources are in, like
+//
+//	{"packageDirectories": [{"path": "force-app", "default": true}]}
+package project
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ConfigFile is the name of the file at the root of a project.
+const ConfigFile = "sfdx-project.json"
+
+// ErrNotFound is returned when a directory is not inside a project.
+var ErrNotFound = errors.New("not in a Salesforce DX project")
+
+// ErrSourceNotFound is returned when no package directory has the source of a class or trigger.
+var ErrSourceNotFound = errors.New("apex source not found in the project")
+
+// A Project is a Salesforce DX project on disk.
+type Project struct {
+	// Root is the directory of the sfdx-project.json file.
+	Root string
+	// PackageDirs are the paths of the package directories.
+	PackageDirs []string
+	// Namespace is the namespace of the packages of the project, which
+	// qualifies the names of their classes in the logs. It is empty when
+	// the project has no namespace.
+	Namespace string
+}
+
+type config struct {
+	Namespace          string `json:"namespace"`
+	PackageDirectories []struct {
+		Path string `json:"path"`
+	} `json:"packageDirectories"`
+}
+
+// Find loads the project dir is in, looking for the sfdx-project.json file in
+// dir and its parents.
+func Find(dir string) (Project, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return Project{}, err
+	}
+	for {
+		p, err := Load(dir)
+		if !errors.Is(err, fs.ErrNotExist) {
+			return p, err
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return Project{}, ErrNotFound
+		}
+		dir = parent
+	}
+}
+
+// Load loads the project with the given root directory.
+func Load(root string) (Project, error) {
+	b, err := os.ReadFile(filepath.Join(root, ConfigFile))
+	if err != nil {
+		return Project{}, err
+	}
+	var c config
+	if err := json.Unmarshal(b, &c); err != nil {
+		return Project{}, fmt.Errorf("error reading %s: %w", ConfigFile, err)
+	}
+
+	p := Project{Root: root, Namespace: c.Namespace}
+	for _, d := range c.PackageDirectories {
+		if d.Path != "" {
+			p.PackageDirs = append(p.PackageDirs, filepath.Join(root, filepath.FromSlash(d.Path)))
+		}
+	}
+	return p, nil
+}
+
+// Source returns the path of the source file of the Apex class or trigger
+// with the given name, like force-app/main/default/classes/MyClass.cls.
+// Names are matched ignoring case, like Apex does.
+func (p Project) Source(name string, trigger bool) (string, error) {
+	file := name + ".cls"
+	if trigger {
+		file = name + ".trigger"
+	}
+
+	var found string
+	for _, dir := range p.PackageDirs {
+		err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() && strings.EqualFold(d.Name(), file) {
+				found = path
+				return fs.SkipAll
+			}
+			return nil
+		})
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return "", err
+		}
+		if found != "" {
+			return found, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %s", ErrSourceNotFound, file)
+}
@@ -0,0 +1,52 @@
+package project
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFind(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, ConfigFile), `{"packageDirectories": [{"path": "force-app"}, {"path": "libs/utils"}], "namespace": "acme"}`)
+	classes := filepath.Join(root, "force-app", "main", "default", "classes")
+	writeFile(t, filepath.Join(classes, "AccountService.cls"), "public class AccountService {}")
+	writeFile(t, filepath.Join(root, "libs", "utils", "triggers", "AccountTrigger.trigger"), "trigger AccountTrigger on Account (before insert) {}")
+
+	p, err := Find(classes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Root != root || len(p.PackageDirs) != 2 || p.Namespace != "acme" {
+		t.Fatalf("unexpected project %+v", p)
+	}
+
+	path, err := p.Source("accountservice", false)
+	if err != nil || path != filepath.Join(classes, "AccountService.cls") {
+		t.Errorf("expected the class to be found ignoring case, got %q (%v)", path, err)
+	}
+	path, err = p.Source("AccountTrigger", true)
+	if err != nil || filepath.Base(path) != "AccountTrigger.trigger" {
+		t.Errorf("expected the trigger in the second package directory, got %q (%v)", path, err)
+	}
+	if _, err := p.Source("Missing", false); !errors.Is(err, ErrSourceNotFound) {
+		t.Errorf("expected ErrSourceNotFound, got %v", err)
+	}
+}
+
+func TestFindNotInProject(t *testing.T) {
+	if _, err := Find(t.TempDir()); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,63 @@
+package salesforce
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// AnonymousApexOperation is the operation of the Apex Logs of anonymous Apex
+// executions, like "/services/data/v61.0/tooling/executeAnonymous/", as a
+// pattern for [ApexLogFilter].
+const AnonymousApexOperation = "%/executeAnonymous/%"
+
+// An ExecuteAnonymousResult is the outcome of an anonymous Apex execution.
+// Line and Column locate the compile problem, and are -1 when there is none.
+type ExecuteAnonymousResult struct {
+	Line                int    `json:"line"`
+	Column              int    `json:"column"`
+	Compiled            bool   `json:"compiled"`
+	Success             bool   `json:"success"`
+	CompileProblem      string `json:"compileProblem"`
+	ExceptionMessage    string `json:"exceptionMessage"`
+	ExceptionStackTrace string `json:"exceptionStackTrace"`
+}
+
+// Err returns the compile problem or the exception of a failed execution, or
+// nil if it succeeded.
+func (r ExecuteAnonymousResult) Err() error {
+	switch {
+	case r.Success:
+		return nil
+	case !r.Compiled:
+		return fmt.Errorf("compile error at line %d, column %d: %s", r.Line, r.Column, r.CompileProblem)
+	case r.ExceptionStackTrace != "":
+		trace := strings.ReplaceAll(strings.TrimSpace(r.ExceptionStackTrace), "\n", ", ")
+		return fmt.Errorf("%s (%s)", r.ExceptionMessage, trace)
+	default:
+		return fmt.Errorf("%s", r.ExceptionMessage)
+	}
+}
+
+// ExecuteAnonymous compiles and runs the given Apex code as the user of the client.
+// The request is not retried, so the code never runs twice.
+// An error is returned if the request fails, a failed execution is reported
+// by [ExecuteAnonymousResult.Err] instead.
+func ExecuteAnonymous(ctx context.Context, c *Client, code string) (ExecuteAnonymousResult, error) {
+	u, err := c.resourceUrl("executeAnonymous/", map[string]string{"anonymousBody": code})
+	if err != nil {
+		return ExecuteAnonymousResult{}, err
+	}
+
+	body, err := c.send(ctx, c.timeout, "GET", u, "", nil)
+	if err != nil {
+		return ExecuteAnonymousResult{}, fmt.Errorf("error sending request to execute anonymous apex: %w", err)
+	}
+
+	var res ExecuteAnonymousResult
+	if err := json.Unmarshal(body, &res); err != nil {
+		return ExecuteAnonymousResult{}, fmt.Errorf("unexpected error parsing response body: %s", err)
+	}
+	return res, nil
+}
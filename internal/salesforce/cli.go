@@ -1,6 +1,7 @@
 package salesforce
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os/exec"
@@ -34,8 +35,8 @@ type CommandResponse[T any] struct {
 }
 
 // GetDefaultUserInfo returns the Salesforce CLI default user.
-func GetDefaultUserInfo() (UserInfo, error) {
-	cmd := exec.Command("sf", "org", "display", "user", "--json")
+func GetDefaultUserInfo(ctx context.Context) (UserInfo, error) {
+	cmd := exec.CommandContext(ctx, "sf", "org", "display", "user", "--json")
 	out, err := cmd.Output()
 	if err != nil {
 		return UserInfo{}, err
@@ -51,10 +52,10 @@ func GetDefaultUserInfo() (UserInfo, error) {
 }
 
 // GetDefaultScratchOrgInfo returns the Salesforce CLI default scratch org.
-func GetDefaultScratchOrgInfo() (ScratchOrgInfo, error) {
+func GetDefaultScratchOrgInfo(ctx context.Context) (ScratchOrgInfo, error) {
 	var info ScratchOrgInfo
 
-	cmd := exec.Command("sf", "org", "display", "--json")
+	cmd := exec.CommandContext(ctx, "sf", "org", "display", "--json")
 	out, err := cmd.Output()
 	if err != nil {
 		return info, err
@@ -78,3 +79,52 @@ func GetDefaultScratchOrgInfo() (ScratchOrgInfo, error) {
 
 	return info, nil
 }
+
+// A ConfigValue is a Salesforce CLI configuration variable.
+type ConfigValue struct {
+	Name     string
+	Value    string
+	Location string
+}
+
+// An Alias is a Salesforce CLI alias of a username.
+type Alias struct {
+	Alias string
+	Value string
+}
+
+// GetDefaultUsername returns the username of the Salesforce CLI default org.
+// It only reads the local Salesforce CLI configuration, so it works without connectivity.
+func GetDefaultUsername(ctx context.Context) (string, error) {
+	out, err := exec.CommandContext(ctx, "sf", "config", "get", "target-org", "--json").Output()
+	if err != nil {
+		return "", err
+	}
+
+	var configResponse CommandResponse[[]ConfigValue]
+	if err := json.Unmarshal(out, &configResponse); err != nil {
+		return "", err
+	}
+	if len(configResponse.Result) == 0 || configResponse.Result[0].Value == "" {
+		return "", fmt.Errorf("no default org configured, run sf config set target-org")
+	}
+	targetOrg := configResponse.Result[0].Value
+
+	out, err = exec.CommandContext(ctx, "sf", "alias", "list", "--json").Output()
+	if err != nil {
+		return "", err
+	}
+
+	var aliasResponse CommandResponse[[]Alias]
+	if err := json.Unmarshal(out, &aliasResponse); err != nil {
+		return "", err
+	}
+	for _, a := range aliasResponse.Result {
+		if a.Alias == targetOrg {
+			return a.Value, nil
+		}
+	}
+
+	// The default org is not an alias, so it is already a username.
+	return targetOrg, nil
+}
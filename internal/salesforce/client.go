@@ -1,15 +1,34 @@
 package salesforce
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"math/rand/v2"
+	"net"
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
+	"time"
 )
 
-const DateTimeLayout = "2006-01-02T15:04:05.999Z0700"
+const (
+	DateTimeLayout = "2006-01-02T15:04:05.999Z0700"
+
+	// DefaultTimeout and DefaultBodyTimeout are the timeouts of a client
+	// created without [WithTimeout] and [WithBodyTimeout].
+	DefaultTimeout     = 30 * time.Second
+	DefaultBodyTimeout = 5 * time.Minute
+
+	defaultMaxRetries   = 3
+	defaultRetryBackoff = 500 * time.Millisecond
+	maxRetryBackoff     = 30 * time.Second
+
+	requestLimitExceeded = "REQUEST_LIMIT_EXCEEDED"
+)
 
 type Attributes struct {
 	Type string `json:"type"`
@@ -35,33 +54,136 @@ type PostSObjectResponse struct {
 
 // A Client is a Salesforce API client.
 // Client stores the access token, instance URL, API version and alias of a Salesforce org.
+// A single [http.Client] is shared by all the requests sent through the same Client.
 type Client struct {
-	accessToken string
-	instanceUrl string
-	apiVersion  string
-	alias       string
+	httpClient   *http.Client
+	accessToken  string
+	instanceUrl  string
+	apiVersion   string
+	alias        string
+	timeout      time.Duration
+	bodyTimeout  time.Duration
+	maxRetries   int
+	retryBackoff time.Duration
+	usageMu      sync.Mutex
+	usage        ApiUsage
+}
+
+// A ClientOption configures a [Client].
+type ClientOption func(*Client)
+
+// WithTimeout sets the maximum duration of a single attempt of a regular API request.
+func WithTimeout(d time.Duration) ClientOption {
+	return func(c *Client) {
+		c.timeout = d
+	}
+}
+
+// WithBodyTimeout sets the maximum duration of a single attempt of a request downloading a record body.
+// Bodies can be several megabytes long, so this is usually longer than the regular timeout.
+func WithBodyTimeout(d time.Duration) ClientOption {
+	return func(c *Client) {
+		c.bodyTimeout = d
+	}
+}
+
+// WithRetries sets how many times a failed request is retried before giving up.
+// Only server errors, timeouts and REQUEST_LIMIT_EXCEEDED errors are retried.
+// Requests creating records are only retried on 503 and REQUEST_LIMIT_EXCEEDED errors.
+func WithRetries(n int) ClientOption {
+	return func(c *Client) {
+		c.maxRetries = n
+	}
+}
+
+// WithRetryBackoff sets the delay before the first retry.
+// The delay doubles on every subsequent retry.
+func WithRetryBackoff(d time.Duration) ClientOption {
+	return func(c *Client) {
+		c.retryBackoff = d
+	}
+}
+
+// WithTransport sets the [http.RoundTripper] used to send the requests.
+// It is mostly useful to point the client to a fake server in tests.
+func WithTransport(rt http.RoundTripper) ClientOption {
+	return func(c *Client) {
+		c.httpClient.Transport = rt
+	}
+}
+
+// WithBaseUrl overrides the instance URL the requests are sent to.
+func WithBaseUrl(u string) ClientOption {
+	return func(c *Client) {
+		c.instanceUrl = u
+	}
 }
 
 // NewClient creates a new Client.
-// NewClient receives a [ScratchOrgInfo] with the Salesforce org information.
+// NewClient receives a [ScratchOrgInfo] with the Salesforce org information and a list of [ClientOption].
 // It returns a pointer to a new [Client].
-func NewClient(orgInfo ScratchOrgInfo) *Client {
-	return &Client{
-		accessToken: orgInfo.AccessToken,
-		instanceUrl: orgInfo.InstanceUrl,
-		apiVersion:  orgInfo.ApiVersion,
-		alias:       orgInfo.Alias,
+func NewClient(orgInfo ScratchOrgInfo, opts ...ClientOption) *Client {
+	c := &Client{
+		httpClient:   &http.Client{},
+		accessToken:  orgInfo.AccessToken,
+		instanceUrl:  orgInfo.InstanceUrl,
+		apiVersion:   orgInfo.ApiVersion,
+		alias:        orgInfo.Alias,
+		timeout:      DefaultTimeout,
+		bodyTimeout:  DefaultBodyTimeout,
+		maxRetries:   defaultMaxRetries,
+		retryBackoff: defaultRetryBackoff,
 	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
 }
 
 func (c *Client) doRequest(
+	ctx context.Context,
 	method, resource, body string,
 	queryParams map[string]string,
 	headers map[string]string,
 ) ([]byte, error) {
+	return c.doRequestWithTimeout(ctx, c.timeout, method, resource, body, queryParams, headers)
+}
+
+// doRequestWithTimeout sends a request to the Tooling API, retrying it with
+// exponential backoff when it fails with a transient error.
+// Every attempt is bounded by timeout.
+// Requests creating records are only retried when the org rejected them,
+// since a request that timed out may still have created its record.
+func (c *Client) doRequestWithTimeout(
+	ctx context.Context,
+	timeout time.Duration,
+	method, resource, body string,
+	queryParams map[string]string,
+	headers map[string]string,
+) ([]byte, error) {
+	u, err := c.resourceUrl(resource, queryParams)
+	if err != nil {
+		return nil, err
+	}
+
+	retryable := c.isRetryable
+	if method == "POST" {
+		retryable = c.isRejected
+	}
+
+	var resBody []byte
+	err = c.retry(ctx, retryable, func() error {
+		resBody, err = c.send(ctx, timeout, method, u, body, headers)
+		return err
+	})
+	return resBody, err
+}
+
+// resourceUrl returns the URL of the given resource of the Tooling API.
+func (c *Client) resourceUrl(resource string, queryParams map[string]string) (string, error) {
 	u, err := url.Parse(c.instanceUrl)
 	if err != nil {
-		return nil, fmt.Errorf("unexpected error parsing instance url")
+		return "", fmt.Errorf("unexpected error parsing instance url")
 	}
 
 	path := fmt.Sprintf("/services/data/v%s/tooling/%s", c.apiVersion, resource)
@@ -72,10 +194,65 @@ func (c *Client) doRequest(
 		q.Set(key, value)
 	}
 	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+// retry calls attempt until it succeeds, retrying it with exponential backoff
+// while it fails with an error accepted by retryable.
+func (c *Client) retry(
+	ctx context.Context,
+	retryable func(context.Context, error) bool,
+	attempt func() error,
+) error {
+	for n := 0; ; n++ {
+		err := attempt()
+		if err == nil || n >= c.maxRetries || !retryable(ctx, err) {
+			return err
+		}
+
+		t := time.NewTimer(c.backoff(n))
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return err
+		case <-t.C:
+		}
+	}
+}
 
-	client := &http.Client{}
+func (c *Client) send(
+	ctx context.Context,
+	timeout time.Duration,
+	method, u, body string,
+	headers map[string]string,
+) ([]byte, error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	res, err := c.open(ctx, method, u, body, headers)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	resBody, err := io.ReadAll(res.Body)
+	if err != nil {
+		return resBody, fmt.Errorf("error reading response body: %w", err)
+	}
+	return resBody, nil
+}
 
-	req, err := http.NewRequest(method, u.String(), strings.NewReader(body))
+// open sends a request and returns the response once its headers are received.
+// The body of a successful response must be closed by the caller.
+func (c *Client) open(
+	ctx context.Context,
+	method, u, body string,
+	headers map[string]string,
+) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, u, strings.NewReader(body))
 	if err != nil {
 		return nil, fmt.Errorf("error creating http request: %s", err)
 	}
@@ -85,31 +262,113 @@ func (c *Client) doRequest(
 		req.Header.Add(key, value)
 	}
 
-	res, err := client.Do(req)
+	res, err := c.httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("error doing request: %s", err)
+		return nil, fmt.Errorf("error doing request: %w", err)
 	}
+	c.updateApiUsage(res.Header)
 
-	defer res.Body.Close()
-	resBody, err := io.ReadAll(res.Body)
-	if err != nil {
-		return resBody, fmt.Errorf("error reading response body: %s", err)
+	if res.StatusCode > 399 {
+		defer res.Body.Close()
+		resBody, err := io.ReadAll(res.Body)
+		if err != nil {
+			return nil, fmt.Errorf("error reading response body: %w", err)
+		}
+		return nil, newResponseError(res, resBody)
 	}
 
-	if res.StatusCode > 399 {
-		return resBody, fmt.Errorf("request returned error code: %s", res.Status)
+	return res, nil
+}
+
+// isRetryable reports whether err is a transient error worth retrying.
+// Errors caused by the cancellation of ctx are never retried.
+func (c *Client) isRetryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
 	}
 
-	return resBody, nil
+	var resErr *ResponseError
+	if errors.As(err, &resErr) {
+		return resErr.StatusCode >= 500 || resErr.HasErrorCode(requestLimitExceeded)
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+
+	return errors.Is(err, context.DeadlineExceeded)
+}
+
+// isRejected reports whether err is a transient error returned by an org that
+// did not process the request, so that even a request creating a record can
+// be safely retried.
+func (c *Client) isRejected(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	var resErr *ResponseError
+	if errors.As(err, &resErr) {
+		return resErr.StatusCode == http.StatusServiceUnavailable || resErr.HasErrorCode(requestLimitExceeded)
+	}
+	return false
+}
+
+func (c *Client) backoff(attempt int) time.Duration {
+	d := c.retryBackoff << attempt
+	if d <= 0 || d > maxRetryBackoff {
+		d = maxRetryBackoff
+	}
+	jitter := time.Duration(rand.Int64N(int64(d)/2 + 1))
+	return d/2 + jitter
+}
+
+// An ApiErrorDetail is an error reported by the Salesforce API in the body of a failed response.
+type ApiErrorDetail struct {
+	Message   string `json:"message"`
+	ErrorCode string `json:"errorCode"`
+}
+
+// A ResponseError is returned when the Salesforce API answers with an error status code.
+type ResponseError struct {
+	StatusCode int
+	Status     string
+	Details    []ApiErrorDetail
+}
+
+func newResponseError(res *http.Response, body []byte) *ResponseError {
+	e := &ResponseError{StatusCode: res.StatusCode, Status: res.Status}
+	// The body is not always a list of errors, so it is fine if this fails.
+	_ = json.Unmarshal(body, &e.Details)
+	return e
 }
 
-func (c *Client) doQuery(query string, v any) error {
+func (e *ResponseError) Error() string {
+	s := fmt.Sprintf("request returned error code: %s", e.Status)
+	for _, d := range e.Details {
+		s += fmt.Sprintf(" (%s: %s)", d.ErrorCode, d.Message)
+	}
+	return s
+}
+
+// HasErrorCode reports whether the Salesforce API returned an error with the given code.
+func (e *ResponseError) HasErrorCode(code string) bool {
+	for _, d := range e.Details {
+		if d.ErrorCode == code {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Client) doQuery(ctx context.Context, query string, v any) error {
 	resource := "query"
 	q := map[string]string{
 		"q": query,
 	}
 
-	body, err := c.doRequest("GET", resource, "", q, nil)
+	body, err := c.doRequest(ctx, "GET", resource, "", q, nil)
 	if err != nil {
 		return err
 	}
@@ -125,13 +384,13 @@ func (c *Client) doQuery(query string, v any) error {
 // DoQuery performs a query request to the Salesforce API.
 // The response is typed to the type T, which represents the Salesforce object related to the query.
 // An error is returned if the requests fails or if the response cannot be unmarshalled to type T.
-func DoQuery[T any](c *Client, query string) (QueryResponse[T], error) {
+func DoQuery[T any](ctx context.Context, c *Client, query string) (QueryResponse[T], error) {
 	resource := "query"
 	q := map[string]string{
 		"q": query,
 	}
 
-	body, err := c.doRequest("GET", resource, "", q, nil)
+	body, err := c.doRequest(ctx, "GET", resource, "", q, nil)
 	if err != nil {
 		return QueryResponse[T]{}, err
 	}
@@ -147,7 +406,7 @@ func DoQuery[T any](c *Client, query string) (QueryResponse[T], error) {
 
 // PatchSObject performs an update request to the Salesforce API.
 // An error is returned if the payload cannot be serialized or if the request fails.
-func PatchSObject(c *Client, resource, id string, payload any) error {
+func PatchSObject(ctx context.Context, c *Client, resource, id string, payload any) error {
 	serializedPayload, err := json.Marshal(payload)
 	if err != nil {
 		return fmt.Errorf("error serializing payload: %s", err)
@@ -155,9 +414,21 @@ func PatchSObject(c *Client, resource, id string, payload any) error {
 
 	r := fmt.Sprintf("sobjects/%s/%s", resource, id)
 	h := map[string]string{"Content-Type": "application/json"}
-	_, err = c.doRequest("PATCH", r, string(serializedPayload), nil, h)
+	_, err = c.doRequest(ctx, "PATCH", r, string(serializedPayload), nil, h)
+	if err != nil {
+		return fmt.Errorf("error sending request to update record: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteSObject performs a delete request to the Salesforce API.
+// An error is returned if the request fails.
+func DeleteSObject(ctx context.Context, c *Client, resource, id string) error {
+	r := fmt.Sprintf("sobjects/%s/%s", resource, id)
+	_, err := c.doRequest(ctx, "DELETE", r, "", nil, nil)
 	if err != nil {
-		return fmt.Errorf("error sending request to update record: %s", err)
+		return fmt.Errorf("error sending request to delete record: %w", err)
 	}
 
 	return nil
@@ -169,7 +440,7 @@ func PatchSObject(c *Client, resource, id string, payload any) error {
 //   - The request fails
 //   - The response cannot be deserialized
 //   - The response contains an error (the response is also returned in this case)
-func PostSObject(c *Client, resource string, payload any) (PostSObjectResponse, error) {
+func PostSObject(ctx context.Context, c *Client, resource string, payload any) (PostSObjectResponse, error) {
 	serializedPayload, err := json.Marshal(payload)
 	if err != nil {
 		return PostSObjectResponse{}, fmt.Errorf("error serializing payload: %s", err)
@@ -177,9 +448,9 @@ func PostSObject(c *Client, resource string, payload any) (PostSObjectResponse,
 
 	r := fmt.Sprintf("sobjects/%s", resource)
 	h := map[string]string{"Content-Type": "application/json"}
-	body, err := c.doRequest("POST", r, string(serializedPayload), nil, h)
+	body, err := c.doRequest(ctx, "POST", r, string(serializedPayload), nil, h)
 	if err != nil {
-		return PostSObjectResponse{}, fmt.Errorf("error sending request to create new record: %s", err)
+		return PostSObjectResponse{}, fmt.Errorf("error sending request to create new record: %w", err)
 	}
 
 	var unserializedBody PostSObjectResponse
@@ -196,14 +467,58 @@ func PostSObject(c *Client, resource string, payload any) (PostSObjectResponse,
 }
 
 // GetSObjectBody performs a request to retrieve the body of an Object of type resource with the given id.
+// Each attempt is bounded by the client body timeout instead of the regular one.
 // An error is returned if the request fails.
-func GetSObjectBody(c *Client, resource, id string) (string, error) {
+func GetSObjectBody(ctx context.Context, c *Client, resource, id string) (string, error) {
 	r := fmt.Sprintf("sobjects/%s/%s/Body", resource, id)
 
-	body, err := c.doRequest("GET", r, "", nil, nil)
+	body, err := c.doRequestWithTimeout(ctx, c.bodyTimeout, "GET", r, "", nil, nil)
 	if err != nil {
-		return "", fmt.Errorf("error sending request to retrieve record body: %s", err)
+		return "", fmt.Errorf("error sending request to retrieve record body: %w", err)
 	}
 
 	return string(body), nil
 }
+
+// OpenSObjectBody starts the download of the body of an Object of type resource with the given id.
+// The body is read from the returned reader as it is received, and the reader must be closed.
+// The size is the length of the body, or -1 if the response does not report it.
+// The request is retried until the response starts, and the whole download is
+// bounded by the client body timeout.
+func OpenSObjectBody(ctx context.Context, c *Client, resource, id string) (io.ReadCloser, int64, error) {
+	u, err := c.resourceUrl(fmt.Sprintf("sobjects/%s/%s/Body", resource, id), nil)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var res *http.Response
+	var cancel context.CancelFunc
+	err = c.retry(ctx, c.isRetryable, func() error {
+		attemptCtx := ctx
+		cancel = func() {}
+		if c.bodyTimeout > 0 {
+			attemptCtx, cancel = context.WithTimeout(ctx, c.bodyTimeout)
+		}
+		res, err = c.open(attemptCtx, "GET", u, "", nil)
+		if err != nil {
+			cancel()
+		}
+		return err
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("error sending request to retrieve record body: %w", err)
+	}
+
+	return cancelOnClose{ReadCloser: res.Body, cancel: cancel}, res.ContentLength, nil
+}
+
+// cancelOnClose cancels the context of a response when its body is closed.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (r cancelOnClose) Close() error {
+	defer r.cancel()
+	return r.ReadCloser.Close()
+}
@@ -0,0 +1,294 @@
+package salesforce_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	sf "github.com/cdelmoral/apexlogs/internal/salesforce"
+	"github.com/cdelmoral/apexlogs/internal/salesforce/sftest"
+)
+
+func TestDoQuery(t *testing.T) {
+	srv := sftest.New(sftest.FixturesDir())
+	c := srv.Client()
+
+	res, err := sf.DoQuery[sf.ApexLog](context.Background(), c, sf.SelectApexLogs())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if res.TotalSize != 10 || len(res.Records) != 10 {
+		t.Fatalf("expected 10 apex logs, got %d", len(res.Records))
+	}
+	if res.Records[0].StartTime < res.Records[9].StartTime {
+		t.Errorf("expected apex logs sorted by start time descending")
+	}
+}
+
+func TestGetSObjectBody(t *testing.T) {
+	srv := sftest.New(sftest.FixturesDir())
+	c := srv.Client()
+
+	body, err := sf.GetSObjectBody(context.Background(), c, "ApexLog", "07L0500000G0f5pEAB")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !strings.Contains(body, "EXECUTION_STARTED") {
+		t.Errorf("unexpected body: %q", body)
+	}
+}
+
+func TestOpenSObjectBody(t *testing.T) {
+	srv := sftest.New(sftest.FixturesDir())
+	c := srv.Client()
+
+	r, size, err := sf.OpenSObjectBody(context.Background(), c, "ApexLog", "07L0500000G0f5pEAB")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer r.Close()
+
+	b, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("unexpected error reading body: %s", err)
+	}
+	if !strings.Contains(string(b), "EXECUTION_STARTED") {
+		t.Errorf("unexpected body: %q", b)
+	}
+	if size != -1 && size != int64(len(b)) {
+		t.Errorf("expected the size of the body, got %d for %d bytes", size, len(b))
+	}
+
+	if _, _, err := sf.OpenSObjectBody(context.Background(), c, "ApexLog", "07L000000000000AAA"); err == nil {
+		t.Errorf("expected an error opening a missing log")
+	}
+}
+
+func TestPostAndPatchSObject(t *testing.T) {
+	srv := sftest.New(sftest.FixturesDir())
+	c := srv.Client()
+	ctx := context.Background()
+
+	res, err := sf.PostSObject(ctx, c, "TraceFlag", map[string]string{"TracedEntityId": "005A", "LogType": "DEVELOPER_LOG"})
+	if err != nil {
+		t.Fatalf("unexpected error creating record: %s", err)
+	}
+
+	err = sf.PatchSObject(ctx, c, "TraceFlag", res.Id, map[string]string{"ExpirationDate": "2024-06-16T00:00:00.000+0000"})
+	if err != nil {
+		t.Fatalf("unexpected error updating record: %s", err)
+	}
+
+	q, err := sf.DoQuery[sf.TraceFlag](ctx, c, sf.SelectDebugLogTraceFlagByTracedId("005A"))
+	if err != nil {
+		t.Fatalf("unexpected error querying record: %s", err)
+	}
+	if q.TotalSize != 1 || q.Records[0].ExpirationDate != "2024-06-16T00:00:00.000+0000" {
+		t.Errorf("unexpected trace flags: %+v", q.Records)
+	}
+}
+
+func TestRetries(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		errorCode string
+		failures  int
+		wantErr   bool
+		wantCalls int
+	}{
+		{"server error", http.StatusServiceUnavailable, "", 2, false, 3},
+		{"request limit", http.StatusForbidden, "REQUEST_LIMIT_EXCEEDED", 1, false, 2},
+		{"too many failures", http.StatusInternalServerError, "", 4, true, 4},
+		{"not retryable", http.StatusBadRequest, "MALFORMED_QUERY", 1, true, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := sftest.New(sftest.FixturesDir())
+			srv.FailNext(tt.failures, tt.status, tt.errorCode)
+			c := srv.Client(sf.WithRetries(3))
+
+			_, err := sf.DoQuery[sf.ApexLog](context.Background(), c, sf.SelectApexLogs())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var resErr *sf.ResponseError
+			if tt.wantErr && !errors.As(err, &resErr) {
+				t.Errorf("expected a response error, got %T", err)
+			}
+			if got := len(srv.Requests()); got != tt.wantCalls {
+				t.Errorf("expected %d requests, got %d", tt.wantCalls, got)
+			}
+		})
+	}
+}
+
+func TestCreateRetries(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		errorCode string
+		wantErr   bool
+		wantCalls int
+	}{
+		{"service unavailable", http.StatusServiceUnavailable, "", false, 2},
+		{"request limit", http.StatusForbidden, "REQUEST_LIMIT_EXCEEDED", false, 2},
+		{"server error", http.StatusInternalServerError, "", true, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := sftest.New(sftest.FixturesDir())
+			srv.FailNext(1, tt.status, tt.errorCode)
+			c := srv.Client(sf.WithRetries(3), sf.WithRetryBackoff(time.Millisecond))
+
+			_, err := sf.PostSObject(context.Background(), c, "TraceFlag", map[string]string{"TracedEntityId": "005A", "LogType": "DEVELOPER_LOG"})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := len(srv.Requests()); got != tt.wantCalls {
+				t.Errorf("expected %d requests, got %d", tt.wantCalls, got)
+			}
+		})
+	}
+}
+
+func TestCreateTimeoutIsNotRetried(t *testing.T) {
+	srv := sftest.New(sftest.FixturesDir())
+	srv.SetLatency(50 * time.Millisecond)
+	c := srv.Client(sf.WithTimeout(5*time.Millisecond), sf.WithRetries(1))
+
+	_, err := sf.PostSObject(context.Background(), c, "TraceFlag", map[string]string{"TracedEntityId": "005A", "LogType": "DEVELOPER_LOG"})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded error, got %v", err)
+	}
+	if got := len(srv.Requests()); got != 1 {
+		t.Errorf("expected 1 request, got %d", got)
+	}
+}
+
+func TestTimeoutIsRetried(t *testing.T) {
+	srv := sftest.New(sftest.FixturesDir())
+	srv.SetLatency(50 * time.Millisecond)
+	c := srv.Client(sf.WithTimeout(5*time.Millisecond), sf.WithRetries(1))
+
+	_, err := sf.DoQuery[sf.ApexLog](context.Background(), c, sf.SelectApexLogs())
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded error, got %v", err)
+	}
+	if got := len(srv.Requests()); got != 2 {
+		t.Errorf("expected 2 requests, got %d", got)
+	}
+}
+
+func TestCancelledRequestIsNotRetried(t *testing.T) {
+	srv := sftest.New(sftest.FixturesDir())
+	srv.SetLatency(time.Second)
+	c := srv.Client(sf.WithRetries(3))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, err := sf.GetSObjectBody(ctx, c, "ApexLog", "07L0500000G0f5pEAB")
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected cancelled error, got %v", err)
+	}
+	if time.Since(start) > 500*time.Millisecond {
+		t.Errorf("request was not cancelled promptly")
+	}
+}
+
+func TestApiUsage(t *testing.T) {
+	srv := sftest.New(sftest.FixturesDir())
+	srv.SetApiUsage(99, 5000)
+	c := srv.Client()
+
+	if _, ok := c.ApiUsage(); ok {
+		t.Fatalf("expected no api usage before the first request")
+	}
+
+	_, err := sf.DoQuery[sf.ApexLog](context.Background(), c, sf.SelectApexLogs())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	u, ok := c.ApiUsage()
+	if !ok || u.Used != 100 || u.Max != 5000 {
+		t.Errorf("unexpected api usage: %+v", u)
+	}
+}
+
+func TestExecuteAnonymous(t *testing.T) {
+	srv := sftest.New(sftest.FixturesDir())
+	c := srv.Client()
+
+	res, err := sf.ExecuteAnonymous(context.Background(), c, "System.debug('hello');")
+	if err != nil || res.Err() != nil {
+		t.Fatalf("unexpected error: %v, %v", err, res.Err())
+	}
+	reqs := srv.Requests()
+	if len(reqs) != 1 || reqs[0].Resource != "executeAnonymous/" || reqs[0].Query != "System.debug('hello');" {
+		t.Errorf("unexpected requests %+v", reqs)
+	}
+
+	srv.SetExecuteAnonymous(func(code string) (sf.ExecuteAnonymousResult, string) {
+		return sf.ExecuteAnonymousResult{Line: 1, Column: 7, CompileProblem: "Unexpected token 'debug'."}, ""
+	})
+	res, err = sf.ExecuteAnonymous(context.Background(), c, "System debug('hello');")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := res.Err(); err == nil || err.Error() != "compile error at line 1, column 7: Unexpected token 'debug'." {
+		t.Errorf("unexpected compile error %v", err)
+	}
+
+	// The code must not run twice, so failures are not retried.
+	srv.FailNext(1, http.StatusInternalServerError, "")
+	if _, err := sf.ExecuteAnonymous(context.Background(), c, "insert new Account(Name = 'x');"); err == nil {
+		t.Errorf("expected the server error")
+	}
+	if n := len(srv.Requests()); n != 3 {
+		t.Errorf("expected the failed execution not to be retried, got %d requests", n)
+	}
+}
+
+func TestRunTestsAsynchronous(t *testing.T) {
+	srv := sftest.New(sftest.FixturesDir())
+	c := srv.Client()
+
+	jobId, err := sf.RunTestsAsynchronous(context.Background(), c, []string{"AccountTest", "ContactTest"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	reqs := srv.Requests()
+	if len(reqs) != 1 || reqs[0].Resource != "runTestsAsynchronous/" || !strings.Contains(reqs[0].Body, `"classNames":"AccountTest,ContactTest"`) {
+		t.Errorf("unexpected requests %+v", reqs)
+	}
+
+	items, err := sf.DoQuery[sf.ApexTestQueueItem](context.Background(), c, sf.SelectApexTestQueueItems(jobId))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(items.Records) != 2 || items.Records[0].ApexClass.Name != "AccountTest" || !items.Records[0].Finished() {
+		t.Errorf("unexpected queue items %+v", items.Records)
+	}
+
+	results, err := sf.DoQuery[sf.ApexTestResult](context.Background(), c, sf.SelectApexTestResults(jobId))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(results.Records) != 2 {
+		t.Fatalf("expected a result per class, got %+v", results.Records)
+	}
+	if r := results.Records[1]; r.ApexClass.Name != "ContactTest" || r.Outcome != sf.TestOutcomePass || r.ApexLogId == "" {
+		t.Errorf("unexpected test result %+v", r)
+	}
+}
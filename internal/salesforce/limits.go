@@ -0,0 +1,70 @@
+package salesforce
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const limitInfoHeader = "Sforce-Limit-Info"
+
+// ApiUsage contains the daily API requests used by the org and its limit,
+// as reported by the Sforce-Limit-Info response header.
+type ApiUsage struct {
+	Used int
+	Max  int
+}
+
+// Percent returns the percentage of the daily API limit used by the org.
+func (u ApiUsage) Percent() int {
+	if u.Max == 0 {
+		return 0
+	}
+	return u.Used * 100 / u.Max
+}
+
+// ApiUsage returns the latest API usage reported by the Salesforce API.
+// The second value is false if no response has reported it yet.
+func (c *Client) ApiUsage() (ApiUsage, bool) {
+	c.usageMu.Lock()
+	defer c.usageMu.Unlock()
+	return c.usage, c.usage.Max > 0
+}
+
+func (c *Client) updateApiUsage(h http.Header) {
+	u, ok := parseLimitInfo(h.Get(limitInfoHeader))
+	if !ok {
+		return
+	}
+	c.usageMu.Lock()
+	c.usage = u
+	c.usageMu.Unlock()
+}
+
+// parseLimitInfo parses a header value like "api-usage=18/5000".
+// The header can contain several comma separated limits, only api-usage is considered.
+func parseLimitInfo(v string) (ApiUsage, bool) {
+	for _, part := range strings.Split(v, ",") {
+		name, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok || name != "api-usage" {
+			continue
+		}
+
+		used, limit, ok := strings.Cut(value, "/")
+		if !ok {
+			return ApiUsage{}, false
+		}
+
+		u, err := strconv.Atoi(used)
+		if err != nil {
+			return ApiUsage{}, false
+		}
+		m, err := strconv.Atoi(limit)
+		if err != nil {
+			return ApiUsage{}, false
+		}
+
+		return ApiUsage{Used: u, Max: m}, true
+	}
+	return ApiUsage{}, false
+}
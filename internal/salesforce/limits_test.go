@@ -0,0 +1,23 @@
+package salesforce
+
+import "testing"
+
+func TestParseLimitInfo(t *testing.T) {
+	tests := []struct {
+		in     string
+		want   ApiUsage
+		wantOk bool
+	}{
+		{"api-usage=18/5000", ApiUsage{18, 5000}, true},
+		{"per-app-api-usage=1/100(appName=x), api-usage=7/15000", ApiUsage{7, 15000}, true},
+		{"", ApiUsage{}, false},
+		{"api-usage=abc", ApiUsage{}, false},
+	}
+
+	for _, tt := range tests {
+		got, ok := parseLimitInfo(tt.in)
+		if got != tt.want || ok != tt.wantOk {
+			t.Errorf("parseLimitInfo(%q) = %v, %v; want %v, %v", tt.in, got, ok, tt.want, tt.wantOk)
+		}
+	}
+}
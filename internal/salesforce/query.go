@@ -2,6 +2,15 @@ package salesforce
 
 import (
 	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultApexLogsLimit is the number of Apex Logs selected when no limit is given.
+	DefaultApexLogsLimit = 100
+	// MaxApexLogsLimit is the maximum number of records returned by a single query.
+	MaxApexLogsLimit = 2000
 )
 
 const apexLogsQuery = `
@@ -17,9 +26,9 @@ SELECT
   StartTime,
   DurationMilliseconds,
   LogLength
-FROM ApexLog
+FROM ApexLog%s
 ORDER BY StartTime DESC
-LIMIT 100
+LIMIT %d
 `
 
 const debugLogsQuery = `
@@ -94,9 +103,49 @@ type TraceFlag struct {
 	LogType        string
 }
 
+// An ApexLogFilter restricts the Apex Logs selected by [SelectApexLogsWhere].
+// Empty fields do not restrict the selection.
+type ApexLogFilter struct {
+	// Operation may contain the % and _ wildcards of the SOQL LIKE operator.
+	Operation string
+	Status    string
+	LogUserId string
+	Since     time.Time
+	// Limit is capped to [MaxApexLogsLimit], zero means [DefaultApexLogsLimit].
+	Limit int
+}
+
 // SelectApexLogs returns a SOQL query to select the last 100 Apex Logs.
 func SelectApexLogs() string {
-	return apexLogsQuery
+	return SelectApexLogsWhere(ApexLogFilter{})
+}
+
+// SelectApexLogsWhere returns a SOQL query to select the last Apex Logs matching f.
+func SelectApexLogsWhere(f ApexLogFilter) string {
+	var conditions []string
+	if f.Operation != "" {
+		conditions = append(conditions, fmt.Sprintf("Operation LIKE '%s'", escapeSoql(f.Operation)))
+	}
+	if f.Status != "" {
+		conditions = append(conditions, fmt.Sprintf("Status = '%s'", escapeSoql(f.Status)))
+	}
+	if f.LogUserId != "" {
+		conditions = append(conditions, fmt.Sprintf("LogUserId = '%s'", escapeSoql(f.LogUserId)))
+	}
+	if !f.Since.IsZero() {
+		conditions = append(conditions, fmt.Sprintf("StartTime >= %s", f.Since.UTC().Format(time.RFC3339)))
+	}
+
+	var where string
+	if len(conditions) > 0 {
+		where = "\nWHERE " + strings.Join(conditions, "\nAND ")
+	}
+
+	limit := f.Limit
+	if limit <= 0 {
+		limit = DefaultApexLogsLimit
+	}
+	return fmt.Sprintf(apexLogsQuery, where, min(limit, MaxApexLogsLimit))
 }
 
 // SelectDebugLogByDeveloperName returns a SOQL query to select a Debug Level by Developer Name.
@@ -108,3 +157,8 @@ func SelectDebugLogByDeveloperName(n string) string {
 func SelectDebugLogTraceFlagByTracedId(i string) string {
 	return fmt.Sprintf(traceFlagQuery, i)
 }
+
+// escapeSoql escapes the characters with a special meaning inside a SOQL string literal.
+func escapeSoql(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s)
+}
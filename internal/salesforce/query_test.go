@@ -0,0 +1,38 @@
+package salesforce_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	sf "github.com/cdelmoral/apexlogs/internal/salesforce"
+)
+
+func TestSelectApexLogsWhere(t *testing.T) {
+	q := sf.SelectApexLogsWhere(sf.ApexLogFilter{
+		Operation: "/apex/%",
+		Status:    "Can't parse",
+		LogUserId: "005A",
+		Since:     time.Date(2024, 6, 15, 12, 0, 0, 0, time.FixedZone("PDT", -7*60*60)),
+		Limit:     5000,
+	})
+
+	for _, want := range []string{
+		"WHERE Operation LIKE '/apex/%'",
+		"AND Status = 'Can\\'t parse'",
+		"AND LogUserId = '005A'",
+		"AND StartTime >= 2024-06-15T19:00:00Z",
+		"LIMIT 2000",
+	} {
+		if !strings.Contains(q, want) {
+			t.Errorf("expected query to contain %q, got:\n%s", want, q)
+		}
+	}
+}
+
+func TestSelectApexLogs(t *testing.T) {
+	q := sf.SelectApexLogs()
+	if strings.Contains(q, "WHERE") || !strings.Contains(q, "LIMIT 100") {
+		t.Errorf("unexpected query:\n%s", q)
+	}
+}
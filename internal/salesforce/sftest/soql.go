@@ -0,0 +1,161 @@
+package sftest
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	fromRe       = regexp.MustCompile(`(?i)\bFROM\s+(\w+)`)
+	whereRe      = regexp.MustCompile(`(?is)\bWHERE\s+(.*?)(?:\bORDER\s+BY\b|\bLIMIT\b|$)`)
+	orderByRe    = regexp.MustCompile(`(?i)\bORDER\s+BY\s+(\w+)(?:\s+(ASC|DESC))?`)
+	limitRe      = regexp.MustCompile(`(?i)\bLIMIT\s+(\d+)`)
+	conditionRe  = regexp.MustCompile(`^(\w+)\s*(=|!=|>=|<=|>|<|LIKE)\s*(?:'((?:[^'\\]|\\.)*)'|(\S+))$`)
+	andSeparator = regexp.MustCompile(`(?i)\s+AND\s+`)
+)
+
+// A query is the subset of SOQL understood by the fake: a single object,
+// conditions joined by AND, a single ORDER BY field and a LIMIT.
+type query struct {
+	from       string
+	conditions []condition
+	orderBy    string
+	desc       bool
+	limit      int
+}
+
+type condition struct {
+	field string
+	op    string
+	value any
+}
+
+func parseQuery(q string) (query, error) {
+	var res query
+
+	m := fromRe.FindStringSubmatch(q)
+	if m == nil {
+		return res, fmt.Errorf("unexpected query without FROM clause: %s", q)
+	}
+	res.from = m[1]
+
+	if m := whereRe.FindStringSubmatch(q); m != nil {
+		for _, c := range andSeparator.Split(strings.TrimSpace(m[1]), -1) {
+			cm := conditionRe.FindStringSubmatch(strings.TrimSpace(c))
+			if cm == nil {
+				return res, fmt.Errorf("unsupported condition: %s", c)
+			}
+			cond := condition{field: cm[1], op: strings.ToUpper(cm[2])}
+			switch {
+			case cm[4] == "":
+				cond.value = cm[3]
+			case strings.EqualFold(cm[4], "true"), strings.EqualFold(cm[4], "false"):
+				cond.value = strings.EqualFold(cm[4], "true")
+			default:
+				if f, err := strconv.ParseFloat(cm[4], 64); err == nil {
+					cond.value = f
+				} else {
+					// Unquoted date time literals are compared as strings.
+					cond.value = cm[4]
+				}
+			}
+			res.conditions = append(res.conditions, cond)
+		}
+	}
+
+	if m := orderByRe.FindStringSubmatch(q); m != nil {
+		res.orderBy = m[1]
+		res.desc = strings.EqualFold(m[2], "DESC")
+	}
+
+	if m := limitRe.FindStringSubmatch(q); m != nil {
+		res.limit, _ = strconv.Atoi(m[1])
+	}
+
+	return res, nil
+}
+
+func (q query) apply(records []map[string]any) []map[string]any {
+	var res []map[string]any
+	for _, r := range records {
+		if q.matches(r) {
+			res = append(res, r)
+		}
+	}
+
+	if q.orderBy != "" {
+		sort.SliceStable(res, func(i, j int) bool {
+			c := compare(res[i][q.orderBy], res[j][q.orderBy])
+			if q.desc {
+				return c > 0
+			}
+			return c < 0
+		})
+	}
+
+	if q.limit > 0 && len(res) > q.limit {
+		res = res[:q.limit]
+	}
+	return res
+}
+
+func (q query) matches(r map[string]any) bool {
+	for _, c := range q.conditions {
+		v := r[c.field]
+		var ok bool
+		switch c.op {
+		case "=":
+			ok = compare(v, c.value) == 0
+		case "!=":
+			ok = compare(v, c.value) != 0
+		case ">":
+			ok = compare(v, c.value) > 0
+		case ">=":
+			ok = compare(v, c.value) >= 0
+		case "<":
+			ok = compare(v, c.value) < 0
+		case "<=":
+			ok = compare(v, c.value) <= 0
+		case "LIKE":
+			ok = like(fmt.Sprint(v), fmt.Sprint(c.value))
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func compare(a, b any) int {
+	af, aok := toFloat(a)
+	bf, bok := toFloat(b)
+	if aok && bok {
+		switch {
+		case af < bf:
+			return -1
+		case af > bf:
+			return 1
+		}
+		return 0
+	}
+	return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
+
+func toFloat(v any) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	}
+	return 0, false
+}
+
+func like(s, pattern string) bool {
+	re := "^" + strings.ReplaceAll(regexp.QuoteMeta(pattern), "%", ".*") + "$"
+	ok, _ := regexp.MatchString("(?i)"+re, s)
+	return ok
+}
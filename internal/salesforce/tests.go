@@ -0,0 +1,134 @@
+package salesforce
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// The statuses of an [ApexTestQueueItem] once its tests are no longer running.
+const (
+	TestQueueCompleted = "Completed"
+	TestQueueFailed    = "Failed"
+	TestQueueAborted   = "Aborted"
+)
+
+// The outcomes of an [ApexTestResult].
+const (
+	TestOutcomePass        = "Pass"
+	TestOutcomeFail        = "Fail"
+	TestOutcomeCompileFail = "CompileFail"
+	TestOutcomeSkip        = "Skip"
+)
+
+const apexTestQueueItemsQuery = `
+SELECT
+  Id,
+  ApexClassId,
+  ApexClass.Name,
+  ExtendedStatus,
+  ParentJobId,
+  Status
+FROM ApexTestQueueItem
+WHERE ParentJobId = '%s'
+`
+
+const apexTestResultsQuery = `
+SELECT
+  Id,
+  ApexClass.Name,
+  ApexLogId,
+  AsyncApexJobId,
+  Message,
+  MethodName,
+  Outcome,
+  QueueItemId,
+  RunTime,
+  StackTrace,
+  TestTimestamp
+FROM ApexTestResult
+WHERE AsyncApexJobId = '%s'
+ORDER BY TestTimestamp
+`
+
+// An ApexClassName is the Name of the Apex Class related to a record.
+type ApexClassName struct {
+	Name string
+}
+
+// An ApexTestQueueItem represents the tests of an Apex Class enqueued by [RunTestsAsynchronous].
+type ApexTestQueueItem struct {
+	Id             string
+	ApexClassId    string
+	ApexClass      ApexClassName
+	ExtendedStatus string
+	ParentJobId    string
+	Status         string
+}
+
+// Finished reports whether the tests of the item are no longer running.
+func (i ApexTestQueueItem) Finished() bool {
+	switch i.Status {
+	case TestQueueCompleted, TestQueueFailed, TestQueueAborted:
+		return true
+	}
+	return false
+}
+
+// An ApexTestResult represents the outcome of a test method.
+// ApexLogId is the Apex Log the test generated, empty when it was not traced.
+type ApexTestResult struct {
+	Id             string
+	ApexClass      ApexClassName
+	ApexLogId      string
+	AsyncApexJobId string
+	Message        string
+	MethodName     string
+	Outcome        string
+	QueueItemId    string
+	// RunTime is in milliseconds.
+	RunTime       int
+	StackTrace    string
+	TestTimestamp string
+}
+
+// SelectApexTestQueueItems returns a SOQL query to select the queue items of the test run with the given job id.
+func SelectApexTestQueueItems(jobId string) string {
+	return fmt.Sprintf(apexTestQueueItemsQuery, escapeSoql(jobId))
+}
+
+// SelectApexTestResults returns a SOQL query to select the results of the test run with the given job id.
+func SelectApexTestResults(jobId string) string {
+	return fmt.Sprintf(apexTestResultsQuery, escapeSoql(jobId))
+}
+
+// RunTestsAsynchronous enqueues the test methods of the given Apex classes and
+// returns the id of the job running them.
+// The request is not retried, so the tests are never enqueued twice.
+func RunTestsAsynchronous(ctx context.Context, c *Client, classNames []string) (string, error) {
+	payload, err := json.Marshal(map[string]string{
+		"classNames": strings.Join(classNames, ","),
+		"testLevel":  "RunSpecifiedTests",
+	})
+	if err != nil {
+		return "", fmt.Errorf("error serializing payload: %s", err)
+	}
+	u, err := c.resourceUrl("runTestsAsynchronous/", nil)
+	if err != nil {
+		return "", err
+	}
+
+	h := map[string]string{"Content-Type": "application/json"}
+	body, err := c.send(ctx, c.timeout, "POST", u, string(payload), h)
+	if err != nil {
+		return "", fmt.Errorf("error sending request to run tests: %w", err)
+	}
+
+	// The response is the job id as a JSON string.
+	var jobId string
+	if err := json.Unmarshal(body, &jobId); err != nil {
+		return "", fmt.Errorf("unexpected error parsing response body: %s", err)
+	}
+	return jobId, nil
+}
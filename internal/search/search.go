@@ -0,0 +1,310 @@
+// Package search finds text across the bodies of many apex logs.
+//
+// The bodies are indexed by their trigrams, the sequences of three bytes they
+// contain. A search first narrows the logs down to the ones containing every
+// trigram of the searched text, and only scans the lines of those logs.
+package search
+
+import (
+	"fmt"
+	"regexp"
+	"regexp/syntax"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// DefaultLimit is the maximum number of results returned by a search when none is given.
+const DefaultLimit = 1000
+
+type trigram [3]byte
+
+// A Query is a text to search for.
+type Query struct {
+	// Pattern is the text to search for, or a regular expression when Regex is true.
+	Pattern    string
+	Regex      bool
+	IgnoreCase bool
+}
+
+// ParseQuery creates a [Query] from user input.
+// Input wrapped in slashes, like /Exception: .*null/, is a regular expression.
+// The search ignores case unless the input contains upper case letters.
+func ParseQuery(s string) Query {
+	q := Query{Pattern: s}
+	if len(s) > 2 && strings.HasPrefix(s, "/") && strings.HasSuffix(s, "/") {
+		q.Pattern = s[1 : len(s)-1]
+		q.Regex = true
+	}
+	q.IgnoreCase = IgnoresCase(q.Pattern, q.Regex)
+	return q
+}
+
+// IgnoresCase reports whether a pattern typed by the user is matched ignoring
+// case, which is when it has no upper case letters. The letters of the escapes
+// of a regular expression, like \S or \p{Lu}, are not counted.
+func IgnoresCase(pattern string, regex bool) bool {
+	if !regex {
+		return !strings.ContainsFunc(pattern, unicode.IsUpper)
+	}
+	for i := 0; i < len(pattern); i++ {
+		if pattern[i] == '\\' {
+			i++
+			if i+1 >= len(pattern) || strings.IndexByte("pPx", pattern[i]) < 0 {
+				continue
+			}
+			// Skip the name of a class like \pL or \p{Lu}, or a code like \x4A or \x{263A}.
+			switch {
+			case pattern[i+1] == '{':
+				if end := strings.IndexByte(pattern[i:], '}'); end >= 0 {
+					i += end
+				}
+			case pattern[i] == 'x':
+				i += 2
+			default:
+				i++
+			}
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(pattern[i:])
+		if unicode.IsUpper(r) {
+			return false
+		}
+		i += size - 1
+	}
+	return true
+}
+
+// A Result is a line matching a query.
+type Result struct {
+	LogId string
+	// Line is the zero based index of the matching line in the log body.
+	Line int
+	Text string
+}
+
+// An Index is a trigram index of log bodies.
+// It is safe for concurrent use.
+type Index struct {
+	mu       sync.RWMutex
+	load     func(id string) (string, error)
+	ids      []string
+	docs     map[string]int
+	trigrams map[trigram][]int
+}
+
+// NewIndex creates an empty [Index].
+// The index does not keep the bodies in memory, load is called to read the
+// body of the candidate logs of a search.
+func NewIndex(load func(id string) (string, error)) *Index {
+	return &Index{
+		load:     load,
+		docs:     map[string]int{},
+		trigrams: map[trigram][]int{},
+	}
+}
+
+// Add indexes the body of the log with the given id.
+// Logs that are already indexed are ignored, since log bodies never change.
+func (ix *Index) Add(id, body string) {
+	ix.mu.RLock()
+	_, ok := ix.docs[id]
+	ix.mu.RUnlock()
+	if ok {
+		return
+	}
+
+	// Extract the trigrams before taking the write lock, it is the slow part.
+	set := trigrams(strings.ToLower(body))
+
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	if _, ok := ix.docs[id]; ok {
+		return
+	}
+	doc := len(ix.ids)
+	ix.ids = append(ix.ids, id)
+	ix.docs[id] = doc
+	for t := range set {
+		ix.trigrams[t] = append(ix.trigrams[t], doc)
+	}
+}
+
+// AddLoaded indexes the body of the log with the given id, reading it with
+// the load function of the index, like [Index.Add].
+func (ix *Index) AddLoaded(id string) error {
+	ix.mu.RLock()
+	_, ok := ix.docs[id]
+	ix.mu.RUnlock()
+	if ok {
+		return nil
+	}
+
+	body, err := ix.load(id)
+	if err != nil {
+		return err
+	}
+	ix.Add(id, body)
+	return nil
+}
+
+// Len returns the number of indexed logs.
+func (ix *Index) Len() int {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	return len(ix.ids)
+}
+
+// Search returns up to limit lines matching q, grouped by log in indexing order.
+// A limit lower or equal to zero means [DefaultLimit].
+func (ix *Index) Search(q Query, limit int) ([]Result, error) {
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+	if q.Pattern == "" {
+		return nil, nil
+	}
+
+	match, literal, err := matcher(q)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []Result
+	for _, id := range ix.candidates(strings.ToLower(literal)) {
+		body, err := ix.load(id)
+		if err != nil {
+			return results, fmt.Errorf("error loading log %s: %w", id, err)
+		}
+
+		for i, line := range strings.Split(body, "\n") {
+			if !match(line) {
+				continue
+			}
+			results = append(results, Result{LogId: id, Line: i, Text: line})
+			if len(results) >= limit {
+				return results, nil
+			}
+		}
+	}
+	return results, nil
+}
+
+// candidates returns the logs containing every trigram of literal.
+func (ix *Index) candidates(literal string) []string {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	if len(literal) < 3 {
+		return append([]string(nil), ix.ids...)
+	}
+
+	var docs []int
+	for i, t := range sortedTrigrams(literal) {
+		postings := ix.trigrams[t]
+		if i == 0 {
+			docs = append([]int(nil), postings...)
+		} else {
+			docs = intersect(docs, postings)
+		}
+		if len(docs) == 0 {
+			return nil
+		}
+	}
+
+	ids := make([]string, 0, len(docs))
+	for _, d := range docs {
+		ids = append(ids, ix.ids[d])
+	}
+	return ids
+}
+
+// matcher returns a function matching the lines of q, and a literal every
+// matching line contains, used to narrow down the candidate logs.
+func matcher(q Query) (func(string) bool, string, error) {
+	if !q.Regex {
+		if q.IgnoreCase {
+			p := strings.ToLower(q.Pattern)
+			return func(s string) bool { return strings.Contains(strings.ToLower(s), p) }, q.Pattern, nil
+		}
+		return func(s string) bool { return strings.Contains(s, q.Pattern) }, q.Pattern, nil
+	}
+
+	p := q.Pattern
+	if q.IgnoreCase {
+		p = "(?i)" + p
+	}
+	re, err := regexp.Compile(p)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid regular expression: %w", err)
+	}
+	return re.MatchString, requiredLiteral(q.Pattern), nil
+}
+
+// requiredLiteral returns the longest literal text that every match of the
+// regular expression contains, or an empty string if there is none.
+func requiredLiteral(pattern string) string {
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return ""
+	}
+	re = re.Simplify()
+
+	switch re.Op {
+	case syntax.OpLiteral:
+		return string(re.Rune)
+	case syntax.OpConcat:
+		var longest string
+		for _, sub := range re.Sub {
+			if sub.Op == syntax.OpLiteral && len(string(sub.Rune)) > len(longest) {
+				longest = string(sub.Rune)
+			}
+		}
+		return longest
+	}
+	return ""
+}
+
+func trigrams(s string) map[trigram]struct{} {
+	set := make(map[trigram]struct{})
+	for i := 0; i+3 <= len(s); i++ {
+		if s[i] == '\n' || s[i+1] == '\n' || s[i+2] == '\n' {
+			continue
+		}
+		set[trigram{s[i], s[i+1], s[i+2]}] = struct{}{}
+	}
+	return set
+}
+
+func sortedTrigrams(s string) []trigram {
+	set := trigrams(s)
+	ts := make([]trigram, 0, len(set))
+	for t := range set {
+		ts = append(ts, t)
+	}
+	sort.Slice(ts, func(i, j int) bool {
+		return string(ts[i][:]) < string(ts[j][:])
+	})
+	return ts
+}
+
+// intersect returns the documents in both sorted lists.
+func intersect(a, b []int) []int {
+	res := a[:0]
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] < b[j]:
+			i++
+		case a[i] > b[j]:
+			j++
+		default:
+			res = append(res, a[i])
+			i++
+			j++
+		}
+	}
+	return res
+}
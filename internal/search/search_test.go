@@ -0,0 +1,140 @@
+package search
+
+import (
+	"errors"
+	"testing"
+)
+
+var bodies = map[string]string{
+	"07L1": "15:50:17.5 (1)|EXECUTION_STARTED\n15:50:17.5 (2)|USER_DEBUG|[4]|DEBUG|Account 001A000000abcde\n15:50:17.5 (3)|EXECUTION_FINISHED",
+	"07L2": "15:51:00.1 (1)|EXECUTION_STARTED\n15:51:00.1 (2)|FATAL_ERROR|System.NullPointerException: Attempt to de-reference a null object\n15:51:00.1 (3)|EXECUTION_FINISHED",
+	"07L3": "15:52:00.1 (1)|EXECUTION_STARTED\n15:52:00.1 (2)|USER_DEBUG|[9]|DEBUG|Contact 003A000000xyz\n15:52:00.1 (3)|EXECUTION_FINISHED",
+}
+
+func newTestIndex() (*Index, *int) {
+	loads := 0
+	ix := NewIndex(func(id string) (string, error) {
+		loads++
+		b, ok := bodies[id]
+		if !ok {
+			return "", errors.New("not found")
+		}
+		return b, nil
+	})
+	for _, id := range []string{"07L1", "07L2", "07L3"} {
+		ix.Add(id, bodies[id])
+	}
+	return ix, &loads
+}
+
+func TestSearch(t *testing.T) {
+	tests := []struct {
+		input     string
+		want      []Result
+		wantLoads int
+	}{
+		{"001A000000abcde", []Result{{"07L1", 1, "15:50:17.5 (2)|USER_DEBUG|[4]|DEBUG|Account 001A000000abcde"}}, 1},
+		{"nullpointer", []Result{{"07L2", 1, "15:51:00.1 (2)|FATAL_ERROR|System.NullPointerException: Attempt to de-reference a null object"}}, 1},
+		{"NULLPOINTER", nil, 1},
+		{"/USER_DEBUG.*(Account|Contact)/", []Result{
+			{"07L1", 1, "15:50:17.5 (2)|USER_DEBUG|[4]|DEBUG|Account 001A000000abcde"},
+			{"07L3", 1, "15:52:00.1 (2)|USER_DEBUG|[9]|DEBUG|Contact 003A000000xyz"},
+		}, 2},
+		{"EXECUTION_FINISHED", []Result{
+			{"07L1", 2, "15:50:17.5 (3)|EXECUTION_FINISHED"},
+			{"07L2", 2, "15:51:00.1 (3)|EXECUTION_FINISHED"},
+			{"07L3", 2, "15:52:00.1 (3)|EXECUTION_FINISHED"},
+		}, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			ix, loads := newTestIndex()
+
+			got, err := ix.Search(ParseQuery(tt.input), 0)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("expected %d results, got %v", len(tt.want), got)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("result %d: expected %v, got %v", i, tt.want[i], got[i])
+				}
+			}
+			if *loads != tt.wantLoads {
+				t.Errorf("expected %d logs to be scanned, got %d", tt.wantLoads, *loads)
+			}
+		})
+	}
+}
+
+func TestSearchLimit(t *testing.T) {
+	ix, _ := newTestIndex()
+
+	got, err := ix.Search(ParseQuery("EXECUTION"), 4)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(got) != 4 {
+		t.Errorf("expected 4 results, got %d", len(got))
+	}
+}
+
+func TestSearchInvalidRegex(t *testing.T) {
+	ix, _ := newTestIndex()
+
+	if _, err := ix.Search(ParseQuery("/USER_DEBUG(/"), 0); err == nil {
+		t.Errorf("expected an error for an invalid regular expression")
+	}
+}
+
+func TestParseQueryIgnoresCase(t *testing.T) {
+	tests := []struct {
+		input string
+		want  bool
+	}{
+		{"nullpointer", true},
+		{"NullPointer", false},
+		{`/\d+\s\S+/`, true},
+		{`/\W\D\B\pL\p{Lu}\x{263A}\x4A/`, true},
+		{`/\d+ Account/`, false},
+		{`/\.Null/`, false},
+		{`\S`, false},
+	}
+	for _, tt := range tests {
+		if got := ParseQuery(tt.input).IgnoreCase; got != tt.want {
+			t.Errorf("ParseQuery(%q).IgnoreCase = %t, want %t", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestAddIgnoresIndexedLogs(t *testing.T) {
+	ix, _ := newTestIndex()
+	ix.Add("07L1", bodies["07L1"])
+
+	if ix.Len() != 3 {
+		t.Errorf("expected 3 indexed logs, got %d", ix.Len())
+	}
+}
+
+func TestAddLoaded(t *testing.T) {
+	ix, loads := newTestIndex()
+	if err := ix.AddLoaded("07L1"); err != nil || *loads != 0 {
+		t.Errorf("expected an indexed log not to be loaded, got %d loads (%v)", *loads, err)
+	}
+	if err := ix.AddLoaded("07L4"); err == nil {
+		t.Errorf("expected an error loading a missing log")
+	}
+
+	bodies["07L4"] = "15:53:00.1 (1)|USER_DEBUG|[2]|DEBUG|Opportunity 006A000000opq"
+	defer delete(bodies, "07L4")
+	if err := ix.AddLoaded("07L4"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	got, err := ix.Search(ParseQuery("opportunity"), 0)
+	if err != nil || len(got) != 1 || got[0].LogId != "07L4" {
+		t.Errorf("expected the loaded log to be found, got %v (%v)", got, err)
+	}
+}
@@ -0,0 +1,288 @@
+// Package theme defines the colors of the user interface.
+//
+// The built-in themes are "dark", "light" and "high-contrast". Themes defined
+// by the user extend one of them, replacing some of its colors:
+//
+//	{"extends": "light", "focused": "#268bd2", "selectedBackground": "25"}
+//
+// Colors are ANSI color numbers from 0 to 255, or hex colors like "#268bd2".
+package theme
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/cdelmoral/apexlogs/internal/apexlog"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// The names of the built-in themes.
+const (
+	Dark         = "dark"
+	Light        = "light"
+	HighContrast = "high-contrast"
+	// Auto is the dark or the light theme, depending on the background color
+	// of the terminal.
+	Auto = "auto"
+)
+
+var hexColorRe = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// Theme is the colors of the user interface.
+type Theme struct {
+	// Extends is the built-in theme the colors not set in a user theme are
+	// taken from. The dark or the light one is chosen like with [Auto] when
+	// empty.
+	Extends string `json:"extends,omitempty"`
+	// Focused is the color of the border of the focused panel and of the current tab.
+	Focused lipgloss.Color `json:"focused,omitempty"`
+	// Base is the color of the borders of the other panels and of the status bar.
+	Base lipgloss.Color `json:"base,omitempty"`
+	// Dim is the color of the times and of the field separators of the log lines.
+	Dim lipgloss.Color `json:"dim,omitempty"`
+	// SelectedForeground and SelectedBackground are the colors of the
+	// selected rows of the panels and of the line under the cursor.
+	SelectedForeground lipgloss.Color `json:"selectedForeground,omitempty"`
+	SelectedBackground lipgloss.Color `json:"selectedBackground,omitempty"`
+	Warning            lipgloss.Color `json:"warning,omitempty"`
+	Error              lipgloss.Color `json:"error,omitempty"`
+	Success            lipgloss.Color `json:"success,omitempty"`
+	// Bookmark is the color of the marks of the bookmarked lines and of their notes.
+	Bookmark lipgloss.Color `json:"bookmark,omitempty"`
+	// Match and CurrentMatch are the backgrounds of the filter matches,
+	// written in MatchForeground.
+	Match           lipgloss.Color `json:"match,omitempty"`
+	CurrentMatch    lipgloss.Color `json:"currentMatch,omitempty"`
+	MatchForeground lipgloss.Color `json:"matchForeground,omitempty"`
+	// The colors of the event types of each category, see [apexlog.Category].
+	SOQL       lipgloss.Color `json:"soql,omitempty"`
+	DML        lipgloss.Color `json:"dml,omitempty"`
+	Debug      lipgloss.Color `json:"debug,omitempty"`
+	Exception  lipgloss.Color `json:"exception,omitempty"`
+	Limit      lipgloss.Color `json:"limit,omitempty"`
+	CodeUnit   lipgloss.Color `json:"codeUnit,omitempty"`
+	Method     lipgloss.Color `json:"method,omitempty"`
+	Validation lipgloss.Color `json:"validation,omitempty"`
+	Flow       lipgloss.Color `json:"flow,omitempty"`
+}
+
+var builtin = map[string]Theme{
+	Dark: {
+		Focused:            "12",
+		Base:               "7",
+		Dim:                "240",
+		SelectedForeground: "229",
+		SelectedBackground: "57",
+		Warning:            "11",
+		Error:              "9",
+		Success:            "10",
+		Bookmark:           "11",
+		Match:              "11",
+		CurrentMatch:       "208",
+		MatchForeground:    "0",
+		SOQL:               "12",
+		DML:                "13",
+		Debug:              "10",
+		Exception:          "9",
+		Limit:              "11",
+		CodeUnit:           "14",
+		Method:             "6",
+		Validation:         "3",
+		Flow:               "5",
+	},
+	// The bright colors of the dark theme are hard to read on a light
+	// background, so the light theme uses darker ones.
+	Light: {
+		Focused:            "4",
+		Base:               "244",
+		Dim:                "248",
+		SelectedForeground: "231",
+		SelectedBackground: "25",
+		Warning:            "130",
+		Error:              "1",
+		Success:            "28",
+		Bookmark:           "130",
+		Match:              "228",
+		CurrentMatch:       "214",
+		MatchForeground:    "0",
+		SOQL:               "4",
+		DML:                "5",
+		Debug:              "28",
+		Exception:          "1",
+		Limit:              "130",
+		CodeUnit:           "30",
+		Method:             "24",
+		Validation:         "94",
+		Flow:               "90",
+	},
+	HighContrast: {
+		Focused:            "14",
+		Base:               "15",
+		Dim:                "250",
+		SelectedForeground: "0",
+		SelectedBackground: "11",
+		Warning:            "11",
+		Error:              "9",
+		Success:            "10",
+		Bookmark:           "13",
+		Match:              "11",
+		CurrentMatch:       "13",
+		MatchForeground:    "0",
+		SOQL:               "14",
+		DML:                "13",
+		Debug:              "10",
+		Exception:          "9",
+		Limit:              "11",
+		CodeUnit:           "14",
+		Method:             "15",
+		Validation:         "11",
+		Flow:               "13",
+	},
+}
+
+// current is the theme the user interface is rendered with.
+var current = builtin[Dark]
+
+// Current returns the theme set with [Set], the dark one by default.
+func Current() Theme {
+	return current
+}
+
+// Set changes the theme of the user interface. The models read it when they
+// are created and when their focus changes, so it is set before creating them.
+func Set(t Theme) {
+	current = t
+}
+
+// Builtin returns the built-in theme with the given name.
+func Builtin(name string) (Theme, bool) {
+	t, ok := builtin[name]
+	return t, ok
+}
+
+// Resolve returns the built-in or user theme with the given name, which is
+// [Auto] when empty. hasDarkBackground reports the background of the
+// terminal, and is only called when the theme depends on it.
+func Resolve(name string, custom map[string]Theme, hasDarkBackground func() bool) (Theme, error) {
+	auto := func() Theme {
+		if hasDarkBackground() {
+			return builtin[Dark]
+		}
+		return builtin[Light]
+	}
+
+	if name == "" || name == Auto {
+		return auto(), nil
+	}
+	if t, ok := builtin[name]; ok {
+		return t, nil
+	}
+	user, ok := custom[name]
+	if !ok {
+		return Theme{}, fmt.Errorf("unknown theme %q, expected %s, %s, %s, %s or a theme of the configuration", name, Auto, Dark, Light, HighContrast)
+	}
+	if err := user.Validate(); err != nil {
+		return Theme{}, fmt.Errorf("theme %q: %w", name, err)
+	}
+	if user.Extends != "" {
+		return builtin[user.Extends].Merge(user), nil
+	}
+	return auto().Merge(user), nil
+}
+
+// Merge returns t with the colors set in o replacing its own.
+func (t Theme) Merge(o Theme) Theme {
+	colors := o.colors()
+	for name, c := range t.colors() {
+		if *colors[name] != "" {
+			*c = *colors[name]
+		}
+	}
+	return t
+}
+
+// Validate returns an error describing every color that is not valid.
+func (t Theme) Validate() error {
+	var errs []error
+	if _, ok := builtin[t.Extends]; t.Extends != "" && !ok {
+		errs = append(errs, fmt.Errorf("extends must be %s, %s or %s, got %q", Dark, Light, HighContrast, t.Extends))
+	}
+	colors := t.colors()
+	for _, name := range colorNames {
+		if c := *colors[name]; c != "" && !validColor(string(c)) {
+			errs = append(errs, fmt.Errorf("%s must be a color number from 0 to 255 or a hex color like \"#268bd2\", got %q", name, c))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func validColor(c string) bool {
+	if hexColorRe.MatchString(c) {
+		return true
+	}
+	n, err := strconv.Atoi(c)
+	return err == nil && n >= 0 && n <= 255 && strconv.Itoa(n) == c
+}
+
+// colorNames are the names of the colors in the configuration, in the order
+// of the fields.
+var colorNames = []string{
+	"focused", "base", "dim", "selectedForeground", "selectedBackground",
+	"warning", "error", "success", "bookmark", "match", "currentMatch", "matchForeground",
+	"soql", "dml", "debug", "exception", "limit", "codeUnit", "method", "validation", "flow",
+}
+
+// colors returns the colors of t by their name in the configuration.
+func (t *Theme) colors() map[string]*lipgloss.Color {
+	return map[string]*lipgloss.Color{
+		"focused":            &t.Focused,
+		"base":               &t.Base,
+		"dim":                &t.Dim,
+		"selectedForeground": &t.SelectedForeground,
+		"selectedBackground": &t.SelectedBackground,
+		"warning":            &t.Warning,
+		"error":              &t.Error,
+		"success":            &t.Success,
+		"bookmark":           &t.Bookmark,
+		"match":              &t.Match,
+		"currentMatch":       &t.CurrentMatch,
+		"matchForeground":    &t.MatchForeground,
+		"soql":               &t.SOQL,
+		"dml":                &t.DML,
+		"debug":              &t.Debug,
+		"exception":          &t.Exception,
+		"limit":              &t.Limit,
+		"codeUnit":           &t.CodeUnit,
+		"method":             &t.Method,
+		"validation":         &t.Validation,
+		"flow":               &t.Flow,
+	}
+}
+
+// CategoryColor returns the color of the event types of the given category,
+// which is empty for the events without a color.
+func (t Theme) CategoryColor(c apexlog.Category) lipgloss.Color {
+	switch c {
+	case apexlog.CategorySOQL:
+		return t.SOQL
+	case apexlog.CategoryDML:
+		return t.DML
+	case apexlog.CategoryDebug:
+		return t.Debug
+	case apexlog.CategoryException:
+		return t.Exception
+	case apexlog.CategoryLimit:
+		return t.Limit
+	case apexlog.CategoryCodeUnit:
+		return t.CodeUnit
+	case apexlog.CategoryMethod:
+		return t.Method
+	case apexlog.CategoryValidation:
+		return t.Validation
+	case apexlog.CategoryFlow:
+		return t.Flow
+	}
+	return ""
+}
@@ -0,0 +1,72 @@
+package theme
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	dark := func() bool { return true }
+	light := func() bool { return false }
+	custom := map[string]Theme{
+		"solarized": {Extends: HighContrast, Focused: "#268bd2"},
+		"accent":    {Focused: "208"},
+		"broken":    {Focused: "orange"},
+	}
+
+	for name, tc := range map[string]struct {
+		name              string
+		hasDarkBackground func() bool
+		want              Theme
+	}{
+		"auto on dark":    {"", dark, builtin[Dark]},
+		"auto on light":   {Auto, light, builtin[Light]},
+		"builtin":         {HighContrast, light, builtin[HighContrast]},
+		"user theme":      {"solarized", light, builtin[HighContrast].Merge(Theme{Focused: "#268bd2"})},
+		"user theme auto": {"accent", light, builtin[Light].Merge(Theme{Focused: "208"})},
+	} {
+		t.Run(name, func(t *testing.T) {
+			got, err := Resolve(tc.name, custom, tc.hasDarkBackground)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if got.Focused != tc.want.Focused || got.Base != tc.want.Base || got.Flow != tc.want.Flow {
+				t.Errorf("expected %+v, got %+v", tc.want, got)
+			}
+		})
+	}
+
+	if _, err := Resolve("solarised", custom, dark); err == nil || !strings.Contains(err.Error(), `unknown theme "solarised"`) {
+		t.Errorf("expected an unknown theme error, got %v", err)
+	}
+	if _, err := Resolve("broken", custom, dark); err == nil || !strings.Contains(err.Error(), "focused must be") {
+		t.Errorf("expected an invalid color error, got %v", err)
+	}
+}
+
+func TestResolveDetectsBackgroundOnlyWhenNeeded(t *testing.T) {
+	detect := func() bool {
+		t.Error("expected the background not to be detected")
+		return true
+	}
+	if _, err := Resolve(Light, nil, detect); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, err := Resolve("mine", map[string]Theme{"mine": {Extends: Dark}}, detect); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	valid := Theme{Extends: Light, Focused: "#268bd2", Base: "#fff", Dim: "0", Error: "255"}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+
+	err := Theme{Extends: "solarized", Focused: "256", Base: "#12345", Error: "red", Flow: "07"}.Validate()
+	for _, want := range []string{"extends must be", "focused must be", "base must be", "error must be", "flow must be"} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("expected the error to contain %q, got %v", want, err)
+		}
+	}
+}
@@ -0,0 +1,350 @@
+// Package traceflag keeps the debug log trace flag of a Salesforce user active
+// while the application is running.
+package traceflag
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	sf "github.com/cdelmoral/apexlogs/internal/salesforce"
+)
+
+const (
+	// MaxDuration is the longest time a trace flag can be active for.
+	// Salesforce rejects trace flags expiring more than 24 hours after their start date.
+	MaxDuration = 24 * time.Hour
+	// DefaultDuration is the duration used when none is configured.
+	DefaultDuration = 30 * time.Minute
+
+	minDuration      = time.Minute
+	maxRenewBefore   = 10 * time.Minute
+	errorRetryPeriod = 30 * time.Second
+)
+
+// A Cleanup is the action performed on the trace flag when the manager stops.
+type Cleanup string
+
+const (
+	// CleanupNone leaves the trace flag active until it expires.
+	CleanupNone Cleanup = "none"
+	// CleanupExpire sets the expiration date of the trace flag to the current time.
+	CleanupExpire Cleanup = "expire"
+	// CleanupDelete deletes the trace flag.
+	CleanupDelete Cleanup = "delete"
+)
+
+// ParseCleanup returns the [Cleanup] with the given name.
+func ParseCleanup(s string) (Cleanup, error) {
+	switch c := Cleanup(s); c {
+	case CleanupNone, CleanupExpire, CleanupDelete:
+		return c, nil
+	}
+	return "", fmt.Errorf("unknown trace flag cleanup %q, expected one of none, expire or delete", s)
+}
+
+// ValidateDuration returns an error if d is not a valid trace flag duration.
+func ValidateDuration(d time.Duration) error {
+	if d < minDuration || d > MaxDuration {
+		return fmt.Errorf("trace flag duration must be between %s and %s, got %s", minDuration, MaxDuration, d)
+	}
+	return nil
+}
+
+// A NotFoundError is an error that occurs when a trace flag is not found.
+type NotFoundError struct {
+	s string
+}
+
+func (e *NotFoundError) Error() string {
+	return e.s
+}
+
+// Status is the state of the trace flag after it was checked by the manager.
+// Err is set when the trace flag could not be renewed.
+type Status struct {
+	TraceFlagId    string
+	ExpirationDate time.Time
+	Err            error
+}
+
+// Remaining returns the time left until the trace flag expires.
+func (s Status) Remaining(now time.Time) time.Duration {
+	return max(s.ExpirationDate.Sub(now), 0)
+}
+
+// An Option configures a [Manager].
+type Option func(*Manager)
+
+// WithDuration sets how long the trace flag stays active after every renewal.
+func WithDuration(d time.Duration) Option {
+	return func(m *Manager) {
+		m.duration = d
+	}
+}
+
+// WithCleanup sets the action performed on the trace flag when the manager stops.
+func WithCleanup(c Cleanup) Option {
+	return func(m *Manager) {
+		m.cleanup = c
+	}
+}
+
+// A Manager creates the trace flag of a user and renews it in the background before it expires.
+// A trace flag created by another tool is renewed too, but it is left in place when the manager stops.
+type Manager struct {
+	client       *sf.Client
+	userId       string
+	debugLevelId string
+	duration     time.Duration
+	cleanup      Cleanup
+	updates      chan Status
+	done         chan struct{}
+	traceFlagId  string
+	// created is set when the trace flag was created by the manager.
+	created bool
+	// prevDebugLevelId is the debug level the trace flag had before the
+	// manager changed it, restored on stop if the trace flag was not created
+	// by the manager.
+	prevDebugLevelId string
+	now              func() time.Time
+}
+
+// New creates a new [Manager] for the trace flag of the given user.
+// An error is returned if the options are not valid.
+func New(client *sf.Client, userId, debugLevelId string, opts ...Option) (*Manager, error) {
+	m := &Manager{
+		client:       client,
+		userId:       userId,
+		debugLevelId: debugLevelId,
+		duration:     DefaultDuration,
+		cleanup:      CleanupNone,
+		updates:      make(chan Status, 1),
+		now:          time.Now,
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	if err := ValidateDuration(m.duration); err != nil {
+		return nil, err
+	}
+	if _, err := ParseCleanup(string(m.cleanup)); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// Start makes sure the trace flag is active and keeps renewing it until ctx is cancelled.
+// The initial status is returned, later ones are sent to [Manager.Updates].
+// An error is returned if the trace flag cannot be activated.
+func (m *Manager) Start(ctx context.Context) (Status, error) {
+	s := m.ensure(ctx)
+	if s.Err != nil {
+		return s, s.Err
+	}
+
+	m.done = make(chan struct{})
+	go m.run(ctx, s)
+
+	return s, nil
+}
+
+// Updates returns a channel receiving the status of the trace flag after every renewal attempt.
+// Only the latest status is kept if nobody is receiving them.
+func (m *Manager) Updates() <-chan Status {
+	return m.updates
+}
+
+// Stop performs the configured cleanup of the trace flag if it was created by the manager.
+// Otherwise the trace flag is kept and its previous debug level is restored.
+// It must be called after the context passed to [Manager.Start] is cancelled,
+// it waits for the renewals running in the background to finish.
+func (m *Manager) Stop(ctx context.Context) error {
+	if m.done != nil {
+		select {
+		case <-m.done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	if m.traceFlagId == "" {
+		return nil
+	}
+
+	if !m.created {
+		return m.restoreDebugLevel(ctx)
+	}
+
+	switch m.cleanup {
+	case CleanupExpire:
+		payload := map[string]string{
+			"ExpirationDate": m.now().UTC().Format(sf.DateTimeLayout),
+		}
+		if err := sf.PatchSObject(ctx, m.client, "TraceFlag", m.traceFlagId, payload); err != nil {
+			return fmt.Errorf("error expiring trace flag with id %s: %w", m.traceFlagId, err)
+		}
+	case CleanupDelete:
+		if err := sf.DeleteSObject(ctx, m.client, "TraceFlag", m.traceFlagId); err != nil {
+			return fmt.Errorf("error deleting trace flag with id %s: %w", m.traceFlagId, err)
+		}
+	}
+
+	return nil
+}
+
+// restoreDebugLevel sets the debug level of a trace flag not created by the
+// manager back to the one it had before the manager changed it.
+func (m *Manager) restoreDebugLevel(ctx context.Context) error {
+	if m.prevDebugLevelId == "" {
+		return nil
+	}
+
+	payload := map[string]string{"DebugLevelId": m.prevDebugLevelId}
+	if err := sf.PatchSObject(ctx, m.client, "TraceFlag", m.traceFlagId, payload); err != nil {
+		return fmt.Errorf("error restoring debug level of trace flag with id %s: %w", m.traceFlagId, err)
+	}
+	return nil
+}
+
+func (m *Manager) run(ctx context.Context, s Status) {
+	defer close(m.done)
+	for {
+		t := time.NewTimer(m.nextCheck(s))
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return
+		case <-t.C:
+		}
+
+		next := m.ensure(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if next.Err != nil {
+			// Keep the last known expiration date so the remaining time is still displayed.
+			next.TraceFlagId, next.ExpirationDate = s.TraceFlagId, s.ExpirationDate
+		}
+		s = next
+		m.publish(s)
+	}
+}
+
+// nextCheck returns how long to wait before checking the trace flag again.
+func (m *Manager) nextCheck(s Status) time.Duration {
+	if s.Err != nil {
+		return errorRetryPeriod
+	}
+	return max(s.Remaining(m.now())-m.renewBefore(), minDuration/2)
+}
+
+// renewBefore returns how long before its expiration the trace flag is renewed.
+func (m *Manager) renewBefore() time.Duration {
+	return min(m.duration/3, maxRenewBefore)
+}
+
+func (m *Manager) publish(s Status) {
+	select {
+	case <-m.updates:
+	default:
+	}
+	m.updates <- s
+}
+
+// ensure creates the trace flag if it does not exist or extends it if it is
+// about to expire, setting its debug level if it is not the configured one.
+// The previous debug level of a trace flag created by another tool is kept,
+// so that it can be restored on stop.
+func (m *Manager) ensure(ctx context.Context) Status {
+	traceFlag, err := m.find(ctx)
+
+	var notFound *NotFoundError
+	if errors.As(err, &notFound) {
+		return m.create(ctx)
+	}
+	if err != nil {
+		return Status{Err: err}
+	}
+	if traceFlag.Id != m.traceFlagId {
+		m.traceFlagId = traceFlag.Id
+		m.created = false
+		m.prevDebugLevelId = ""
+	}
+
+	expirationDate, err := time.Parse(sf.DateTimeLayout, traceFlag.ExpirationDate)
+	if err != nil {
+		return Status{
+			TraceFlagId: traceFlag.Id,
+			Err:         fmt.Errorf("unexpected format found for trace flag expiration date: %s", traceFlag.ExpirationDate),
+		}
+	}
+
+	s := Status{TraceFlagId: traceFlag.Id, ExpirationDate: expirationDate}
+	renew := s.Remaining(m.now()) <= m.renewBefore()
+	// The trace flag may have been created with another debug level, by an
+	// earlier run or by another tool.
+	changeLevel := m.debugLevelId != "" && traceFlag.DebugLevelId != m.debugLevelId
+	if !renew && !changeLevel {
+		return s
+	}
+
+	patchPayload := map[string]string{}
+	if renew {
+		now := m.now().UTC()
+		expirationDate = now.Add(m.duration)
+		patchPayload["ExpirationDate"] = expirationDate.Format(sf.DateTimeLayout)
+		patchPayload["StartDate"] = now.Format(sf.DateTimeLayout)
+	}
+	if changeLevel {
+		patchPayload["DebugLevelId"] = m.debugLevelId
+	}
+	if err := sf.PatchSObject(ctx, m.client, "TraceFlag", traceFlag.Id, patchPayload); err != nil {
+		s.Err = fmt.Errorf("error sending request to update trace flag with id %s: %w", traceFlag.Id, err)
+		return s
+	}
+	if changeLevel && !m.created && m.prevDebugLevelId == "" {
+		m.prevDebugLevelId = traceFlag.DebugLevelId
+	}
+
+	s.ExpirationDate = expirationDate
+	return s
+}
+
+func (m *Manager) find(ctx context.Context) (sf.TraceFlag, error) {
+	traceFlagQuery := sf.SelectDebugLogTraceFlagByTracedId(m.userId)
+	queryResult, err := sf.DoQuery[sf.TraceFlag](ctx, m.client, traceFlagQuery)
+	if err != nil {
+		return sf.TraceFlag{}, fmt.Errorf("error querying trace flag record: %w", err)
+	}
+
+	if queryResult.TotalSize == 0 {
+		return sf.TraceFlag{}, &NotFoundError{"trace flag of type debug log not found"}
+	}
+
+	return queryResult.Records[0], nil
+}
+
+func (m *Manager) create(ctx context.Context) Status {
+	now := m.now().UTC()
+	expirationDate := now.Add(m.duration)
+	traceFlag := map[string]any{
+		"TracedEntityId": m.userId,
+		"DebugLevelId":   m.debugLevelId,
+		"LogType":        "DEVELOPER_LOG",
+		"StartDate":      now.Format(sf.DateTimeLayout),
+		"ExpirationDate": expirationDate.Format(sf.DateTimeLayout),
+	}
+	postResult, err := sf.PostSObject(ctx, m.client, "TraceFlag", traceFlag)
+	if err != nil {
+		return Status{Err: fmt.Errorf("error creating trace flag record: %w", err)}
+	}
+
+	m.traceFlagId = postResult.Id
+	m.created = true
+	m.prevDebugLevelId = ""
+	return Status{TraceFlagId: postResult.Id, ExpirationDate: expirationDate}
+}
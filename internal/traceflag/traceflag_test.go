@@ -0,0 +1,227 @@
+package traceflag
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	sf "github.com/cdelmoral/apexlogs/internal/salesforce"
+	"github.com/cdelmoral/apexlogs/internal/salesforce/sftest"
+)
+
+const testDebugLevelId = "7dl000000000001"
+
+func newTestManager(t *testing.T, srv *sftest.Server, opts ...Option) *Manager {
+	t.Helper()
+	m, err := New(srv.Client(sf.WithRetries(0)), sftest.UserId, testDebugLevelId, opts...)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	return m
+}
+
+func addTraceFlag(srv *sftest.Server, expiration time.Time) string {
+	return srv.AddRecord("TraceFlag", map[string]any{
+		"TracedEntityId": sftest.UserId,
+		"DebugLevelId":   testDebugLevelId,
+		"LogType":        "DEVELOPER_LOG",
+		"ExpirationDate": expiration.UTC().Format(sf.DateTimeLayout),
+	})
+}
+
+func TestNewValidatesOptions(t *testing.T) {
+	srv := sftest.New(sftest.FixturesDir())
+	c := srv.Client()
+
+	if _, err := New(c, sftest.UserId, "", WithDuration(25*time.Hour)); err == nil {
+		t.Errorf("expected an error for a duration longer than 24 hours")
+	}
+	if _, err := New(c, sftest.UserId, "", WithCleanup("forget")); err == nil {
+		t.Errorf("expected an error for an unknown cleanup")
+	}
+}
+
+func TestEnsureCreatesTraceFlag(t *testing.T) {
+	srv := sftest.New(sftest.FixturesDir())
+	m := newTestManager(t, srv, WithDuration(2*time.Hour))
+
+	s := m.ensure(context.Background())
+	if s.Err != nil {
+		t.Fatalf("unexpected error: %s", s.Err)
+	}
+
+	records := srv.Records("TraceFlag")
+	if len(records) != 1 || records[0]["Id"] != s.TraceFlagId {
+		t.Fatalf("expected the trace flag to be created, got %v", records)
+	}
+	if r := s.Remaining(time.Now()); r < 119*time.Minute || r > 2*time.Hour {
+		t.Errorf("unexpected remaining time %s", r)
+	}
+}
+
+func TestEnsureExtendsExpiringTraceFlag(t *testing.T) {
+	tests := []struct {
+		name       string
+		remaining  time.Duration
+		wantUpdate bool
+	}{
+		{"far from expiration", 20 * time.Minute, false},
+		{"about to expire", 5 * time.Minute, true},
+		{"expired", -time.Hour, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := sftest.New(sftest.FixturesDir())
+			id := addTraceFlag(srv, time.Now().Add(tt.remaining))
+			m := newTestManager(t, srv)
+
+			s := m.ensure(context.Background())
+			if s.Err != nil || s.TraceFlagId != id {
+				t.Fatalf("unexpected status: %+v", s)
+			}
+
+			updated := false
+			for _, r := range srv.Requests() {
+				updated = updated || r.Method == http.MethodPatch
+			}
+			if updated != tt.wantUpdate {
+				t.Errorf("expected update %t, got %t", tt.wantUpdate, updated)
+			}
+			if tt.wantUpdate && s.Remaining(time.Now()) < 29*time.Minute {
+				t.Errorf("expected the trace flag to be extended, %s remaining", s.Remaining(time.Now()))
+			}
+		})
+	}
+}
+
+func TestEnsureChangesDebugLevel(t *testing.T) {
+	srv := sftest.New(sftest.FixturesDir())
+	expiration := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	id := srv.AddRecord("TraceFlag", map[string]any{
+		"TracedEntityId": sftest.UserId,
+		"DebugLevelId":   "7dl000000000002",
+		"LogType":        "DEVELOPER_LOG",
+		"ExpirationDate": expiration.Format(sf.DateTimeLayout),
+	})
+	m := newTestManager(t, srv)
+
+	s := m.ensure(context.Background())
+	if s.Err != nil || s.TraceFlagId != id {
+		t.Fatalf("unexpected status: %+v", s)
+	}
+
+	records := srv.Records("TraceFlag")
+	if len(records) != 1 || records[0]["DebugLevelId"] != testDebugLevelId {
+		t.Errorf("expected the debug level to be changed, got %v", records)
+	}
+	if !s.ExpirationDate.Equal(expiration) {
+		t.Errorf("expected the expiration date to be kept, got %s", s.ExpirationDate)
+	}
+}
+
+func TestEnsureReportsErrors(t *testing.T) {
+	srv := sftest.New(sftest.FixturesDir())
+	addTraceFlag(srv, time.Now())
+	m := newTestManager(t, srv)
+
+	srv.FailNext(1, http.StatusInternalServerError, "")
+	s := m.ensure(context.Background())
+
+	var resErr *sf.ResponseError
+	if !errors.As(s.Err, &resErr) {
+		t.Errorf("expected a response error, got %v", s.Err)
+	}
+}
+
+func TestStopCleansUpTraceFlag(t *testing.T) {
+	tests := []struct {
+		cleanup     Cleanup
+		wantRecords int
+		wantExpired bool
+	}{
+		{CleanupNone, 1, false},
+		{CleanupExpire, 1, true},
+		{CleanupDelete, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.cleanup), func(t *testing.T) {
+			srv := sftest.New(sftest.FixturesDir())
+			m := newTestManager(t, srv, WithCleanup(tt.cleanup))
+
+			ctx, cancel := context.WithCancel(context.Background())
+			if _, err := m.Start(ctx); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			cancel()
+
+			if err := m.Stop(context.Background()); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			records := srv.Records("TraceFlag")
+			if len(records) != tt.wantRecords {
+				t.Fatalf("expected %d trace flags, got %d", tt.wantRecords, len(records))
+			}
+			if tt.wantRecords == 0 {
+				return
+			}
+			exp, _ := time.Parse(sf.DateTimeLayout, records[0]["ExpirationDate"].(string))
+			if expired := !exp.After(time.Now()); expired != tt.wantExpired {
+				t.Errorf("expected expired %t, got %t", tt.wantExpired, expired)
+			}
+		})
+	}
+}
+
+func TestStopKeepsExistingTraceFlag(t *testing.T) {
+	for _, cleanup := range []Cleanup{CleanupExpire, CleanupDelete} {
+		t.Run(string(cleanup), func(t *testing.T) {
+			srv := sftest.New(sftest.FixturesDir())
+			expiration := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+			srv.AddRecord("TraceFlag", map[string]any{
+				"TracedEntityId": sftest.UserId,
+				"DebugLevelId":   "7dl000000000002",
+				"LogType":        "DEVELOPER_LOG",
+				"ExpirationDate": expiration.Format(sf.DateTimeLayout),
+			})
+			m := newTestManager(t, srv, WithCleanup(cleanup))
+
+			ctx, cancel := context.WithCancel(context.Background())
+			if _, err := m.Start(ctx); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			cancel()
+
+			if err := m.Stop(context.Background()); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			records := srv.Records("TraceFlag")
+			if len(records) != 1 {
+				t.Fatalf("expected the trace flag to be kept, got %v", records)
+			}
+			if records[0]["DebugLevelId"] != "7dl000000000002" {
+				t.Errorf("expected the debug level to be restored, got %v", records[0]["DebugLevelId"])
+			}
+			if records[0]["ExpirationDate"] != expiration.Format(sf.DateTimeLayout) {
+				t.Errorf("expected the expiration date to be kept, got %v", records[0]["ExpirationDate"])
+			}
+		})
+	}
+}
+
+func TestPublishKeepsLatestStatus(t *testing.T) {
+	srv := sftest.New(sftest.FixturesDir())
+	m := newTestManager(t, srv)
+
+	m.publish(Status{TraceFlagId: "first"})
+	m.publish(Status{TraceFlagId: "second"})
+
+	if s := <-m.Updates(); s.TraceFlagId != "second" {
+		t.Errorf("expected the latest status, got %+v", s)
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"os"
 
@@ -9,13 +10,53 @@ import (
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "download":
+			os.Exit(downloadCmd(os.Args[2:]))
+		case "view":
+			os.Exit(viewCmd(os.Args[2:]))
+		case "run":
+			os.Exit(runCmd(os.Args[2:]))
+		case "test":
+			os.Exit(testCmd(os.Args[2:]))
+		}
+	}
+
+	opts := loadOptions()
+	orgFlags := addOrgFlags(flag.CommandLine, &opts)
+	flag.BoolVar(&opts.Offline, "offline", false, "browse the logs in the local cache without connecting to the org")
+	flag.Parse()
+
+	if err := orgFlags.validate(); err != nil {
+		fmt.Fprintln(os.Stderr, "fatal:", err)
+		os.Exit(2)
+	}
+
+	os.Exit(start(opts))
+}
+
+// loadOptions returns the options configured by the configuration files,
+// exiting if they are not valid.
+func loadOptions() app.Options {
+	opts, err := app.LoadOptions()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "fatal:", err)
+		os.Exit(2)
+	}
+	return opts
+}
+
+// start runs the application and returns the exit code.
+func start(opts app.Options) int {
 	// TODO: Temporary log configuration
 	f, err := tea.LogToFile("debug.log", "debug")
 	if err != nil {
 		fmt.Println("fatal:", err)
-		os.Exit(1)
+		return 1
 	}
 	defer f.Close()
 
-	app.Start()
+	app.Start(opts)
+	return 0
 }
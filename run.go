@@ -0,0 +1,59 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// runCmd runs the run subcommand and returns the exit code.
+func runCmd(args []string) int {
+	opts := loadOptions()
+	fs := flag.NewFlagSet("run", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: apexlogs run [flags] [file]")
+		fmt.Fprintln(fs.Output(), "\nExecutes anonymous Apex in the default org and opens its log.")
+		fmt.Fprintln(fs.Output(), "The code is read from the standard input when the file is - or no file is given.")
+		fmt.Fprintln(fs.Output())
+		fs.PrintDefaults()
+	}
+	orgFlags := addOrgFlags(fs, &opts)
+	fs.Parse(args)
+
+	if fs.NArg() > 1 {
+		fs.Usage()
+		return 2
+	}
+	if err := orgFlags.validate(); err != nil {
+		fmt.Fprintln(os.Stderr, "fatal:", err)
+		return 2
+	}
+	var r io.Reader = os.Stdin
+	if path := fs.Arg(0); path != "" && path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "fatal:", err)
+			return 2
+		}
+		defer f.Close()
+		r = f
+	} else if stat, err := os.Stdin.Stat(); err == nil && stat.Mode()&os.ModeCharDevice != 0 {
+		fs.Usage()
+		return 2
+	}
+
+	code, err := io.ReadAll(r)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "fatal:", err)
+		return 1
+	}
+	opts.AnonymousApex = strings.TrimSpace(string(code))
+	if opts.AnonymousApex == "" {
+		fmt.Fprintln(os.Stderr, "fatal: no anonymous apex to run")
+		return 2
+	}
+
+	return start(opts)
+}
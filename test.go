@@ -0,0 +1,52 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// classList is a flag that can be repeated, and accepts comma separated class names.
+type classList []string
+
+func (l *classList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *classList) Set(s string) error {
+	for _, c := range strings.Split(s, ",") {
+		if c = strings.TrimSpace(c); c != "" {
+			*l = append(*l, c)
+		}
+	}
+	return nil
+}
+
+// testCmd runs the test subcommand and returns the exit code.
+func testCmd(args []string) int {
+	opts := loadOptions()
+	fs := flag.NewFlagSet("test", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: apexlogs test --class name [flags]")
+		fmt.Fprintln(fs.Output(), "\nRuns the tests of Apex classes in the default org and shows their results next to their logs.")
+		fmt.Fprintln(fs.Output())
+		fs.PrintDefaults()
+	}
+	var classes classList
+	fs.Var(&classes, "class", "Apex class whose tests are run, can be repeated or comma separated")
+	orgFlags := addOrgFlags(fs, &opts)
+	fs.Parse(args)
+
+	if len(classes) == 0 || fs.NArg() > 0 {
+		fs.Usage()
+		return 2
+	}
+	if err := orgFlags.validate(); err != nil {
+		fmt.Fprintln(os.Stderr, "fatal:", err)
+		return 2
+	}
+	opts.TestClasses = classes
+
+	return start(opts)
+}
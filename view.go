@@ -0,0 +1,42 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+)
+
+// viewCmd runs the view subcommand and returns the exit code.
+func viewCmd(args []string) int {
+	opts := loadOptions()
+	fs := flag.NewFlagSet("view", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: apexlogs view [flags] [path ...]")
+		fmt.Fprintln(fs.Output(), "\nOpens log files, or directories of .log files, without connecting to an org.")
+		fmt.Fprintln(fs.Output(), "A log is read from the standard input when the path is - or no path is given.")
+		fmt.Fprintln(fs.Output())
+		fs.PrintDefaults()
+	}
+	fs.StringVar(&opts.DownloadDir, "download-dir", opts.DownloadDir, "directory the listed logs are downloaded to")
+	fs.Parse(args)
+
+	opts.LocalPaths = fs.Args()
+	if len(opts.LocalPaths) == 0 {
+		if stat, err := os.Stdin.Stat(); err == nil && stat.Mode()&os.ModeCharDevice != 0 {
+			fs.Usage()
+			return 2
+		}
+		opts.LocalPaths = []string{"-"}
+	}
+	for _, p := range opts.LocalPaths {
+		if p == "-" {
+			continue
+		}
+		if _, err := os.Stat(p); err != nil {
+			fmt.Fprintln(os.Stderr, "fatal:", err)
+			return 2
+		}
+	}
+
+	return start(opts)
+}